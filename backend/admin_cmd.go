@@ -0,0 +1,401 @@
+package main
+
+import (
+	"backend/mail"
+	"backend/store"
+	"backend/utils"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const userUsage = `usage: server user <command>
+
+  list [--role r] [--published]          แสดงผู้ใช้ทั้งหมด (แทน check_dashboard_status.sql)
+  show <id|email>                        ข้อมูลผู้ใช้ skills โปรเจค และสถานะบน dashboard
+  create --email e [--password p] [--name n] [--role r] [--university u] [--locale th|en]
+                                         สร้างบัญชี (ไม่ใส่ --password จะสุ่มให้และแสดงครั้งเดียว)
+  delete <id|email> --yes                ลบบัญชีพร้อมโปรเจค, session และ snapshot บน dashboard
+  set-role <id|email> <role>             student | recruiter | university_admin | platform_admin
+  set-university <id|email> <university> มหาวิทยาลัยที่ university admin ดูแล (admin แก้เองผ่าน API ไม่ได้)
+  reset-password <id|email> [--password p]
+                                         ตั้งรหัสผ่านใหม่ ล้างการล็อก และ revoke ทุก session`
+
+const publishUsage = `usage: server publish resync (--user <id|email> | --all)
+
+  สร้าง published_profiles / published_projects ใหม่จากข้อมูลปัจจุบัน
+  user ที่ show_on_dashboard และไม่ถูกระงับจะถูก publish ซ้ำ ที่เหลือถูกลบออกจาก dashboard`
+
+const codesUsage = `usage: server codes purge-expired
+
+  ลบรหัส OTP และ reset token ที่หมดอายุหรือใช้ไปแล้ว`
+
+// generatedPasswordLength คือความยาวของรหัสผ่านที่สุ่มให้ (hex — 80 bit)
+const generatedPasswordLength = 20
+
+// runAdminCommand จัดการ `server user|publish|codes ...` แล้วคืน exit code
+func runAdminCommand(st *store.Store, args []string) int {
+	ctx := context.Background()
+
+	switch args[0] {
+	case "user":
+		return runUserCommand(ctx, st, args[1:])
+	case "publish":
+		return runPublishCommand(ctx, st, args[1:])
+	case "codes":
+		return runCodesCommand(ctx, st, args[1:])
+	}
+	fmt.Fprintln(os.Stderr, commandUsage)
+	return 2
+}
+
+func runUserCommand(ctx context.Context, st *store.Store, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, userUsage) }
+	role := fs.String("role", "", "")
+	published := fs.Bool("published", false, "")
+	email := fs.String("email", "", "")
+	password := fs.String("password", "", "")
+	name := fs.String("name", "", "")
+	university := fs.String("university", "", "")
+	locale := fs.String("locale", "", "")
+	yes := fs.Bool("yes", false, "")
+
+	rest, err := parseInterleaved(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		if len(rest) != 0 {
+			break
+		}
+		users, err := st.Users.List(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE\tVERIFIED\tDASHBOARD\tSTATUS")
+		for _, u := range users {
+			if (*role != "" && u.Role != *role) || (*published && !u.ShowOnDashboard) {
+				continue
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.UserName, u.Role, mark(u.EmailVerified), mark(u.ShowOnDashboard), userStatus(u))
+		}
+		if err := w.Flush(); err != nil {
+			return fail(err)
+		}
+		return 0
+
+	case "show":
+		if len(rest) != 1 {
+			break
+		}
+		u, err := findUser(ctx, st.Users, rest[0])
+		if err != nil {
+			return fail(err)
+		}
+		projects, err := st.Projects.List(ctx, u.ID)
+		if err != nil {
+			return fail(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ID\t%d\n", u.ID)
+		fmt.Fprintf(w, "Email\t%s (verified %s)\n", u.Email, mark(u.EmailVerified))
+		fmt.Fprintf(w, "Name\t%s\n", u.UserName)
+		fmt.Fprintf(w, "Role\t%s\n", u.Role)
+		fmt.Fprintf(w, "Locale\t%s\n", u.Locale)
+		fmt.Fprintf(w, "Status\t%s\n", userStatus(*u))
+		fmt.Fprintf(w, "University\t%s / %s / %s (GPA %.2f)\n", u.University, u.Faculty, u.Major, u.GPA)
+		fmt.Fprintf(w, "Skills\t%s\n", strings.Join(u.Skills, ", "))
+		fmt.Fprintf(w, "Projects\t%d\n", len(projects))
+		switch profile, err := st.Dashboard.Get(ctx, u.ID); {
+		case errors.Is(err, store.ErrNotFound):
+			fmt.Fprintf(w, "Dashboard\t%s not published (show_on_dashboard %s)\n", mark(false), mark(u.ShowOnDashboard))
+		case err != nil:
+			return fail(err)
+		default:
+			fmt.Fprintf(w, "Dashboard\t%s published %s, %d projects\n", mark(true), profile.UpdatedAt.Format("2006-01-02 15:04:05"), len(profile.Projects))
+		}
+		if err := w.Flush(); err != nil {
+			return fail(err)
+		}
+		return 0
+
+	case "create":
+		if len(rest) != 0 || strings.TrimSpace(*email) == "" {
+			break
+		}
+		r := *role
+		if r == "" {
+			r = utils.RoleStudent
+		}
+		if !utils.IsValidRole(r) {
+			return fail(fmt.Errorf("ไม่รู้จัก role %q", r))
+		}
+		pw, generated, err := passwordOrGenerate(*password)
+		if err != nil {
+			return fail(err)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		if err != nil {
+			return fail(err)
+		}
+		emailNorm := strings.ToLower(strings.TrimSpace(*email))
+		userID, err := st.Users.Create(ctx, store.NewUser{
+			Email:        emailNorm,
+			PasswordHash: string(hash),
+			UserName:     *name,
+			University:   strings.TrimSpace(*university),
+			Role:         r,
+			Locale:       mail.NormalizeLocale(*locale),
+		})
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Printf("✅ Created user %d %s (%s)\n", userID, emailNorm, r)
+		if generated {
+			fmt.Printf("   password: %s\n", pw)
+		}
+		return 0
+
+	case "delete":
+		if len(rest) != 1 {
+			break
+		}
+		u, err := findUser(ctx, st.Users, rest[0])
+		if err != nil {
+			return fail(err)
+		}
+		if !*yes {
+			fmt.Fprintf(os.Stderr, "จะลบ user %d %s พร้อมโปรเจค, session และ snapshot บน dashboard — ใส่ --yes เพื่อยืนยัน\n", u.ID, u.Email)
+			return 1
+		}
+		if err := st.Users.Delete(ctx, u.ID); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("🗑️  Deleted user %d %s\n", u.ID, u.Email)
+		return 0
+
+	case "set-role":
+		if len(rest) != 2 {
+			break
+		}
+		if !utils.IsValidRole(rest[1]) {
+			return fail(fmt.Errorf("ไม่รู้จัก role %q", rest[1]))
+		}
+		u, err := findUser(ctx, st.Users, rest[0])
+		if err != nil {
+			return fail(err)
+		}
+		if err := st.Users.SetRole(ctx, u.ID, rest[1]); err != nil {
+			return fail(err)
+		}
+		// access token ที่ออกไปแล้วยังถือ role เดิมจนหมดอายุ — role ใหม่มีผลตั้งแต่ refresh ครั้งถัดไป
+		fmt.Printf("✅ User %d %s: %s → %s\n", u.ID, u.Email, u.Role, rest[1])
+		return 0
+
+	case "set-university":
+		if len(rest) != 2 {
+			break
+		}
+		u, err := findUser(ctx, st.Users, rest[0])
+		if err != nil {
+			return fail(err)
+		}
+		// UpdateProfile แทนที่ทั้งชุด — ส่งค่าเดิมทุกช่องยกเว้นมหาวิทยาลัย
+		if err := st.Users.UpdateProfile(ctx, u.ID, store.ProfileUpdate{
+			UserName:        u.UserName,
+			Phone:           u.Phone,
+			University:      strings.TrimSpace(rest[1]),
+			Faculty:         u.Faculty,
+			Major:           u.Major,
+			GPA:             u.GPA,
+			JobInterest:     u.JobInterest,
+			ProfileImageURL: u.ProfileImageURL,
+			Locale:          u.Locale,
+			Skills:          u.Skills,
+		}); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("✅ User %d %s: university %q → %q\n", u.ID, u.Email, u.University, strings.TrimSpace(rest[1]))
+		return 0
+
+	case "reset-password":
+		if len(rest) != 1 {
+			break
+		}
+		u, err := findUser(ctx, st.Users, rest[0])
+		if err != nil {
+			return fail(err)
+		}
+		pw, generated, err := passwordOrGenerate(*password)
+		if err != nil {
+			return fail(err)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		if err != nil {
+			return fail(err)
+		}
+		if err := st.Users.SetPassword(ctx, u.ID, string(hash)); err != nil {
+			return fail(err)
+		}
+		fmt.Printf("✅ Password reset for user %d %s (all sessions revoked)\n", u.ID, u.Email)
+		if generated {
+			fmt.Printf("   password: %s\n", pw)
+		}
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, userUsage)
+	return 2
+}
+
+func runPublishCommand(ctx context.Context, st *store.Store, args []string) int {
+	if len(args) == 0 || args[0] != "resync" {
+		fmt.Fprintln(os.Stderr, publishUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("publish resync", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, publishUsage) }
+	one := fs.String("user", "", "")
+	all := fs.Bool("all", false, "")
+	rest, err := parseInterleaved(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+	if len(rest) != 0 || (*one == "") == !*all {
+		fmt.Fprintln(os.Stderr, publishUsage)
+		return 2
+	}
+
+	var users []store.User
+	if *all {
+		if users, err = st.Users.List(ctx); err != nil {
+			return fail(err)
+		}
+	} else {
+		u, err := findUser(ctx, st.Users, *one)
+		if err != nil {
+			return fail(err)
+		}
+		users = []store.User{*u}
+	}
+
+	published, removed := 0, 0
+	for _, u := range users {
+		// show_on_dashboard คือสิ่งที่ user เลือกไว้ — ผู้ที่ถูกระงับไม่แสดง
+		// ไม่ตรวจ verified เพราะคนที่ publish ไว้ก่อนมีการยืนยันอีเมลต้องยังอยู่บน dashboard
+		// Publish ข้ามโปรเจคที่ admin ลบออกจาก Dashboard ไว้แล้ว (projects.moderated_at) — resync ไม่ทำให้กลับขึ้นมา
+		if u.ShowOnDashboard && !u.Suspended {
+			if err := st.Dashboard.Publish(ctx, u.ID); err != nil {
+				return fail(fmt.Errorf("publish user %d: %w", u.ID, err))
+			}
+			fmt.Printf("✅ %d %s published\n", u.ID, u.Email)
+			published++
+			continue
+		}
+		if err := st.Dashboard.Unpublish(ctx, u.ID); err != nil {
+			return fail(fmt.Errorf("unpublish user %d: %w", u.ID, err))
+		}
+		if !*all {
+			fmt.Printf("↩️  %d %s not on dashboard (show_on_dashboard %s, status %s)\n", u.ID, u.Email, mark(u.ShowOnDashboard), userStatus(u))
+		}
+		removed++
+	}
+	fmt.Printf("Resynced %d published, %d not on dashboard\n", published, removed)
+	return 0
+}
+
+func runCodesCommand(ctx context.Context, st *store.Store, args []string) int {
+	if len(args) != 1 || args[0] != "purge-expired" {
+		fmt.Fprintln(os.Stderr, codesUsage)
+		return 2
+	}
+
+	codes, tokens, err := st.Codes.PurgeExpired(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Printf("🧹 Purged %d verification codes, %d password reset tokens\n", codes, tokens)
+	return 0
+}
+
+// parseInterleaved ให้ใส่ flag ก่อนหรือหลัง argument ได้ (flag.Parse ปกติหยุดที่ argument ตัวแรก)
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// findUser รับ user_id หรืออีเมล
+func findUser(ctx context.Context, users store.UserStore, idOrEmail string) (*store.User, error) {
+	var u *store.User
+	var err error
+	if id, convErr := strconv.Atoi(idOrEmail); convErr == nil {
+		u, err = users.Get(ctx, id)
+	} else if u, err = users.GetByEmail(ctx, idOrEmail); err == nil {
+		u, err = users.Get(ctx, u.ID) // GetByEmail ไม่โหลด skills
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("ไม่พบ user %q", idOrEmail)
+	}
+	return u, err
+}
+
+// passwordOrGenerate คืน password ที่ระบุ หรือสุ่มใหม่ถ้าว่าง (generated = true)
+func passwordOrGenerate(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", false, err
+	}
+	return token[:generatedPasswordLength], true, nil
+}
+
+func userStatus(u store.User) string {
+	switch {
+	case u.Suspended:
+		return "suspended"
+	case u.LockedFor > 0:
+		return fmt.Sprintf("locked %s", u.LockedFor)
+	}
+	return "active"
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "✅"
+	}
+	return "❌"
+}
+
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "❌", err)
+	return 1
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend/store"
+	"backend/store/memory"
+	"backend/store/sqlite"
+	"backend/utils"
+)
+
+func TestPublishResyncSkipsModeratedProjects(t *testing.T) {
+	backends := map[string]func(t *testing.T) *store.Store{
+		"memory": func(*testing.T) *store.Store { return memory.New() },
+		"sqlite": func(t *testing.T) *store.Store {
+			db, err := sqlite.Open(context.Background(), ":memory:")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return sqlite.New(db)
+		},
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			st := open(t)
+
+			create := func(email, role string) int {
+				id, err := st.Users.Create(ctx, store.NewUser{Email: email, PasswordHash: "x", UserName: email, University: "KU", Role: role, Locale: "th"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return id
+			}
+			adminID := create("admin@example.com", utils.RolePlatformAdmin)
+			studentID := create("student@example.com", utils.RoleStudent)
+			suspendedID := create("suspended@example.com", utils.RoleStudent)
+
+			spam := &store.Project{UserID: studentID, Title: "Spam", Desc: "buy now"}
+			keep := &store.Project{UserID: studentID, Title: "PortHub", Desc: "portfolio platform"}
+			for _, p := range []*store.Project{spam, keep} {
+				if err := st.Projects.Create(ctx, p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, id := range []int{studentID, suspendedID} {
+				if err := st.Dashboard.Publish(ctx, id); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := st.Moderation.RemovePublishedProject(ctx, store.ModerationAction{
+				AdminID: adminID, TargetUserID: studentID, Action: store.ModDeletePublishedProject, Reason: "spam", ProjectID: spam.ID,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := st.Moderation.Suspend(ctx, store.ModerationAction{
+				AdminID: adminID, TargetUserID: suspendedID, Action: store.ModSuspendUser, Reason: "spam",
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, args := range [][]string{
+				{"publish", "resync", "--all"},
+				{"publish", "resync", "--user", "student@example.com"},
+			} {
+				if code := runAdminCommand(st, args); code != 0 {
+					t.Fatalf("%v: exit code %d", args, code)
+				}
+
+				profile, err := st.Dashboard.Get(ctx, studentID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(profile.Projects) != 1 || profile.Projects[0].ProjectID != keep.ID {
+					t.Fatalf("%v: published projects = %+v, want only %d", args, profile.Projects, keep.ID)
+				}
+				if _, err := st.Dashboard.Get(ctx, suspendedID); !errors.Is(err, store.ErrNotFound) {
+					t.Fatalf("%v: suspended user still on dashboard (err = %v)", args, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,618 @@
+// Package config โหลดค่าตั้งค่าทั้งหมดของ backend จากไฟล์ (CONFIG_FILE) และ env ในที่เดียว
+// ลำดับความสำคัญ: ค่าเริ่มต้น < ไฟล์ config < env — แล้วตรวจสอบด้วย Validate ก่อนเริ่ม server
+// APP_ENV=production จะไม่ยอมเริ่มถ้า secret ยังว่างหรือยังเป็นค่าสำหรับ dev
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment ที่รองรับใน APP_ENV
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Backend เก็บข้อมูลที่รองรับใน STORAGE
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
+// ค่า secret สำหรับ dev — ใช้ได้ตอนพัฒนาเท่านั้น production จะถูกปฏิเสธ
+const (
+	devJWTSecret  = "porthub_dev_secret_change_in_production_2024"
+	devDBPassword = "190946"
+)
+
+// knownDevSecrets คือ secret ที่เคยอยู่ใน repo / docker-compose — ถือว่ารั่วแล้ว ห้ามใช้ใน production
+var knownDevSecrets = []string{
+	devJWTSecret,
+	devDBPassword,
+	"porthub123",
+	"postgres",
+	"password",
+	"secret",
+	"changeme",
+	// Gmail App Password ที่เคย hard-code ไว้ใน utils/email.go (อยู่ใน git history) — ต้อง revoke แล้วออกใหม่
+	"uxio upcy qprf yrwu",
+	"uxioupcyqprfyrwu",
+}
+
+// minProductionSecretLength คือความยาวขั้นต่ำของ JWT_SECRET / TOTP_ENCRYPTION_KEY ใน production
+const minProductionSecretLength = 32
+
+type Config struct {
+	Env        string `json:"env"`         // APP_ENV
+	Port       string `json:"port"`        // PORT
+	CORSOrigin string `json:"cors_origin"` // CORS_ORIGIN
+
+	Log      Log      `json:"log"`
+	Tracing  Tracing  `json:"tracing"`
+	Server   Server   `json:"server"`
+	Database Database `json:"database"`
+	Auth     Auth     `json:"auth"`
+	Mail     Mail     `json:"mail"`
+	Proxy    Proxy    `json:"proxy"`
+
+	Storage        string `json:"storage"`          // STORAGE: postgres | sqlite | memory (หรือ flag --storage)
+	RateLimitStore string `json:"rate_limit_store"` // RATE_LIMIT_STORE: memory | postgres
+	MetricsToken   string `json:"metrics_token"`    // METRICS_TOKEN (ว่าง = /metrics ไม่ต้องใช้ token)
+}
+
+type Log struct {
+	Level  string `json:"level"`  // LOG_LEVEL: debug | info | warn | error
+	Format string `json:"format"` // LOG_FORMAT: json | text
+}
+
+// Tracing เลือก exporter ของ OpenTelemetry — none ปิด tracing ทั้งหมด
+type Tracing struct {
+	Exporter     string  `json:"exporter"`      // TRACING_EXPORTER: none | stdout | otlp
+	OTLPEndpoint string  `json:"otlp_endpoint"` // TRACING_OTLP_ENDPOINT (host:port ของ collector, OTLP/HTTP)
+	OTLPInsecure bool    `json:"otlp_insecure"` // TRACING_OTLP_INSECURE (http แทน https — collector บนเครื่อง / ใน compose)
+	SampleRatio  float64 `json:"sample_ratio"`  // TRACING_SAMPLE_RATIO (0-1)
+	ServiceName  string  `json:"service_name"`  // TRACING_SERVICE_NAME
+}
+
+// Server คือ timeout ของ http.Server และเวลาที่รอ request ค้างตอน shutdown
+type Server struct {
+	ReadTimeout     Duration `json:"read_timeout"`     // HTTP_READ_TIMEOUT
+	WriteTimeout    Duration `json:"write_timeout"`    // HTTP_WRITE_TIMEOUT
+	IdleTimeout     Duration `json:"idle_timeout"`     // HTTP_IDLE_TIMEOUT
+	ShutdownTimeout Duration `json:"shutdown_timeout"` // SHUTDOWN_TIMEOUT
+	ShutdownDelay   Duration `json:"shutdown_delay"`   // SHUTDOWN_DELAY — /readyz ตอบ 503 ไปก่อนนานเท่านี้ แล้วค่อยปิด listener
+}
+
+type Database struct {
+	Host     string `json:"host"`     // DB_HOST
+	Port     string `json:"port"`     // DB_PORT
+	User     string `json:"user"`     // DB_USER
+	Password string `json:"password"` // DB_PASSWORD
+	Name     string `json:"name"`     // DB_NAME
+	SSLMode  string `json:"ssl_mode"` // DB_SSLMODE
+
+	SQLitePath string `json:"sqlite_path"` // SQLITE_PATH (ใช้เมื่อ STORAGE=sqlite)
+}
+
+// DSN คือ connection string สำหรับ lib/pq
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
+}
+
+type Auth struct {
+	JWTSecret         string `json:"jwt_secret"`          // JWT_SECRET
+	TOTPEncryptionKey string `json:"totp_encryption_key"` // TOTP_ENCRYPTION_KEY (ว่าง = derive จาก JWT_SECRET)
+
+	OTPLength      int      `json:"otp_length"`       // OTP_LENGTH (4-8)
+	OTPTTL         Duration `json:"otp_ttl"`          // OTP_TTL
+	OTPMaxAttempts int      `json:"otp_max_attempts"` // OTP_MAX_ATTEMPTS
+
+	LoginMaxFailures int      `json:"login_max_failures"` // LOGIN_MAX_FAILURES
+	LoginLockout     Duration `json:"login_lockout"`      // LOGIN_LOCKOUT
+	LoginLockoutMax  Duration `json:"login_lockout_max"`  // LOGIN_LOCKOUT_MAX
+}
+
+type Mail struct {
+	Driver       string `json:"driver"`        // MAIL_DRIVER: smtp | localsmtp | log | file
+	From         string `json:"from"`          // MAIL_FROM
+	SMTPHost     string `json:"smtp_host"`     // MAIL_SMTP_HOST
+	SMTPPort     string `json:"smtp_port"`     // MAIL_SMTP_PORT
+	SMTPUsername string `json:"smtp_username"` // MAIL_SMTP_USERNAME
+	SMTPPassword string `json:"smtp_password"` // MAIL_SMTP_PASSWORD
+	FileDir      string `json:"file_dir"`      // MAIL_FILE_DIR
+}
+
+type Proxy struct {
+	TrustedProxies  []string `json:"trusted_proxies"`   // TRUSTED_PROXIES (comma)
+	RemoteIPHeaders []string `json:"remote_ip_headers"` // REMOTE_IP_HEADERS (comma)
+	TrustedPlatform string   `json:"trusted_platform"`  // TRUSTED_PLATFORM
+}
+
+// Duration อ่านจากไฟล์ JSON เป็น string แบบ time.ParseDuration เช่น "15m"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration ต้องเป็น string เช่น \"15m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Default คือค่าเริ่มต้นที่ไม่ใช่ secret — secret สำหรับ dev ใส่ทีหลังด้วย applyDevSecrets เฉพาะเมื่อไม่ใช่ production
+func Default() *Config {
+	return &Config{
+		Env:        EnvDevelopment,
+		Port:       "8080",
+		CORSOrigin: "http://localhost:3000",
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter:     "none",
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+			ServiceName:  "porthub-backend",
+		},
+		Server: Server{
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(20 * time.Second),
+			ShutdownDelay:   Duration(5 * time.Second),
+		},
+		Database: Database{
+			Host:    "localhost",
+			Port:    "5432",
+			User:    "postgres",
+			Name:    "porthub_db",
+			SSLMode: "disable",
+
+			SQLitePath: "porthub.db",
+		},
+		Auth: Auth{
+			OTPLength:        4,
+			OTPTTL:           Duration(10 * time.Minute),
+			OTPMaxAttempts:   5,
+			LoginMaxFailures: 5,
+			LoginLockout:     Duration(15 * time.Minute),
+			LoginLockoutMax:  Duration(24 * time.Hour),
+		},
+		Mail: Mail{
+			Driver:  "log",
+			From:    "PortHub <no-reply@porthub.local>",
+			FileDir: "tmp/mail",
+		},
+		Storage:        StoragePostgres,
+		RateLimitStore: "memory",
+	}
+}
+
+// Load อ่านค่าเริ่มต้น → CONFIG_FILE (JSON, ถ้ามี) → env แล้วตรวจสอบ
+// ค่าที่ผ่านการตรวจจะถูกเก็บไว้ให้ Current() ใช้ทั้ง process
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := strings.TrimSpace(os.Getenv("CONFIG_FILE")); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	envErr := cfg.loadEnv()
+	// APP_ENV ที่ไม่รู้จัก (เช่น prod) ต้องหยุดก่อนเติม secret ของ dev — ไม่งั้นจะรันแบบไม่ตรวจ secret โดยไม่รู้ตัว
+	if err := cfg.validateEnv(); err != nil {
+		return nil, fmt.Errorf("config ไม่ถูกต้อง:\n%w", err)
+	}
+	if !cfg.IsProduction() {
+		cfg.applyDevSecrets()
+	}
+	if err := errors.Join(envErr, cfg.Validate()); err != nil {
+		return nil, err
+	}
+
+	Set(cfg)
+	return cfg, nil
+}
+
+// applyDevSecrets เติม secret ที่ยังว่างด้วยค่า dev ให้รันบนเครื่องได้โดยไม่ต้องตั้งอะไร
+func (cfg *Config) applyDevSecrets() {
+	if cfg.Database.Password == "" {
+		cfg.Database.Password = devDBPassword
+	}
+	if cfg.Auth.JWTSecret == "" {
+		cfg.Auth.JWTSecret = devJWTSecret
+	}
+}
+
+func (cfg *Config) loadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: อ่าน CONFIG_FILE ไม่ได้: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+func (cfg *Config) loadEnv() error {
+	var errs []error
+
+	envString("APP_ENV", &cfg.Env)
+	envString("PORT", &cfg.Port)
+	envString("CORS_ORIGIN", &cfg.CORSOrigin)
+
+	envString("LOG_LEVEL", &cfg.Log.Level)
+	envString("LOG_FORMAT", &cfg.Log.Format)
+
+	envString("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	envString("TRACING_OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint)
+	envString("TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	errs = append(errs,
+		envBool("TRACING_OTLP_INSECURE", &cfg.Tracing.OTLPInsecure),
+		envFloat("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio),
+	)
+
+	errs = append(errs,
+		envDuration("HTTP_READ_TIMEOUT", &cfg.Server.ReadTimeout),
+		envDuration("HTTP_WRITE_TIMEOUT", &cfg.Server.WriteTimeout),
+		envDuration("HTTP_IDLE_TIMEOUT", &cfg.Server.IdleTimeout),
+		envDuration("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout),
+		envDuration("SHUTDOWN_DELAY", &cfg.Server.ShutdownDelay),
+	)
+
+	envString("DB_HOST", &cfg.Database.Host)
+	envString("DB_PORT", &cfg.Database.Port)
+	envString("DB_USER", &cfg.Database.User)
+	envString("DB_PASSWORD", &cfg.Database.Password)
+	envString("DB_NAME", &cfg.Database.Name)
+	envString("DB_SSLMODE", &cfg.Database.SSLMode)
+	envString("SQLITE_PATH", &cfg.Database.SQLitePath)
+
+	envString("JWT_SECRET", &cfg.Auth.JWTSecret)
+	envString("TOTP_ENCRYPTION_KEY", &cfg.Auth.TOTPEncryptionKey)
+	errs = append(errs,
+		envInt("OTP_LENGTH", &cfg.Auth.OTPLength),
+		envDuration("OTP_TTL", &cfg.Auth.OTPTTL),
+		envInt("OTP_MAX_ATTEMPTS", &cfg.Auth.OTPMaxAttempts),
+		envInt("LOGIN_MAX_FAILURES", &cfg.Auth.LoginMaxFailures),
+		envDuration("LOGIN_LOCKOUT", &cfg.Auth.LoginLockout),
+		envDuration("LOGIN_LOCKOUT_MAX", &cfg.Auth.LoginLockoutMax),
+	)
+
+	envString("MAIL_DRIVER", &cfg.Mail.Driver)
+	envString("MAIL_FROM", &cfg.Mail.From)
+	envString("MAIL_SMTP_HOST", &cfg.Mail.SMTPHost)
+	envString("MAIL_SMTP_PORT", &cfg.Mail.SMTPPort)
+	envString("MAIL_SMTP_USERNAME", &cfg.Mail.SMTPUsername)
+	envString("MAIL_SMTP_PASSWORD", &cfg.Mail.SMTPPassword)
+	envString("MAIL_FILE_DIR", &cfg.Mail.FileDir)
+
+	envList("TRUSTED_PROXIES", &cfg.Proxy.TrustedProxies)
+	envList("REMOTE_IP_HEADERS", &cfg.Proxy.RemoteIPHeaders)
+	envString("TRUSTED_PLATFORM", &cfg.Proxy.TrustedPlatform)
+
+	envString("STORAGE", &cfg.Storage)
+	envString("RATE_LIMIT_STORE", &cfg.RateLimitStore)
+	envString("METRICS_TOKEN", &cfg.MetricsToken)
+
+	cfg.Env = strings.ToLower(cfg.Env)
+	cfg.Log.Level = strings.ToLower(cfg.Log.Level)
+	cfg.Log.Format = strings.ToLower(cfg.Log.Format)
+	cfg.Tracing.Exporter = strings.ToLower(cfg.Tracing.Exporter)
+	cfg.Mail.Driver = strings.ToLower(cfg.Mail.Driver)
+	cfg.Storage = strings.ToLower(cfg.Storage)
+	cfg.RateLimitStore = strings.ToLower(cfg.RateLimitStore)
+
+	return errors.Join(errs...)
+}
+
+// Validate ตรวจค่าทั้งหมด คืน error รวมทุกข้อที่ผิดในครั้งเดียว
+func (cfg *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if err := cfg.validateEnv(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if !validPort(cfg.Port) {
+		fail("PORT ไม่ถูกต้อง: %q", cfg.Port)
+	}
+	switch cfg.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("LOG_LEVEL ต้องเป็น debug, info, warn หรือ error (ได้ %q)", cfg.Log.Level)
+	}
+	if cfg.Log.Format != "json" && cfg.Log.Format != "text" {
+		fail("LOG_FORMAT ต้องเป็น json หรือ text (ได้ %q)", cfg.Log.Format)
+	}
+	switch cfg.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if cfg.Tracing.OTLPEndpoint == "" {
+			fail("TRACING_EXPORTER=otlp ต้องตั้ง TRACING_OTLP_ENDPOINT")
+		}
+	default:
+		fail("TRACING_EXPORTER ต้องเป็น none, stdout หรือ otlp (ได้ %q)", cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		fail("TRACING_SAMPLE_RATIO ต้องอยู่ระหว่าง 0-1 (ได้ %g)", cfg.Tracing.SampleRatio)
+	}
+	if cfg.Server.ReadTimeout <= 0 || cfg.Server.WriteTimeout <= 0 || cfg.Server.IdleTimeout <= 0 || cfg.Server.ShutdownTimeout <= 0 {
+		fail("HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT, HTTP_IDLE_TIMEOUT และ SHUTDOWN_TIMEOUT ต้องมากกว่า 0")
+	}
+	if cfg.Server.ShutdownDelay < 0 {
+		fail("SHUTDOWN_DELAY ต้องไม่ติดลบ (0 = ปิด listener ทันที)")
+	}
+	if !validPort(cfg.Database.Port) {
+		fail("DB_PORT ไม่ถูกต้อง: %q", cfg.Database.Port)
+	}
+	if cfg.Database.Host == "" || cfg.Database.User == "" || cfg.Database.Name == "" {
+		fail("ต้องตั้ง DB_HOST, DB_USER และ DB_NAME")
+	}
+	if cfg.Auth.OTPLength < 4 || cfg.Auth.OTPLength > 8 {
+		fail("OTP_LENGTH ต้องอยู่ระหว่าง 4-8 (ได้ %d)", cfg.Auth.OTPLength)
+	}
+	if cfg.Auth.OTPTTL <= 0 {
+		fail("OTP_TTL ต้องมากกว่า 0")
+	}
+	if cfg.Auth.OTPMaxAttempts < 1 {
+		fail("OTP_MAX_ATTEMPTS ต้องอย่างน้อย 1")
+	}
+	if cfg.Auth.LoginMaxFailures < 1 {
+		fail("LOGIN_MAX_FAILURES ต้องอย่างน้อย 1")
+	}
+	if cfg.Auth.LoginLockout <= 0 || cfg.Auth.LoginLockoutMax < cfg.Auth.LoginLockout {
+		fail("LOGIN_LOCKOUT ต้องมากกว่า 0 และไม่เกิน LOGIN_LOCKOUT_MAX")
+	}
+
+	switch cfg.Mail.Driver {
+	case "smtp":
+		if cfg.Mail.SMTPHost == "" || cfg.Mail.SMTPUsername == "" || cfg.Mail.SMTPPassword == "" {
+			fail("MAIL_DRIVER=smtp ต้องตั้ง MAIL_SMTP_HOST, MAIL_SMTP_USERNAME และ MAIL_SMTP_PASSWORD")
+		}
+	case "localsmtp", "log", "file":
+	default:
+		fail("ไม่รู้จัก MAIL_DRIVER %q", cfg.Mail.Driver)
+	}
+	if cfg.Mail.SMTPPort != "" && !validPort(cfg.Mail.SMTPPort) {
+		fail("MAIL_SMTP_PORT ไม่ถูกต้อง: %q", cfg.Mail.SMTPPort)
+	}
+
+	for _, proxy := range cfg.Proxy.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				fail("TRUSTED_PROXIES: %q ไม่ใช่ IP หรือ CIDR", proxy)
+			}
+		}
+	}
+
+	switch cfg.Storage {
+	case StoragePostgres:
+	case StorageSQLite, StorageMemory:
+		if cfg.Storage == StorageSQLite && strings.TrimSpace(cfg.Database.SQLitePath) == "" {
+			fail("STORAGE=sqlite ต้องตั้ง SQLITE_PATH")
+		}
+		if cfg.RateLimitStore == "postgres" {
+			fail("STORAGE=%s ใช้ RATE_LIMIT_STORE=postgres ไม่ได้", cfg.Storage)
+		}
+	default:
+		fail("STORAGE ต้องเป็น %s, %s หรือ %s (ได้ %q)", StoragePostgres, StorageSQLite, StorageMemory, cfg.Storage)
+	}
+
+	switch cfg.RateLimitStore {
+	case "memory", "postgres":
+	default:
+		fail("ไม่รู้จัก RATE_LIMIT_STORE %q", cfg.RateLimitStore)
+	}
+
+	if cfg.IsProduction() {
+		errs = append(errs, cfg.validateProduction()...)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config ไม่ถูกต้อง:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validateProduction ปฏิเสธ secret ที่ว่าง สั้นเกินไป หรือเป็นค่า dev ที่อยู่ใน repo
+func (cfg *Config) validateProduction() []error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	checkSecret := func(name, value string, minLength int) {
+		switch {
+		case value == "":
+			fail("production ต้องตั้ง %s", name)
+		case isKnownDevSecret(value):
+			fail("%s ยังเป็นค่าสำหรับ dev — ห้ามใช้ใน production", name)
+		case len(value) < minLength:
+			fail("%s ต้องยาวอย่างน้อย %d ตัวอักษรใน production", name, minLength)
+		}
+	}
+
+	checkSecret("JWT_SECRET", cfg.Auth.JWTSecret, minProductionSecretLength)
+	if cfg.Storage == StoragePostgres {
+		checkSecret("DB_PASSWORD", cfg.Database.Password, 1)
+	}
+	if cfg.Auth.TOTPEncryptionKey != "" {
+		checkSecret("TOTP_ENCRYPTION_KEY", cfg.Auth.TOTPEncryptionKey, minProductionSecretLength)
+	}
+
+	if cfg.Storage == StorageMemory {
+		fail("production ใช้ STORAGE=memory ไม่ได้ (ข้อมูลหายเมื่อ restart)")
+	}
+
+	// log / file เขียน OTP ลง log หรือดิสก์, localsmtp ไม่มี TLS / auth
+	if cfg.Mail.Driver != "smtp" {
+		fail("production ต้องใช้ MAIL_DRIVER=smtp (ได้ %q)", cfg.Mail.Driver)
+	} else if isKnownDevSecret(cfg.Mail.SMTPPassword) {
+		fail("MAIL_SMTP_PASSWORD ยังเป็นค่าสำหรับ dev — ห้ามใช้ใน production")
+	}
+
+	return errs
+}
+
+// IsProduction — ใช้ได้หลัง validateEnv ผ่านแล้วเท่านั้น (Env เป็น development หรือ production)
+func (cfg *Config) IsProduction() bool {
+	return cfg.Env == EnvProduction
+}
+
+// validateEnv ปฏิเสธ APP_ENV ที่ไม่ใช่ development / production
+func (cfg *Config) validateEnv() error {
+	switch cfg.Env {
+	case EnvDevelopment, EnvProduction:
+		return nil
+	default:
+		return fmt.Errorf("APP_ENV ต้องเป็น %s หรือ %s (ได้ %q)", EnvDevelopment, EnvProduction, cfg.Env)
+	}
+}
+
+func isKnownDevSecret(value string) bool {
+	for _, s := range knownDevSecrets {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// ---------------- Current ----------------
+
+var (
+	current   *Config
+	currentMu sync.RWMutex
+)
+
+// Current คืน config ที่โหลดแล้ว — ถ้ายังไม่ได้เรียก Load จะใช้ค่าเริ่มต้นสำหรับ dev
+func Current() *Config {
+	currentMu.RLock()
+	cfg := current
+	currentMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		current = Default()
+		current.applyDevSecrets()
+	}
+	return current
+}
+
+// Set เปลี่ยน config ที่ Current() คืน
+func Set(cfg *Config) {
+	currentMu.Lock()
+	current = cfg
+	currentMu.Unlock()
+}
+
+// ---------------- env helpers ----------------
+
+func envString(key string, dst *string) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		*dst = v
+	}
+}
+
+func envList(key string, dst *[]string) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	*dst = values
+}
+
+func envInt(key string, dst *int) error {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("%s ต้องเป็นตัวเลข (ได้ %q)", key, raw)
+	}
+	*dst = n
+	return nil
+}
+
+func envDuration(key string, dst *Duration) error {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("%s ต้องเป็นระยะเวลาเช่น 15m (ได้ %q)", key, raw)
+	}
+	*dst = Duration(d)
+	return nil
+}
+
+func envBool(key string, dst *bool) error {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return fmt.Errorf("%s ต้องเป็น true หรือ false (ได้ %q)", key, raw)
+	}
+	*dst = b
+	return nil
+}
+
+func envFloat(key string, dst *float64) error {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("%s ต้องเป็นตัวเลข (ได้ %q)", key, raw)
+	}
+	*dst = f
+	return nil
+}
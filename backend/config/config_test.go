@@ -0,0 +1,98 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// productionConfig คืน config production ที่ผ่าน Validate — test แต่ละตัวแก้เฉพาะค่าที่ต้องการทดสอบ
+func productionConfig() *Config {
+	cfg := Default()
+	cfg.Env = EnvProduction
+	cfg.Auth.JWTSecret = strings.Repeat("k", minProductionSecretLength)
+	cfg.Database.Password = "db-password-from-vault"
+	cfg.Mail.Driver = "smtp"
+	cfg.Mail.SMTPHost = "smtp.example.com"
+	cfg.Mail.SMTPUsername = "porthub"
+	cfg.Mail.SMTPPassword = "smtp-password-from-vault"
+	return cfg
+}
+
+type validateCase struct {
+	name   string
+	mutate func(*Config)
+	want   string // ข้อความที่ต้องอยู่ใน error
+}
+
+func TestValidateProduction(t *testing.T) {
+	if err := productionConfig().Validate(); err != nil {
+		t.Fatalf("valid production config rejected: %v", err)
+	}
+
+	tests := []validateCase{
+		{"empty JWT_SECRET", func(c *Config) { c.Auth.JWTSecret = "" }, "JWT_SECRET"},
+		{"short JWT_SECRET", func(c *Config) { c.Auth.JWTSecret = "short-but-not-a-dev-secret" }, "JWT_SECRET"},
+		{"empty DB_PASSWORD", func(c *Config) { c.Database.Password = "" }, "DB_PASSWORD"},
+		{"short TOTP_ENCRYPTION_KEY", func(c *Config) { c.Auth.TOTPEncryptionKey = "short" }, "TOTP_ENCRYPTION_KEY"},
+		{"memory storage", func(c *Config) { c.Storage = StorageMemory }, "STORAGE=memory"},
+		{"log mail driver", func(c *Config) { c.Mail.Driver = "log" }, "MAIL_DRIVER=smtp"},
+	}
+	for _, secret := range knownDevSecrets {
+		tests = append(tests,
+			validateCase{"dev JWT_SECRET " + secret, func(c *Config) { c.Auth.JWTSecret = secret }, "JWT_SECRET"},
+			validateCase{"dev DB_PASSWORD " + secret, func(c *Config) { c.Database.Password = strings.ToUpper(secret) }, "DB_PASSWORD"},
+			validateCase{"dev MAIL_SMTP_PASSWORD " + secret, func(c *Config) { c.Mail.SMTPPassword = secret }, "MAIL_SMTP_PASSWORD"},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := productionConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("accepted")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error does not mention %s: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsUnknownEnv(t *testing.T) {
+	for _, env := range []string{"", "prod", "staging", "Production"} {
+		cfg := productionConfig()
+		cfg.Env = env
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "APP_ENV") {
+			t.Errorf("APP_ENV=%q: err = %v, want an APP_ENV error", env, err)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownEnvBeforeDevSecrets(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("JWT_SECRET", "")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "APP_ENV") {
+		t.Fatalf("err = %v, want an APP_ENV error", err)
+	}
+}
+
+func TestLoadDevelopmentFillsDevSecrets(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("APP_ENV", EnvDevelopment)
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DB_PASSWORD", "")
+	defer Set(nil)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.JWTSecret != devJWTSecret || cfg.Database.Password != devDBPassword {
+		t.Fatal("development config without secrets did not get the dev defaults")
+	}
+}
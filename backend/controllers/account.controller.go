@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"backend/store"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ---------------------------------------------------------
+// 1. Change Password — ต้องยืนยันรหัสผ่านเดิม, session อื่นถูกยกเลิกทั้งหมด
+// ---------------------------------------------------------
+func ChangePassword(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+	sessionID := c.GetString("session_id")
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+		return
+	}
+
+	if input.CurrentPassword == "" || input.NewPassword == "" {
+		utils.BadRequest(c, "กรุณากรอกรหัสผ่านเดิมและรหัสผ่านใหม่")
+		return
+	}
+
+	if _, err := checkCurrentPassword(c.Request.Context(), st.Users, userID, input.CurrentPassword); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			utils.Unauthorized(c, "รหัสผ่านเดิมไม่ถูกต้อง")
+			return
+		}
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		utils.Internal(c, "hash password ไม่สำเร็จ")
+		return
+	}
+
+	// เครื่องนี้ยัง login อยู่ — เครื่องอื่นต้อง login ใหม่ด้วยรหัสผ่านใหม่
+	// reset token / OTP ที่ค้างอยู่ใช้ไม่ได้แล้วหลังเปลี่ยนรหัสผ่าน
+	u, err := st.Users.ChangePassword(c.Request.Context(), userID, sessionID, string(hashedPassword))
+	if err != nil {
+		utils.Internal(c, "update password ไม่สำเร็จ")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditPasswordChanged,
+	})
+
+	// รหัสผ่านเปลี่ยนไปแล้ว — ส่งอีเมลแจ้งไม่สำเร็จไม่ควรทำให้ผู้ใช้เห็นว่าล้มเหลว
+	if err := utils.SendPasswordChangedEmail(c.Request.Context(), st.Outbox, u.Email, u.Locale); err != nil {
+		slog.Warn("password changed email failed", "user_id", userID, "error", err)
+	}
+
+	utils.Success(c, 200, "เปลี่ยนรหัสผ่านสำเร็จ อุปกรณ์อื่นถูกออกจากระบบแล้ว", nil)
+}
+
+// ---------------------------------------------------------
+// 2. Request Email Change — ส่งรหัสยืนยันไปที่อีเมลใหม่ (ยังไม่เปลี่ยนจนกว่าจะยืนยัน)
+// ---------------------------------------------------------
+func RequestEmailChange(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	var input struct {
+		NewEmail string `json:"new_email"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+		return
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(input.NewEmail))
+	if newEmail == "" || !strings.Contains(newEmail, "@") || input.Password == "" {
+		utils.BadRequest(c, "กรุณากรอกอีเมลใหม่และรหัสผ่าน")
+		return
+	}
+
+	u, err := checkCurrentPassword(c.Request.Context(), st.Users, userID, input.Password)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			utils.Unauthorized(c, "รหัสผ่านไม่ถูกต้อง")
+			return
+		}
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	// อีเมลที่มีบัญชีอยู่แล้วตอบเหมือนกรณีปกติ (ไม่งั้นใช้ endpoint นี้เช็กได้ว่าอีเมลไหนสมัครไว้)
+	// แต่ไม่ออกรหัส — ส่งอีเมลแจ้งเจ้าของอีเมลนั้นแทน
+	taken, err := st.Users.GetByEmail(c.Request.Context(), newEmail)
+	if err == nil {
+		if err := utils.SendEmailChangeTakenEmail(c.Request.Context(), st.Outbox, taken.Email, taken.Locale); err != nil {
+			utils.Internal(c, "ส่งอีเมลไม่สำเร็จ")
+			return
+		}
+
+		utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+			UserID: userID, ActorID: userID, Action: utils.AuditEmailChangeRequested,
+			Metadata: map[string]interface{}{"new_email": newEmail, "email_taken": true},
+		})
+
+		utils.Success(c, 200, "ส่งรหัสยืนยันไปที่อีเมลใหม่แล้ว", gin.H{
+			"new_email": newEmail,
+		})
+		return
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	otp, err := utils.GenerateOTP(utils.OTPLength())
+	if err != nil {
+		utils.Internal(c, "สร้างรหัสยืนยันไม่สำเร็จ")
+		return
+	}
+
+	// รหัสเก็บอีเมลที่รอยืนยันไว้ด้วย
+	if err := st.Codes.IssueEmailChange(c.Request.Context(), userID, newEmail, utils.HashOTP(otp), time.Now().Add(utils.OTPTTL())); err != nil {
+		utils.Internal(c, "สร้างรหัสยืนยันไม่สำเร็จ")
+		return
+	}
+
+	if err := utils.SendOTPEmail(c.Request.Context(), st.Outbox, utils.EmailChange, newEmail, u.Locale, otp); err != nil {
+		utils.Internal(c, "ส่งอีเมลไม่สำเร็จ")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditEmailChangeRequested,
+		Metadata: map[string]interface{}{"new_email": newEmail},
+	})
+
+	utils.Success(c, 200, "ส่งรหัสยืนยันไปที่อีเมลใหม่แล้ว", gin.H{
+		"new_email": newEmail,
+	})
+}
+
+// ---------------------------------------------------------
+// 3. Confirm Email Change — ยืนยันรหัสแล้วจึงเปลี่ยน users.email + snapshot บน Dashboard
+// ---------------------------------------------------------
+func ConfirmEmailChange(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		utils.BadRequest(c, "กรุณากรอกรหัสยืนยัน")
+		return
+	}
+
+	current, err := st.Users.Get(c.Request.Context(), userID)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	currentEmail := current.Email
+
+	codeID, codeUserID, err := checkVerificationCode(c.Request.Context(), st.Codes, strings.ToLower(currentEmail), store.CodeEmailChange, input.Code)
+	if errors.Is(err, errInvalidCode) || (err == nil && codeUserID != userID) {
+		utils.BadRequest(c, "รหัสยืนยันไม่ถูกต้องหรือหมดอายุ")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	// อีเมลใหม่ผ่านการยืนยันด้วยรหัสแล้ว จึงถือว่า verified ทันที
+	// OTP / reset token ที่ส่งไปอีเมลเดิมใช้ไม่ได้แล้ว
+	newEmail, err := st.Users.ChangeEmail(c.Request.Context(), userID, codeID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.BadRequest(c, "รหัสยืนยันไม่ถูกต้องหรือหมดอายุ")
+		return
+	}
+	if errors.Is(err, store.ErrEmailTaken) {
+		// มีคนสมัครด้วยอีเมลนี้ระหว่างรอยืนยัน
+		utils.Error(c, 409, "อีเมลนี้ถูกใช้งานแล้ว", "EMAIL_TAKEN")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "เปลี่ยนอีเมลไม่สำเร็จ")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditEmailChanged,
+		Metadata: map[string]interface{}{"old_email": currentEmail, "new_email": newEmail},
+	})
+
+	utils.Success(c, 200, "เปลี่ยนอีเมลสำเร็จ", gin.H{
+		"email":          newEmail,
+		"email_verified": true,
+	})
+}
+
+// checkCurrentPassword เทียบรหัสผ่านกับ hash ใน DB — คืน bcrypt.ErrMismatchedHashAndPassword ถ้าไม่ตรง
+func checkCurrentPassword(ctx context.Context, users store.UserStore, userID int, password string) (*store.User, error) {
+	u, err := users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
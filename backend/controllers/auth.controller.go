@@ -1,18 +1,34 @@
 package controllers
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
+	"backend/mail"
+	"backend/store"
 	"backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// อายุของ reset token ที่ได้หลัง VerifyOTP สำเร็จ (ใช้ได้ครั้งเดียว)
+const resetTokenTTL = 10 * time.Minute
+
+// อายุของ session / refresh token (ต่ออายุทุกครั้งที่ refresh)
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// ข้อความเดียวกันทั้งกรณีไม่พบอีเมลและรหัสผ่านผิด เพื่อไม่ให้ใช้ /login ไล่เช็คว่าอีเมลไหนมีบัญชี
+const invalidCredentialsMessage = "อีเมลหรือรหัสผ่านไม่ถูกต้อง"
+
+// errInvalidCode ใช้กับรหัสที่ผิด หมดอายุ ถูกใช้แล้ว หรือถูกยกเลิกเพราะกรอกผิดเกินจำนวนครั้ง
+var errInvalidCode = errors.New("invalid or expired code")
+
 type User struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -22,7 +38,7 @@ type User struct {
 // ---------------------------------------------------------
 // 1. Register
 // ---------------------------------------------------------
-func Register(c *gin.Context, db *sql.DB) {
+func Register(c *gin.Context, st *store.Store) {
 
 	var input struct {
 		Email       string   `json:"email"`
@@ -35,6 +51,8 @@ func Register(c *gin.Context, db *sql.DB) {
 		GPA         float64  `json:"gpa"`
 		JobInterest string   `json:"job_interest"`
 		Skills      []string `json:"skills"`
+		Role        string   `json:"role"`
+		Locale      string   `json:"locale"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -49,197 +67,324 @@ func Register(c *gin.Context, db *sql.DB) {
 		return
 	}
 
+	// สมัครเองได้เฉพาะ student (ค่าเริ่มต้น) หรือ recruiter
+	role := strings.TrimSpace(input.Role)
+	if role == "" {
+		role = utils.RoleStudent
+	}
+	if !utils.IsSelfRegisterRole(role) {
+		utils.BadRequest(c, "role ไม่ถูกต้อง")
+		return
+	}
+
+	// ภาษาของอีเมลที่ระบบส่งให้ (th / en)
+	locale := mail.NormalizeLocale(input.Locale)
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
 		utils.Internal(c, "hash password ไม่สำเร็จ")
 		return
 	}
 
-	tx, err := db.Begin()
+	userID, err := st.Users.Create(c.Request.Context(), store.NewUser{
+		Email:        emailNorm,
+		PasswordHash: string(hashedPassword),
+		UserName:     input.UserName,
+		Phone:        input.Phone,
+		University:   input.University,
+		Faculty:      input.Faculty,
+		Major:        input.Major,
+		GPA:          input.GPA,
+		JobInterest:  input.JobInterest,
+		Role:         role,
+		Locale:       locale,
+		Skills:       input.Skills,
+	})
+	if errors.Is(err, store.ErrEmailTaken) {
+		utils.Error(c, 409, "อีเมลนี้ถูกใช้งานแล้ว", "EMAIL_TAKEN")
+		return
+	}
 	if err != nil {
-		utils.Internal(c, "DB error")
+		slog.ErrorContext(c.Request.Context(), "register: insert user", "error", err)
+		utils.Internal(c, "สมัครสมาชิกไม่สำเร็จ")
 		return
 	}
 
-	var userID int
+	// ส่งรหัสยืนยันอีเมล — ถ้าส่งไม่สำเร็จ ผู้ใช้ขอใหม่ได้ที่ /resend-verification
+	if otp, err := issueVerificationCode(c.Request.Context(), st.Codes, userID, store.CodeEmailVerify); err == nil {
+		if err := utils.SendOTPEmail(c.Request.Context(), st.Outbox, utils.EmailVerify, emailNorm, locale, otp); err != nil {
+			slog.WarnContext(c.Request.Context(), "register: queue verification email", "error", err)
+		}
+	} else {
+		slog.WarnContext(c.Request.Context(), "register: create verification code", "error", err)
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditRegister,
+		Metadata: map[string]interface{}{"role": role},
+	})
 
-	userQuery := `
-	INSERT INTO users 
-	(email, password_hash, user_name, phone, university, faculty, major, gpa, job_interest)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
-	RETURNING user_id
-	`
+	utils.Success(c, 201, "สมัครสมาชิกสำเร็จ! กรุณายืนยันอีเมลด้วยรหัสที่ส่งไปให้", gin.H{
+		"user_id":        userID,
+		"role":           role,
+		"email_verified": false,
+	})
+}
 
-	err = tx.QueryRow(userQuery,
-		emailNorm,
-		string(hashedPassword),
-		input.UserName,
-		input.Phone,
-		input.University,
-		input.Faculty,
-		input.Major,
-		input.GPA,
-		input.JobInterest,
-	).Scan(&userID)
+// ---------------------------------------------------------
+// 2. Login
+// ---------------------------------------------------------
+func Login(c *gin.Context, st *store.Store) {
 
-	if err != nil {
-		tx.Rollback()
-		fmt.Println("❌ Insert user error:", err)
-		utils.Error(c, 500, "สมัครสมาชิกไม่สำเร็จ (email อาจซ้ำ)", err.Error())
+	var input User
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
 		return
 	}
 
-	// Insert skills
-	for _, skillName := range input.Skills {
-
-		skillName = strings.TrimSpace(skillName)
-		if skillName == "" {
-			continue
-		}
-
-		var skillID int
+	emailNorm := strings.ToLower(strings.TrimSpace(input.Email))
 
-		err := tx.QueryRow(
-			"SELECT skill_id FROM skills WHERE LOWER(skill_name)=LOWER($1)",
-			skillName,
-		).Scan(&skillID)
+	u, err := st.Users.GetByEmail(c.Request.Context(), emailNorm)
+	if errors.Is(err, store.ErrNotFound) {
+		// เทียบกับ hash หลอกเพื่อให้เวลาตอบใกล้เคียงกับกรณีมีบัญชี — ไม่บอกว่าอีเมลนี้มีในระบบหรือไม่
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(input.Password))
+		utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+			Action:   utils.AuditLoginFailed,
+			Metadata: map[string]interface{}{"email": emailNorm, "reason": "unknown_email"},
+		})
+		utils.Unauthorized(c, invalidCredentialsMessage)
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
 
-		if err == sql.ErrNoRows {
-			err = tx.QueryRow(
-				"INSERT INTO skills (skill_name) VALUES ($1) RETURNING skill_id",
-				skillName,
-			).Scan(&skillID)
+	// บัญชีถูกล็อกชั่วคราว — ไม่รับรหัสผ่านจนกว่าจะหมดเวลา แต่ตอบเหมือนรหัสผ่านผิด (เทียบ hash หลอกให้เวลาเท่ากัน)
+	// ถ้าตอบต่างออกไป คนนอกจะแยกอีเมลที่มีบัญชีออกจากอีเมลที่ไม่มีได้ — เจ้าของบัญชีรู้จากอีเมลแจ้งล็อกแทน
+	if u.LockedFor > 0 {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(input.Password))
+		utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+			UserID: u.ID, Action: utils.AuditLoginFailed,
+			Metadata: map[string]interface{}{"reason": "locked"},
+		})
+		utils.Unauthorized(c, invalidCredentialsMessage)
+		return
+	}
 
-			if err != nil {
-				tx.Rollback()
-				utils.Internal(c, "Insert skill error")
-				return
-			}
-		} else if err != nil {
-			tx.Rollback()
-			utils.Internal(c, "Skill lookup error")
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(input.Password)); err != nil {
+		if err := recordFailedLogin(c, st, u); err != nil {
+			utils.Internal(c, "Database error")
 			return
 		}
+		utils.Unauthorized(c, invalidCredentialsMessage)
+		return
+	}
 
-		_, err = tx.Exec(
-			"INSERT INTO user_skills (user_id, skill_id) VALUES ($1,$2) ON CONFLICT DO NOTHING",
-			userID,
-			skillID,
-		)
+	if err := st.Users.ClearLoginFailures(c.Request.Context(), u.ID); err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	if u.Suspended {
+		utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+			UserID: u.ID, Action: utils.AuditLoginFailed,
+			Metadata: map[string]interface{}{"reason": "suspended"},
+		})
+		utils.Error(c, 403, "บัญชีนี้ถูกระงับการใช้งาน กรุณาติดต่อผู้ดูแลระบบ", "ACCOUNT_SUSPENDED")
+		return
+	}
 
+	// เปิด 2FA ไว้ → ยังไม่ออก token จนกว่าจะยืนยันรหัสที่ /login/2fa
+	twoFactor, err := twoFactorEnabled(c.Request.Context(), st.TwoFactor, u.ID)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if twoFactor {
+		challenge, err := createLoginChallenge(c.Request.Context(), st.TwoFactor, u.ID)
 		if err != nil {
-			tx.Rollback()
-			utils.Internal(c, "Insert user skill error")
+			utils.Internal(c, "สร้าง challenge ไม่สำเร็จ")
 			return
 		}
+
+		utils.Success(c, 200, "กรุณายืนยันตัวตนสองชั้น", gin.H{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int(loginChallengeTTL.Seconds()),
+		})
+		return
 	}
 
-	if err := tx.Commit(); err != nil {
-		utils.Internal(c, "Commit error")
+	token, refreshToken, err := createSession(c, st.Sessions, u.ID, u.Role)
+	if err != nil {
+		utils.Internal(c, "สร้าง token ไม่สำเร็จ")
 		return
 	}
 
-	utils.Success(c, 201, "สมัครสมาชิกสำเร็จ!", gin.H{
-		"user_id": userID,
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: u.ID, ActorID: u.ID, Action: utils.AuditLogin,
+		Metadata: map[string]interface{}{"two_factor": false},
+	})
+
+	// 🔥 รองรับทั้ง format ใหม่ + เก่า
+	utils.Success(c, 200, "เข้าสู่ระบบสำเร็จ!", gin.H{
+		"token":          token,
+		"refresh_token":  refreshToken,
+		"expires_in":     int(utils.AccessTokenTTL.Seconds()),
+		"role":           u.Role,
+		"email_verified": u.EmailVerified,
 	})
 }
 
 // ---------------------------------------------------------
-// 2. Login
+// 3. Forgot Password
 // ---------------------------------------------------------
-func Login(c *gin.Context, db *sql.DB) {
+func ForgotPassword(c *gin.Context, st *store.Store) {
+
+	var input struct {
+		Email string `json:"email"`
+	}
 
-	var input User
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+		utils.BadRequest(c, "กรุณาระบุอีเมล")
 		return
 	}
 
 	emailNorm := strings.ToLower(strings.TrimSpace(input.Email))
 
-	var storedPassword string
-	var userID int
-
-	err := db.QueryRow(
-		"SELECT user_id, password_hash FROM users WHERE LOWER(email)=$1",
-		emailNorm,
-	).Scan(&userID, &storedPassword)
+	// ตอบข้อความเดียวกันทุกกรณี เพื่อไม่ให้ใช้ endpoint นี้เช็คว่ามีอีเมลในระบบหรือไม่
+	const message = "หากอีเมลนี้มีบัญชีอยู่ ระบบได้ส่ง OTP ให้แล้ว"
 
+	u, err := st.Users.GetByEmail(c.Request.Context(), emailNorm)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.Success(c, 200, message, nil)
+		return
+	}
 	if err != nil {
-		utils.Unauthorized(c, "ไม่พบอีเมลนี้ในระบบ")
+		utils.Internal(c, "Database error")
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(input.Password)); err != nil {
-		utils.Unauthorized(c, "รหัสผ่านไม่ถูกต้อง")
+	otp, err := issueVerificationCode(c.Request.Context(), st.Codes, u.ID, store.CodeForgotPassword)
+	if err != nil {
+		utils.Internal(c, "Database error")
 		return
 	}
 
-	token, err := utils.GenerateToken(fmt.Sprintf("%d", userID))
-	if err != nil {
-		utils.Internal(c, "สร้าง token ไม่สำเร็จ")
+	if err := utils.SendOTPEmail(c.Request.Context(), st.Outbox, utils.EmailPasswordResetOTP, u.Email, u.Locale, otp); err != nil {
+		utils.Internal(c, "ส่งอีเมลไม่สำเร็จ")
 		return
 	}
 
-	// 🔥 รองรับทั้ง format ใหม่ + เก่า
-	utils.Success(c, 200, "เข้าสู่ระบบสำเร็จ!", gin.H{
-		"token": token,
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: u.ID, Action: utils.AuditPasswordResetRequested,
 	})
+
+	utils.Success(c, 200, message, nil)
 }
 
 // ---------------------------------------------------------
-// 3. Forgot Password
+// 4. Verify OTP
 // ---------------------------------------------------------
-func ForgotPassword(c *gin.Context, db *sql.DB) {
+func VerifyOTP(c *gin.Context, st *store.Store) {
 
 	var input struct {
 		Email string `json:"email"`
+		OTP   string `json:"otp"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.BadRequest(c, "กรุณาระบุอีเมล")
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
 		return
 	}
 
 	emailNorm := strings.ToLower(strings.TrimSpace(input.Email))
 
-	var userID int
-	var dbEmail string
+	codeID, userID, err := checkVerificationCode(c.Request.Context(), st.Codes, emailNorm, store.CodeForgotPassword, input.OTP)
+	if errors.Is(err, errInvalidCode) {
+		utils.Unauthorized(c, "OTP ไม่ถูกต้องหรือหมดอายุ")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
 
-	err := db.QueryRow(
-		"SELECT user_id, email FROM users WHERE LOWER(email)=$1",
-		emailNorm,
-	).Scan(&userID, &dbEmail)
+	resetToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.Internal(c, "สร้าง reset token ไม่สำเร็จ")
+		return
+	}
 
+	// OTP ใช้ได้ครั้งเดียว — ถ้ามี request อื่นใช้ไปก่อนแล้วจะได้ ErrNotFound
+	err = st.Codes.CreateResetToken(c.Request.Context(), codeID, userID, utils.HashToken(resetToken), time.Now().Add(resetTokenTTL))
+	if errors.Is(err, store.ErrNotFound) {
+		utils.Unauthorized(c, "OTP ไม่ถูกต้องหรือหมดอายุ")
+		return
+	}
 	if err != nil {
-		utils.Unauthorized(c, "ไม่พบอีเมลนี้ในระบบ")
+		utils.Internal(c, "Database error")
 		return
 	}
 
-	otp := fmt.Sprintf("%04d", rand.New(rand.NewSource(time.Now().UnixNano())).Intn(10000))
-	expiresAt := time.Now().Add(5 * time.Minute)
+	utils.Success(c, 200, "OTP ถูกต้อง", gin.H{
+		"reset_token": resetToken,
+		"expires_in":  int(resetTokenTTL.Seconds()),
+	})
+}
+
+// ---------------------------------------------------------
+// 5. Reset Password
+// ---------------------------------------------------------
+func ResetPassword(c *gin.Context, st *store.Store) {
+
+	var input struct {
+		ResetToken string `json:"reset_token"`
+		Password   string `json:"password"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+		return
+	}
 
-	db.Exec("DELETE FROM verification_codes WHERE user_id=$1", userID)
+	if input.ResetToken == "" || input.Password == "" {
+		utils.BadRequest(c, "กรุณาระบุ reset token และรหัสผ่านใหม่")
+		return
+	}
 
-	_, err = db.Exec(
-		"INSERT INTO verification_codes (user_id, code, expired_at) VALUES ($1,$2,$3)",
-		userID, otp, expiresAt,
-	)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.Internal(c, "hash password ไม่สำเร็จ")
+		return
+	}
 
+	// ใช้ token ได้ครั้งเดียวและต้องยังไม่หมดอายุ — store จะ revoke ทุก session ของ user ให้ด้วย
+	u, err := st.Users.ResetPassword(c.Request.Context(), utils.HashToken(input.ResetToken), string(hashedPassword))
+	if errors.Is(err, store.ErrNotFound) {
+		utils.Unauthorized(c, "reset token ไม่ถูกต้องหรือหมดอายุ")
+		return
+	}
 	if err != nil {
-		utils.Internal(c, "Database error")
+		utils.Internal(c, "เปลี่ยนรหัสผ่านไม่สำเร็จ")
 		return
 	}
 
-	utils.SendOTPEmail(dbEmail, otp)
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: u.ID, ActorID: u.ID, Action: utils.AuditPasswordReset,
+	})
+	if err := utils.SendPasswordChangedEmail(c.Request.Context(), st.Outbox, u.Email, u.Locale); err != nil {
+		slog.WarnContext(c.Request.Context(), "reset password: queue notice email", "error", err)
+	}
 
-	utils.Success(c, 200, "ส่ง OTP แล้ว", nil)
+	utils.Success(c, 200, "เปลี่ยนรหัสผ่านสำเร็จ!", nil)
 }
 
 // ---------------------------------------------------------
-// 4. Verify OTP
+// 6. Verify Email
 // ---------------------------------------------------------
-func VerifyOTP(c *gin.Context, db *sql.DB) {
+func VerifyEmail(c *gin.Context, st *store.Store) {
 
 	var input struct {
 		Email string `json:"email"`
@@ -253,61 +398,289 @@ func VerifyOTP(c *gin.Context, db *sql.DB) {
 
 	emailNorm := strings.ToLower(strings.TrimSpace(input.Email))
 
-	var storedOTP string
-	var expiresAt time.Time
+	codeID, userID, err := checkVerificationCode(c.Request.Context(), st.Codes, emailNorm, store.CodeEmailVerify, input.OTP)
+	if errors.Is(err, errInvalidCode) {
+		utils.Unauthorized(c, "รหัสยืนยันไม่ถูกต้องหรือหมดอายุ")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	if err := st.Users.VerifyEmail(c.Request.Context(), userID, codeID); err != nil {
+		utils.Internal(c, "ยืนยันอีเมลไม่สำเร็จ")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditEmailVerified,
+	})
+
+	utils.Success(c, 200, "ยืนยันอีเมลสำเร็จ!", gin.H{
+		"email_verified": true,
+	})
+}
+
+// ---------------------------------------------------------
+// 7. Resend Verification
+// ---------------------------------------------------------
+func ResendVerification(c *gin.Context, st *store.Store) {
+
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, "กรุณาระบุอีเมล")
+		return
+	}
 
-	query := `
-	SELECT vc.code, vc.expired_at
-	FROM verification_codes vc
-	JOIN users u ON vc.user_id=u.user_id
-	WHERE LOWER(u.email)=$1 AND vc.is_used=FALSE
-	ORDER BY vc.created_at DESC LIMIT 1`
+	emailNorm := strings.ToLower(strings.TrimSpace(input.Email))
 
-	err := db.QueryRow(query, emailNorm).Scan(&storedOTP, &expiresAt)
+	// ตอบข้อความเดียวกันทุกกรณี เพื่อไม่ให้ใช้ endpoint นี้เช็คว่ามีอีเมลในระบบหรือไม่
+	const message = "หากอีเมลนี้ยังไม่ได้ยืนยัน ระบบได้ส่งรหัสยืนยันให้แล้ว"
 
+	u, err := st.Users.GetByEmail(c.Request.Context(), emailNorm)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && u.EmailVerified) {
+		utils.Success(c, 200, message, nil)
+		return
+	}
 	if err != nil {
-		utils.Unauthorized(c, "OTP ไม่ถูกต้องหรือหมดอายุ")
+		utils.Internal(c, "Database error")
 		return
 	}
 
-	if storedOTP != input.OTP || time.Now().After(expiresAt) {
-		utils.Unauthorized(c, "OTP ไม่ถูกต้องหรือหมดอายุ")
+	otp, err := issueVerificationCode(c.Request.Context(), st.Codes, u.ID, store.CodeEmailVerify)
+	if err != nil {
+		utils.Internal(c, "Database error")
 		return
 	}
 
-	utils.Success(c, 200, "OTP ถูกต้อง", nil)
+	if err := utils.SendOTPEmail(c.Request.Context(), st.Outbox, utils.EmailVerify, u.Email, u.Locale, otp); err != nil {
+		utils.Internal(c, "ส่งอีเมลไม่สำเร็จ")
+		return
+	}
+
+	utils.Success(c, 200, message, nil)
 }
 
 // ---------------------------------------------------------
-// 5. Reset Password
+// 8. Refresh Token
 // ---------------------------------------------------------
-func ResetPassword(c *gin.Context, db *sql.DB) {
+func RefreshToken(c *gin.Context, st *store.Store) {
 
 	var input struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+	if err := c.ShouldBindJSON(&input); err != nil || input.RefreshToken == "" {
+		utils.BadRequest(c, "กรุณาระบุ refresh token")
 		return
 	}
 
-	emailNorm := strings.ToLower(strings.TrimSpace(input.Email))
+	tokenHash := utils.HashToken(input.RefreshToken)
+
+	// อ่าน role ล่าสุดจาก users เพื่อให้การเปลี่ยน role มีผลตั้งแต่ token ถัดไป
+	// บัญชีที่ถูกระงับต่ออายุ token ไม่ได้
+	sess, err := st.Sessions.FindByRefreshToken(c.Request.Context(), tokenHash)
+	if errors.Is(err, store.ErrNotFound) {
+		// refresh token ที่หมุนไปแล้วถูกนำกลับมาใช้ — อาจถูกขโมย จึง revoke session นั้นทิ้ง
+		if reusedBy, err := st.Sessions.RevokeReused(c.Request.Context(), tokenHash); err == nil {
+			utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+				UserID: reusedBy, Action: utils.AuditRefreshTokenReuse,
+			})
+		}
+		utils.Unauthorized(c, "refresh token ไม่ถูกต้อง")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if !sess.Active {
+		utils.Unauthorized(c, "session หมดอายุหรือถูกยกเลิกแล้ว")
+		return
+	}
 
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	newRefreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.Internal(c, "สร้าง token ไม่สำเร็จ")
+		return
+	}
 
-	_, err := db.Exec(
-		"UPDATE users SET password_hash=$1 WHERE LOWER(email)=$2",
-		string(hashedPassword), emailNorm,
-	)
+	// หมุน refresh token — ถ้า request อื่นหมุนไปก่อนแล้วจะได้ ErrNotFound
+	err = st.Sessions.Rotate(c.Request.Context(), tokenHash, store.Session{
+		ID:               sess.SessionID,
+		UserID:           sess.UserID,
+		RefreshTokenHash: utils.HashToken(newRefreshToken),
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        time.Now().Add(refreshTokenTTL),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		utils.Unauthorized(c, "refresh token ไม่ถูกต้อง")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
 
+	token, err := utils.GenerateToken(fmt.Sprintf("%d", sess.UserID), sess.SessionID, sess.Role)
 	if err != nil {
-		utils.Internal(c, "เปลี่ยนรหัสผ่านไม่สำเร็จ")
+		utils.Internal(c, "สร้าง token ไม่สำเร็จ")
 		return
 	}
 
-	db.Exec("DELETE FROM verification_codes WHERE user_id=(SELECT user_id FROM users WHERE LOWER(email)=$1)", emailNorm)
+	utils.Success(c, 200, "ต่ออายุ token สำเร็จ", gin.H{
+		"token":         token,
+		"refresh_token": newRefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
 
-	utils.Success(c, 200, "เปลี่ยนรหัสผ่านสำเร็จ!", nil)
+// ---------------------------------------------------------
+// 9. Logout
+// ---------------------------------------------------------
+func Logout(c *gin.Context, st *store.Store) {
+
+	sessionID := c.GetString("session_id")
+	if sessionID == "" {
+		utils.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	if err := st.Sessions.Revoke(c.Request.Context(), sessionID); err != nil {
+		utils.Internal(c, "ออกจากระบบไม่สำเร็จ")
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditLogout,
+	})
+
+	utils.Success(c, 200, "ออกจากระบบสำเร็จ", nil)
+}
+
+// createSession สร้าง session ใหม่ให้ user แล้วคืน access token และ refresh token
+func createSession(c *gin.Context, sessions store.SessionStore, userID int, role string) (string, string, error) {
+	sessionID, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = sessions.Create(c.Request.Context(), store.Session{
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := utils.GenerateToken(fmt.Sprintf("%d", userID), sessionID, role)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// issueVerificationCode แทนที่รหัสเดิมของ user ในประเภทเดียวกันด้วยรหัสใหม่ (เก็บเฉพาะ hash)
+func issueVerificationCode(ctx context.Context, codes store.CodeStore, userID int, codeType string) (string, error) {
+	otp, err := utils.GenerateOTP(utils.OTPLength())
+	if err != nil {
+		return "", err
+	}
+
+	if err := codes.Issue(ctx, userID, codeType, utils.HashOTP(otp), time.Now().Add(utils.OTPTTL())); err != nil {
+		return "", err
+	}
+
+	return otp, nil
+}
+
+// checkVerificationCode ตรวจรหัสล่าสุดที่ยังไม่ถูกใช้ของ email + type
+// ทุกครั้งที่ตรวจจะนับ attempts ก่อนเทียบรหัส — ผิดครบ OTPMaxAttempts ครั้งรหัสจะถูกยกเลิก
+// คืน errInvalidCode ถ้ารหัสใช้ไม่ได้ — ผู้เรียกต้อง mark is_used เองเมื่อทำงานสำเร็จ
+func checkVerificationCode(ctx context.Context, codes store.CodeStore, email, codeType, code string) (int, int, error) {
+	maxAttempts := utils.OTPMaxAttempts()
+
+	// จองสิทธิ์ 1 ครั้งแบบ atomic เพื่อให้ request ที่ยิงพร้อมกันเดารหัสเกินจำนวนครั้งไม่ได้
+	vc, err := codes.Reserve(ctx, email, codeType, maxAttempts)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, 0, errInvalidCode
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !utils.CheckOTP(code, vc.Hash) {
+		if vc.Attempts >= maxAttempts {
+			// ผิดครบจำนวนครั้งแล้ว — ยกเลิกรหัสนี้ ต้องขอรหัสใหม่
+			if _, err := codes.MarkUsed(ctx, vc.ID); err != nil {
+				return 0, 0, err
+			}
+		}
+		return 0, 0, errInvalidCode
+	}
+
+	return vc.ID, vc.UserID, nil
+}
+
+// recordFailedLogin นับครั้งที่ใส่รหัสผ่านผิดติดกัน (เก็บใน DB จึงไม่หายตอน restart และนับต่อบัญชีไม่ใช่ต่อ IP)
+// ผิดครบ LoginMaxFailures จะล็อกบัญชีชั่วคราวและส่งอีเมลแจ้งเจ้าของบัญชี
+func recordFailedLogin(c *gin.Context, st *store.Store, u *store.User) error {
+	failures, err := st.Users.RecordLoginFailure(c.Request.Context(), u.ID)
+	if err != nil {
+		return err
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: u.ID, Action: utils.AuditLoginFailed,
+		Metadata: map[string]interface{}{"reason": "wrong_password", "failures": failures},
+	})
+
+	lockFor := utils.LoginLockoutDuration(failures)
+	if lockFor == 0 {
+		return nil
+	}
+
+	if err := st.Users.Lock(c.Request.Context(), u.ID, lockFor); err != nil {
+		return err
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: u.ID, Action: utils.AuditLoginLocked,
+		Metadata: map[string]interface{}{"failures": failures, "locked_seconds": int(lockFor.Seconds())},
+	})
+
+	if err := utils.SendAccountLockedEmail(c.Request.Context(), st.Outbox, u.Email, u.Locale, lockFor); err != nil {
+		slog.Warn("ใส่อีเมลแจ้งล็อกบัญชีลงคิวไม่สำเร็จ", "user_id", u.ID, "error", err)
+	}
+
+	return nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash คือ bcrypt hash ที่ไม่ตรงกับบัญชีใด ใช้ตอนไม่พบอีเมลให้ใช้เวลาเท่ากับการตรวจรหัสผ่านจริง
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("porthub-dummy-password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
 }
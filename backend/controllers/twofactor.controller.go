@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/store"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// จำนวน recovery code ที่ออกให้ต่อครั้ง (แต่ละรหัสใช้ได้ครั้งเดียว)
+const recoveryCodeCount = 10
+
+// อายุและจำนวนครั้งที่กรอกผิดได้ของ challenge ที่ได้จาก /login เมื่อเปิด 2FA
+const (
+	loginChallengeTTL         = 5 * time.Minute
+	loginChallengeMaxAttempts = 5
+)
+
+// ---------------------------------------------------------
+// 1. 2FA Status
+// ---------------------------------------------------------
+func TwoFactorStatus(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	enabled, err := twoFactorEnabled(c.Request.Context(), st.TwoFactor, userID)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	var remaining int
+	if enabled {
+		remaining, err = st.TwoFactor.RemainingRecoveryCodes(c.Request.Context(), userID)
+		if err != nil {
+			utils.Internal(c, "Database error")
+			return
+		}
+	}
+
+	utils.Success(c, 200, "สถานะการยืนยันตัวตนสองชั้น", gin.H{
+		"enabled":                  enabled,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// ---------------------------------------------------------
+// 2. Enroll — สร้าง secret ใหม่ (ยังไม่เปิดใช้จนกว่าจะ confirm)
+// ---------------------------------------------------------
+func TwoFactorEnroll(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	u, err := st.Users.Get(c.Request.Context(), userID)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	enabled, err := twoFactorEnabled(c.Request.Context(), st.TwoFactor, userID)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	if enabled {
+		utils.Error(c, 409, "เปิดใช้การยืนยันตัวตนสองชั้นอยู่แล้ว", "TWO_FACTOR_ALREADY_ENABLED")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.Internal(c, "สร้าง secret ไม่สำเร็จ")
+		return
+	}
+
+	encrypted, err := utils.EncryptTOTPSecret(secret)
+	if err != nil {
+		utils.Internal(c, "สร้าง secret ไม่สำเร็จ")
+		return
+	}
+
+	// enroll ซ้ำได้ก่อน confirm — secret ใหม่จะแทนที่อันเดิม
+	if err := st.TwoFactor.Enroll(c.Request.Context(), userID, encrypted); err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	utils.Success(c, 200, "สแกน QR code ด้วยแอป Authenticator แล้วยืนยันด้วยรหัส 6 หลัก", gin.H{
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(secret, u.Email),
+	})
+}
+
+// ---------------------------------------------------------
+// 3. Confirm — ตรวจรหัสแรกจากแอป แล้วเปิดใช้ 2FA + ออก recovery codes
+// ---------------------------------------------------------
+func TwoFactorConfirm(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		utils.BadRequest(c, "กรุณากรอกรหัส 6 หลักจากแอป Authenticator")
+		return
+	}
+
+	totp, err := st.TwoFactor.Get(c.Request.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.BadRequest(c, "กรุณาเริ่มตั้งค่าการยืนยันตัวตนสองชั้นก่อน")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if totp.Enabled {
+		utils.Error(c, 409, "เปิดใช้การยืนยันตัวตนสองชั้นอยู่แล้ว", "TWO_FACTOR_ALREADY_ENABLED")
+		return
+	}
+
+	secret, err := utils.DecryptTOTPSecret(totp.SecretEncrypted)
+	if err != nil {
+		utils.Internal(c, "อ่าน secret ไม่สำเร็จ")
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(secret, input.Code, time.Now(), 0)
+	if !ok {
+		utils.Unauthorized(c, "รหัสไม่ถูกต้อง")
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		utils.Internal(c, "สร้าง recovery code ไม่สำเร็จ")
+		return
+	}
+
+	if err := st.TwoFactor.Enable(c.Request.Context(), userID, step, hashes); err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditTwoFactorEnabled,
+	})
+
+	utils.Success(c, 200, "เปิดใช้การยืนยันตัวตนสองชั้นสำเร็จ เก็บ recovery codes ไว้ในที่ปลอดภัย", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// ---------------------------------------------------------
+// 4. Disable — ต้องยืนยันทั้งรหัสผ่านและรหัส 2FA (TOTP หรือ recovery code)
+// ---------------------------------------------------------
+func TwoFactorDisable(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	var input struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+		return
+	}
+
+	if _, err := checkCurrentPassword(c.Request.Context(), st.Users, userID, input.Password); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			utils.Unauthorized(c, "รหัสผ่านไม่ถูกต้อง")
+			return
+		}
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	ok, err := verifySecondFactor(c.Request.Context(), st.TwoFactor, userID, input.Code, input.RecoveryCode)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if !ok {
+		utils.Unauthorized(c, "รหัสยืนยันตัวตนสองชั้นไม่ถูกต้อง")
+		return
+	}
+
+	if err := st.TwoFactor.Disable(c.Request.Context(), userID); err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditTwoFactorDisabled,
+	})
+
+	utils.Success(c, 200, "ปิดการยืนยันตัวตนสองชั้นแล้ว", nil)
+}
+
+// ---------------------------------------------------------
+// 5. Regenerate Recovery Codes — ชุดเดิมใช้ไม่ได้ทันที
+// ---------------------------------------------------------
+func TwoFactorRegenerateRecoveryCodes(c *gin.Context, st *store.Store) {
+
+	userID := c.GetInt("user_id")
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		utils.BadRequest(c, "กรุณากรอกรหัส 6 หลักจากแอป Authenticator")
+		return
+	}
+
+	ok, err := verifySecondFactor(c.Request.Context(), st.TwoFactor, userID, input.Code, "")
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if !ok {
+		utils.Unauthorized(c, "รหัสไม่ถูกต้อง")
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		utils.Internal(c, "สร้าง recovery code ไม่สำเร็จ")
+		return
+	}
+
+	if err := st.TwoFactor.ReplaceRecoveryCodes(c.Request.Context(), userID, hashes); err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditRecoveryCodesRenewed,
+	})
+
+	utils.Success(c, 200, "สร้าง recovery codes ใหม่แล้ว", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// ---------------------------------------------------------
+// 6. Login 2FA — ขั้นที่สองของ /login เมื่อเปิด 2FA
+// ---------------------------------------------------------
+func LoginTwoFactor(c *gin.Context, st *store.Store) {
+
+	var input struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.ChallengeToken == "" {
+		utils.BadRequest(c, "ข้อมูลไม่ถูกต้อง")
+		return
+	}
+
+	// นับ attempts แบบ atomic ก่อนตรวจรหัส — challenge ใช้ได้ไม่เกิน loginChallengeMaxAttempts ครั้ง
+	challengeHash := utils.HashToken(input.ChallengeToken)
+	userID, err := st.TwoFactor.ReserveChallenge(c.Request.Context(), challengeHash, loginChallengeMaxAttempts)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.Unauthorized(c, "หมดเวลายืนยันตัวตน กรุณาเข้าสู่ระบบใหม่")
+		return
+	}
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+
+	ok, err := verifySecondFactor(c.Request.Context(), st.TwoFactor, userID, input.Code, input.RecoveryCode)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if !ok {
+		utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+			UserID: userID, Action: utils.AuditLoginTwoFactorFailed,
+		})
+		utils.Unauthorized(c, "รหัสยืนยันตัวตนสองชั้นไม่ถูกต้อง")
+		return
+	}
+
+	used, err := st.TwoFactor.UseChallenge(c.Request.Context(), challengeHash)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if !used {
+		utils.Unauthorized(c, "หมดเวลายืนยันตัวตน กรุณาเข้าสู่ระบบใหม่")
+		return
+	}
+
+	u, err := st.Users.Get(c.Request.Context(), userID)
+	if err != nil {
+		utils.Internal(c, "Database error")
+		return
+	}
+	if u.Suspended {
+		utils.Error(c, 403, "บัญชีนี้ถูกระงับการใช้งาน กรุณาติดต่อผู้ดูแลระบบ", "ACCOUNT_SUSPENDED")
+		return
+	}
+
+	token, refreshToken, err := createSession(c, st.Sessions, userID, u.Role)
+	if err != nil {
+		utils.Internal(c, "สร้าง token ไม่สำเร็จ")
+		return
+	}
+
+	utils.RecordAuditEvent(st.Audit, c, utils.AuditEvent{
+		UserID: userID, ActorID: userID, Action: utils.AuditLogin,
+		Metadata: map[string]interface{}{"two_factor": true, "recovery_code": input.RecoveryCode != ""},
+	})
+
+	utils.Success(c, 200, "เข้าสู่ระบบสำเร็จ!", gin.H{
+		"token":          token,
+		"refresh_token":  refreshToken,
+		"expires_in":     int(utils.AccessTokenTTL.Seconds()),
+		"role":           u.Role,
+		"email_verified": u.EmailVerified,
+	})
+}
+
+// twoFactorEnabled บอกว่า user เปิดใช้ 2FA แล้วหรือยัง (enroll แล้วแต่ยังไม่ confirm ถือว่ายังไม่เปิด)
+func twoFactorEnabled(ctx context.Context, tf store.TwoFactorStore, userID int) (bool, error) {
+	totp, err := tf.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return totp.Enabled, nil
+}
+
+// createLoginChallenge ออก challenge token สำหรับขั้นที่สองของการ login (เก็บเฉพาะ hash)
+func createLoginChallenge(ctx context.Context, tf store.TwoFactorStore, userID int) (string, error) {
+	challenge, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := tf.CreateChallenge(ctx, utils.HashToken(challenge), userID, time.Now().Add(loginChallengeTTL)); err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+// verifySecondFactor ตรวจรหัส TOTP (ถ้าส่ง code) หรือ recovery code (ถ้าส่ง recoveryCode)
+// TOTP ที่ใช้แล้วใช้ซ้ำไม่ได้ และ recovery code แต่ละอันใช้ได้ครั้งเดียว
+func verifySecondFactor(ctx context.Context, tf store.TwoFactorStore, userID int, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return tf.UseRecoveryCode(ctx, userID, utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode)))
+	}
+
+	if code == "" {
+		return false, nil
+	}
+
+	totp, err := tf.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !totp.Enabled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := utils.DecryptTOTPSecret(totp.SecretEncrypted)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now(), totp.LastUsedStep)
+	if !ok {
+		return false, nil
+	}
+
+	// บันทึก step ล่าสุด — store จะไม่ยอมถ้ามี request ที่ใช้รหัสเดียวกันบันทึกไปก่อน
+	return tf.AdvanceStep(ctx, userID, step)
+}
+
+// newRecoveryCodes สร้าง recovery codes ชุดใหม่ คืนรหัสจริง (แสดงกับผู้ใช้ครั้งเดียว) และ hash ที่เก็บใน store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(code))
+	}
+	return codes, hashes, nil
+}
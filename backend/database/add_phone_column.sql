@@ -1,2 +0,0 @@
--- Migration: Add phone column to users table
-ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(20);
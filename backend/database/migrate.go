@@ -0,0 +1,368 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ไฟล์ migration อยู่ใน database/migrations ชื่อ <version>_<name>.up.sql และ <version>_<name>.down.sql
+// version เรียงจากน้อยไปมาก ห้ามแก้ไฟล์ที่ apply ไปแล้ว — เพิ่มไฟล์ใหม่แทน (checksum จะฟ้องถ้าแก้)
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// migrationLockKey คือ key ของ pg_advisory_lock — replica ที่ migrate พร้อมกันจะรอกันทีละตัว
+const migrationLockKey int64 = 0x706f7274687562 // "porthub"
+
+// ErrSchemaBehind คือ database ยังมี migration ที่ไม่ได้ apply
+var ErrSchemaBehind = errors.New("database schema is behind — run `migrate up`")
+
+type Migration struct {
+	Version  int64
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string // SHA-256 ของ UpSQL
+}
+
+// MigrationState คือสถานะของ migration หนึ่งตัวสำหรับ `migrate status`
+type MigrationState struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	// Pending = ยังไม่ apply, Modified = ไฟล์ถูกแก้หลัง apply, Missing = apply แล้วแต่ไม่มีไฟล์ใน binary นี้
+	Pending  bool
+	Modified bool
+	Missing  bool
+}
+
+// LoadMigrations อ่านไฟล์ migration ทั้งหมดที่ embed ไว้ เรียงตาม version
+func LoadMigrations() ([]Migration, error) {
+	migrations, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return loadMigrations(migrations)
+}
+
+// loadMigrations อ่านไฟล์ migration จากราก fsys (แยกออกมาให้ test ใช้ fstest.MapFS ได้)
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration: ชื่อไฟล์ไม่ถูกต้อง %q", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		raw, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration: version %d มีสองชื่อ (%s, %s)", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.UpSQL = string(raw)
+			sum := sha256.Sum256(raw)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.DownSQL = string(raw)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration: %04d_%s ไม่มีไฟล์ .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// queryer คือสิ่งที่ *sql.DB, *sql.Conn และ *sql.Tx มีเหมือนกัน
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func ensureMigrationsTable(ctx context.Context, q queryer) error {
+	_, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+func loadApplied(ctx context.Context, q queryer) (map[int64]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// withMigrationLock ถือ advisory lock บน connection เดียวตลอดการ migrate
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("migration: lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// checkChecksums ไม่ยอมให้ migrate ต่อถ้าไฟล์ที่ apply ไปแล้วถูกแก้
+func checkChecksums(migrations []Migration, applied map[int64]appliedMigration) error {
+	for _, m := range migrations {
+		if a, ok := applied[m.Version]; ok && a.checksum != m.Checksum {
+			return fmt.Errorf("migration: %04d_%s ถูกแก้หลัง apply แล้ว (checksum ไม่ตรง) — เพิ่ม migration ใหม่แทนการแก้ไฟล์เดิม", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// MigrateUp apply migration ที่ยังค้างทั้งหมดตามลำดับ แต่ละตัวอยู่ใน transaction ของตัวเอง
+// คืนรายการที่ apply ในครั้งนี้
+func MigrateUp(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkChecksums(migrations, applied); err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := runInTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, m.UpSQL); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+					m.Version, m.Name, m.Checksum)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration: %04d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown ย้อน migration ล่าสุด steps ตัว (ใช้ไฟล์ .down.sql)
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkChecksums(migrations, applied); err != nil {
+			return err
+		}
+		plan, err := planDown(migrations, applied, steps)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range plan {
+			if err := runInTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, m.DownSQL); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration: %04d_%s (down): %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// planDown เลือก migration ที่จะย้อน — ตัวที่ apply ล่าสุดก่อน ไม่เกิน steps ตัว
+// ตรวจครบทุกตัวก่อนเริ่ม เพื่อไม่ให้ย้อนไปได้ครึ่งทางแล้วเจอตัวที่ไม่มีไฟล์ .down.sql
+func planDown(migrations []Migration, applied map[int64]appliedMigration, steps int) ([]Migration, error) {
+	byVersion := map[int64]Migration{}
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var plan []Migration
+	for i := 0; i < steps && i < len(versions); i++ {
+		m, ok := byVersion[versions[i]]
+		if !ok {
+			return nil, fmt.Errorf("migration: version %d apply แล้วแต่ไม่มีไฟล์ใน build นี้", versions[i])
+		}
+		if m.DownSQL == "" {
+			return nil, fmt.Errorf("migration: %04d_%s ไม่มีไฟล์ .down.sql", m.Version, m.Name)
+		}
+		plan = append(plan, m)
+	}
+	return plan, nil
+}
+
+// MigrationStatus คืนสถานะของทุก migration ทั้งที่มีไฟล์และที่ apply แล้ว
+func MigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationState, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	// อ่านอย่างเดียว — ยังไม่มีตาราง schema_migrations = ยังไม่เคย migrate
+	applied := map[int64]appliedMigration{}
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		if applied, err = loadApplied(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	var states []MigrationState
+	known := map[int64]bool{}
+	for _, m := range migrations {
+		known[m.Version] = true
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			appliedAt := a.appliedAt
+			state.AppliedAt = &appliedAt
+			state.Modified = a.checksum != m.Checksum
+		} else {
+			state.Pending = true
+		}
+		states = append(states, state)
+	}
+	for version, a := range applied {
+		if !known[version] {
+			appliedAt := a.appliedAt
+			states = append(states, MigrationState{Version: version, Name: a.name, AppliedAt: &appliedAt, Missing: true})
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
+
+// CheckMigrations ใช้ตอนเริ่ม server — คืน ErrSchemaBehind ถ้ายังมี migration ค้าง หรือ error ถ้าไฟล์ถูกแก้
+// migration ที่ apply แล้วแต่ไม่มีไฟล์ (database ใหม่กว่า binary) ไม่ถือเป็น error เพื่อให้ rollback binary ได้
+func CheckMigrations(ctx context.Context, db *sql.DB) error {
+	states, err := MigrationStatus(ctx, db)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range states {
+		if s.Modified {
+			return fmt.Errorf("migration: %04d_%s ถูกแก้หลัง apply แล้ว (checksum ไม่ตรง)", s.Version, s.Name)
+		}
+		if s.Pending {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w (pending: %v)", ErrSchemaBehind, pending)
+	}
+	return nil
+}
+
+func runInTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// AppliedAtString คืนเวลาที่ apply ในรูปแบบอ่านง่าย (ว่างถ้ายังไม่ apply)
+func (s MigrationState) AppliedAtString() string {
+	if s.AppliedAt == nil {
+		return ""
+	}
+	return s.AppliedAt.Format("2006-01-02 15:04:05")
+}
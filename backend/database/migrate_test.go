@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLoadMigrationsEmbedded(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("%04d_%s is not after %04d", m.Version, m.Name, migrations[i-1].Version)
+		}
+		sum := sha256.Sum256([]byte(m.UpSQL))
+		if m.Checksum != hex.EncodeToString(sum[:]) {
+			t.Errorf("%04d_%s: checksum is not the SHA-256 of the up file", m.Version, m.Name)
+		}
+		if m.DownSQL == "" {
+			t.Errorf("%04d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":     {"1_Init.up.sql": {Data: []byte("SELECT 1")}},
+		"missing up":   {"0001_init.down.sql": {Data: []byte("SELECT 1")}},
+		"two names":    {"0001_init.up.sql": {Data: []byte("SELECT 1")}, "0001_other.down.sql": {Data: []byte("SELECT 1")}},
+		"no extension": {"0001_init.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// testMigrations คืน migration 1-3 ที่มีไฟล์ down ครบ
+func testMigrations(t *testing.T) []Migration {
+	t.Helper()
+	migrations, err := loadMigrations(fstest.MapFS{
+		"0003_three.up.sql":   {Data: []byte("CREATE TABLE three (id INT)")},
+		"0003_three.down.sql": {Data: []byte("DROP TABLE three")},
+		"0001_one.up.sql":     {Data: []byte("CREATE TABLE one (id INT)")},
+		"0001_one.down.sql":   {Data: []byte("DROP TABLE one")},
+		"0002_two.up.sql":     {Data: []byte("CREATE TABLE two (id INT)")},
+		"0002_two.down.sql":   {Data: []byte("DROP TABLE two")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrations
+}
+
+// appliedFrom ทำเหมือนว่า migrations ทุกตัว apply ไปแล้วด้วย checksum ปัจจุบัน
+func appliedFrom(migrations []Migration) map[int64]appliedMigration {
+	applied := map[int64]appliedMigration{}
+	for _, m := range migrations {
+		applied[m.Version] = appliedMigration{name: m.Name, checksum: m.Checksum, appliedAt: time.Now()}
+	}
+	return applied
+}
+
+func TestCheckChecksums(t *testing.T) {
+	migrations := testMigrations(t)
+	applied := appliedFrom(migrations[:2])
+
+	// ตัวที่ยังไม่ apply ไม่ต้องตรวจ
+	if err := checkChecksums(migrations, applied); err != nil {
+		t.Fatalf("unchanged files rejected: %v", err)
+	}
+
+	edited := append([]Migration(nil), migrations...)
+	edited[1].UpSQL += "\n-- edited"
+	sum := sha256.Sum256([]byte(edited[1].UpSQL))
+	edited[1].Checksum = hex.EncodeToString(sum[:])
+	err := checkChecksums(edited, applied)
+	if err == nil {
+		t.Fatal("edited migration accepted")
+	}
+	if !strings.Contains(err.Error(), "0002_two") {
+		t.Fatalf("error does not name the edited migration: %v", err)
+	}
+}
+
+func TestPlanDown(t *testing.T) {
+	migrations := testMigrations(t)
+
+	versions := func(plan []Migration) []int64 {
+		var v []int64
+		for _, m := range plan {
+			v = append(v, m.Version)
+		}
+		return v
+	}
+	tests := []struct {
+		name    string
+		applied map[int64]appliedMigration
+		steps   int
+		want    []int64
+	}{
+		{"latest first", appliedFrom(migrations), 1, []int64{3}},
+		{"several steps in reverse order", appliedFrom(migrations), 2, []int64{3, 2}},
+		{"steps beyond applied", appliedFrom(migrations[:2]), 5, []int64{2, 1}},
+		{"nothing applied", appliedFrom(nil), 1, nil},
+	}
+	for _, tt := range tests {
+		plan, err := planDown(migrations, tt.applied, tt.steps)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := versions(plan); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: plan = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// database ใหม่กว่า binary — ย้อนตัวที่ไม่มีไฟล์ไม่ได้
+	applied := appliedFrom(migrations)
+	applied[4] = appliedMigration{name: "four", checksum: "x"}
+	if _, err := planDown(migrations, applied, 1); err == nil {
+		t.Error("planned a migration that has no files")
+	}
+
+	// ไม่มี .down.sql ตัวไหนในช่วงที่ขอ → ไม่ย้อนสักตัว
+	noDown := append([]Migration(nil), migrations...)
+	noDown[1].DownSQL = ""
+	if plan, err := planDown(noDown, appliedFrom(noDown), 3); err == nil {
+		t.Errorf("planned %v through a migration without a down file", versions(plan))
+	}
+}
+
+func TestRunInTxReportsRollbackError(t *testing.T) {
+	db, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fnErr := errors.New("boom")
+	err = runInTx(ctx, conn, func(tx *sql.Tx) error {
+		// commit เองก่อน — Rollback ของ runInTx จะได้ sql.ErrTxDone
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) || !errors.Is(err, sql.ErrTxDone) {
+		t.Fatalf("err = %v, want both the fn error and the rollback error", err)
+	}
+
+	err = runInTx(ctx, conn, func(tx *sql.Tx) error { return fnErr })
+	if !errors.Is(err, fnErr) || errors.Is(err, sql.ErrTxDone) {
+		t.Fatalf("err = %v, want only the fn error", err)
+	}
+}
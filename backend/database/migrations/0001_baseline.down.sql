@@ -0,0 +1,21 @@
+-- ลบ schema ทั้งหมด — ข้อมูลทุกอย่างหายด้วย ใช้กับ database สำหรับ dev / test เท่านั้น
+DROP TABLE IF EXISTS
+    published_projects,
+    published_profiles,
+    rate_limit_buckets,
+    mail_outbox,
+    audit_events,
+    login_challenges,
+    recovery_codes,
+    user_totp,
+    moderation_actions,
+    user_skills,
+    skills,
+    projects,
+    sessions,
+    password_reset_tokens,
+    verification_codes,
+    users
+CASCADE;
+
+DROP FUNCTION IF EXISTS audit_events_append_only();
@@ -0,0 +1,274 @@
+-- Baseline: schema ทั้งหมดก่อนมีระบบ migration แบบมีเวอร์ชัน
+-- เขียนแบบ IF NOT EXISTS ทั้งไฟล์ — database เดิมที่สร้างจาก init.sql / migration ใน main.go รันทับได้
+-- แล้วจะได้ schema เท่ากับ database ที่สร้างใหม่
+
+-- 1. USERS (เก็บข้อมูลพื้นฐาน)
+CREATE TABLE IF NOT EXISTS users (
+    user_id SERIAL PRIMARY KEY,
+    user_name VARCHAR(255),
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    phone VARCHAR(50),
+    university VARCHAR(255),
+    faculty VARCHAR(255),
+    major VARCHAR(255),
+    gpa DECIMAL(3,2),
+    job_interest TEXT,
+    profile_image_url TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- คอลัมน์ที่เพิ่มทีหลัง (database เก่าอาจยังไม่มี)
+ALTER TABLE users ALTER COLUMN user_name TYPE VARCHAR(255);
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS phone VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS show_on_dashboard BOOLEAN DEFAULT false,
+    ADD COLUMN IF NOT EXISTS role VARCHAR(30) NOT NULL DEFAULT 'student',
+    ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP, -- ไม่ใช่ NULL = ถูก admin ระงับ (login / publish ไม่ได้)
+    ADD COLUMN IF NOT EXISTS suspension_reason TEXT,
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'th', -- ภาษาของอีเมลที่ระบบส่ง (th / en)
+    ADD COLUMN IF NOT EXISTS failed_login_count INTEGER NOT NULL DEFAULT 0, -- รหัสผ่านผิดติดกันกี่ครั้ง
+    ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP; -- ล็อกชั่วคราวจนถึงเวลานี้
+
+UPDATE users SET show_on_dashboard = false WHERE show_on_dashboard IS NULL;
+
+-- verified_at: NULL = ยังไม่ได้ยืนยันอีเมล — user ที่สมัครก่อนมีระบบยืนยันอีเมลถือว่ายืนยันแล้ว
+-- backfill เฉพาะตอนที่เพิ่มคอลัมน์นี้ครั้งแรก (database ที่มีคอลัมน์อยู่แล้ว NULL คือยังไม่ยืนยันจริงๆ)
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'verified_at'
+    ) THEN
+        ALTER TABLE users ADD COLUMN verified_at TIMESTAMP;
+        UPDATE users SET verified_at = COALESCE(created_at, NOW()) WHERE verified_at IS NULL;
+    END IF;
+END;
+$$;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('student', 'recruiter', 'university_admin', 'platform_admin'));
+
+-- 2. VERIFICATION CODES (Forgot Password / ยืนยันอีเมล / เปลี่ยนอีเมล)
+-- code เก็บ HMAC-SHA256 (hex) ของรหัส ไม่เก็บรหัสจริง
+CREATE TABLE IF NOT EXISTS verification_codes (
+    code_id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    code VARCHAR(64) NOT NULL,
+    type VARCHAR(50) DEFAULT 'forgot_password', -- 'forgot_password' | 'email_verify' | 'email_change'
+    is_used BOOLEAN DEFAULT FALSE,
+    expired_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE verification_codes
+    ALTER COLUMN code TYPE VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0, -- กรอกผิดครบ OTP_MAX_ATTEMPTS ครั้งรหัสจะถูกยกเลิก
+    ADD COLUMN IF NOT EXISTS new_email VARCHAR(255); -- อีเมลใหม่ที่รอยืนยัน (เฉพาะ type 'email_change')
+
+-- รหัสแบบ plain text ที่ค้างจากเวอร์ชันก่อนใช้ไม่ได้แล้ว
+DELETE FROM verification_codes WHERE LENGTH(code) < 64;
+
+-- 2.1 PASSWORD RESET TOKENS (ออกให้หลัง Verify OTP สำเร็จ ใช้ได้ครั้งเดียว)
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    token_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 ของ token (ไม่เก็บ token จริง)
+    expired_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 2.2 SESSIONS (1 แถวต่อการ login, ใช้ revoke access token และหมุน refresh token)
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+    previous_token_hash VARCHAR(64), -- refresh token ก่อนหมุน ใช้ตรวจจับการนำ token เก่ากลับมาใช้
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expired_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+-- 3. PROJECTS
+CREATE TABLE IF NOT EXISTS projects (
+    project_id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    project_name VARCHAR(255),
+    description TEXT,
+    image_url TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMP; -- ไม่ใช่ NULL = admin ลบออกจาก Dashboard แล้ว (publish ใหม่ก็ไม่ขึ้น)
+
+-- 4. SKILLS
+CREATE TABLE IF NOT EXISTS skills (
+    skill_id SERIAL PRIMARY KEY,
+    skill_name VARCHAR(100) UNIQUE
+);
+
+-- 5. USER_SKILLS (Many-to-Many)
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    skill_id INTEGER REFERENCES skills(skill_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, skill_id)
+);
+
+-- 6. MODERATION_ACTIONS (ประวัติการ moderate ของ admin พร้อมเหตุผล)
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    action_id SERIAL PRIMARY KEY,
+    admin_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    target_user_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL, -- unpublish_profile | suspend_user | unsuspend_user | delete_published_project
+    reason TEXT NOT NULL,
+    project_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_target ON moderation_actions(target_user_id, created_at DESC);
+
+-- 7. USER_TOTP (การยืนยันตัวตนสองชั้น — secret เข้ารหัสด้วย AES-GCM)
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id INTEGER PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled_at TIMESTAMP,             -- NULL = enroll แล้วแต่ยังไม่ confirm
+    last_used_step BIGINT NOT NULL DEFAULT 0, -- กันใช้รหัส TOTP ซ้ำ
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 8. RECOVERY_CODES (เก็บเฉพาะ hash, ใช้ได้ครั้งเดียว)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    code_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- 9. LOGIN_CHALLENGES (ขั้นที่สองของการ login เมื่อเปิด 2FA)
+CREATE TABLE IF NOT EXISTS login_challenges (
+    challenge_hash VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expired_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 10. AUDIT_EVENTS (บันทึกเหตุการณ์ด้านความปลอดภัย แบบ append-only)
+CREATE TABLE IF NOT EXISTS audit_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER,  -- บัญชีที่ถูกกระทำ (ไม่มี FK — ประวัติยังอยู่หลังลบบัญชี)
+    actor_id INTEGER, -- คนที่ทำ (NULL = ไม่ทราบ เช่น login ผิดจากคนนอก)
+    action VARCHAR(64) NOT NULL, -- เช่น auth.login, auth.login_failed, account.deleted
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_user ON audit_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, created_at DESC);
+
+-- append-only: ห้าม UPDATE / DELETE
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_modify ON audit_events;
+CREATE TRIGGER audit_events_no_modify BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- 11. MAIL_OUTBOX (คิวอีเมลขาออก ส่งโดย mail.Worker)
+CREATE TABLE IF NOT EXISTS mail_outbox (
+    outbox_id BIGSERIAL PRIMARY KEY,
+    to_email VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    html_body TEXT,
+    text_body TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending | sent | failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- retry ครั้งถัดไป (exponential backoff)
+    locked_until TIMESTAMP, -- worker ที่จองไว้กำลังส่งอยู่
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    failed_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_pending ON mail_outbox(next_attempt_at) WHERE status = 'pending';
+
+-- 12. RATE_LIMIT_BUCKETS (ถัง token ของ rate limiter เมื่อ RATE_LIMIT_STORE=postgres)
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key VARCHAR(512) PRIMARY KEY, -- <policy>|ip:... / user:... / email:...
+    tokens DOUBLE PRECISION NOT NULL, -- token ที่เหลือ ณ updated_at
+    allowed BOOLEAN NOT NULL DEFAULT TRUE, -- ผลของการขอ token ครั้งล่าสุด
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL -- หลังจากนี้ถังเต็มแล้ว ลบทิ้งได้
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_expires ON rate_limit_buckets(expires_at);
+
+-- 13. PUBLISHED_PROFILES / PUBLISHED_PROJECTS (snapshot ที่แสดงใน Dashboard — ไม่ใช้ foreign key)
+CREATE TABLE IF NOT EXISTS published_profiles (
+    user_id INTEGER PRIMARY KEY,
+    user_name VARCHAR(255),
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    university VARCHAR(255),
+    faculty VARCHAR(255),
+    major VARCHAR(255),
+    gpa DECIMAL(3,2),
+    job_interest TEXT,
+    profile_image_url TEXT,
+    skills TEXT, -- JSON array of skills
+    published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS published_projects (
+    published_project_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    project_name VARCHAR(255),
+    description TEXT,
+    image_url TEXT, -- JSON array of images
+    published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, project_id)
+);
+CREATE INDEX IF NOT EXISTS idx_published_projects_user_id ON published_projects(user_id);
+
+-- user ที่ publish ไว้ก่อนมีตาราง published_* → คัดลอก snapshot ให้ครั้งเดียว
+INSERT INTO published_profiles (
+    user_id, user_name, email, phone, university, faculty, major, gpa,
+    job_interest, profile_image_url, skills, published_at, updated_at
+)
+SELECT
+    u.user_id, u.user_name, u.email, u.phone, u.university, u.faculty, u.major, u.gpa,
+    u.job_interest, u.profile_image_url,
+    COALESCE(
+        (
+            SELECT json_agg(s.skill_name)::text
+            FROM user_skills us
+            JOIN skills s ON us.skill_id = s.skill_id
+            WHERE us.user_id = u.user_id
+        ),
+        '[]'
+    ),
+    NOW(), NOW()
+FROM users u
+WHERE u.show_on_dashboard = true
+ON CONFLICT (user_id) DO NOTHING;
+
+INSERT INTO published_projects (user_id, project_id, project_name, description, image_url, published_at)
+SELECT p.user_id, p.project_id, p.project_name, p.description, p.image_url, NOW()
+FROM projects p
+WHERE p.user_id IN (SELECT user_id FROM users WHERE show_on_dashboard = true)
+ON CONFLICT (user_id, project_id) DO NOTHING;
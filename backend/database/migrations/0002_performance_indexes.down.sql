@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS
+    idx_users_show_on_dashboard,
+    idx_users_user_name,
+    idx_published_profiles_user_name,
+    idx_published_profiles_updated_at,
+    idx_published_projects_published_at,
+    idx_projects_composite,
+    idx_user_skills_skill_id,
+    idx_skills_skill_name_lower;
@@ -0,0 +1,17 @@
+-- 🚀 Index สำหรับ query ที่ใช้บ่อย (เดิมอยู่ใน optimize_indexes.sql ที่ต้องรันเอง)
+
+-- users
+CREATE INDEX IF NOT EXISTS idx_users_show_on_dashboard ON users(show_on_dashboard) WHERE show_on_dashboard = true;
+CREATE INDEX IF NOT EXISTS idx_users_user_name ON users(user_name);
+
+-- published_profiles / published_projects
+CREATE INDEX IF NOT EXISTS idx_published_profiles_user_name ON published_profiles(user_name);
+CREATE INDEX IF NOT EXISTS idx_published_profiles_updated_at ON published_profiles(updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_published_projects_published_at ON published_projects(published_at DESC);
+
+-- projects
+CREATE INDEX IF NOT EXISTS idx_projects_composite ON projects(user_id, created_at DESC);
+
+-- user_skills / skills
+CREATE INDEX IF NOT EXISTS idx_user_skills_skill_id ON user_skills(skill_id);
+CREATE INDEX IF NOT EXISTS idx_skills_skill_name_lower ON skills(LOWER(skill_name));
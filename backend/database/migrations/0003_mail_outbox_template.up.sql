@@ -0,0 +1,2 @@
+-- ชื่อ template ของอีเมลแต่ละฉบับ (password_reset_otp, email_verify, ...) — ใช้แยก metrics ส่งสำเร็จ/ล้มเหลวตามประเภท
+ALTER TABLE mail_outbox ADD COLUMN IF NOT EXISTS template VARCHAR(64);
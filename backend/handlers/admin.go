@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/store"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// moderationTarget is the user an admin action applies to, after the scope check.
+type moderationTarget struct {
+	userID    int
+	suspended bool
+}
+
+// AdminListUsers lists and searches users. University admins only see users from their own university.
+// Query: q (name/email), role, status (active|suspended|unverified|published), limit, offset.
+func AdminListUsers(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		adminUniversity, ok := adminScope(c, users)
+		if !ok {
+			return
+		}
+
+		f := store.UserFilter{
+			University: adminUniversity,
+			Query:      strings.TrimSpace(c.Query("q")),
+			Role:       c.Query("role"),
+			Status:     c.Query("status"),
+		}
+		if f.Role != "" && !utils.IsValidRole(f.Role) {
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid role")
+			return
+		}
+		switch f.Status {
+		case "", store.UserStatusActive, store.UserStatusSuspended, store.UserStatusUnverified, store.UserStatusPublished:
+		default:
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid status")
+			return
+		}
+
+		limit, offset := pageParams(c)
+		found, total, err := moderation.ListUsers(c.Request.Context(), f, limit, offset)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		list := make([]gin.H, 0, len(found))
+		for _, u := range found {
+			list = append(list, gin.H{
+				"user_id":           u.ID,
+				"user_name":         u.UserName,
+				"email":             u.Email,
+				"university":        u.University,
+				"role":              u.Role,
+				"email_verified":    u.EmailVerified,
+				"show_on_dashboard": u.ShowOnDashboard,
+				"suspended":         !u.SuspendedAt.IsZero(),
+				"created_at":        timeOrNull(u.CreatedAt),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"users":  list,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+}
+
+// AdminGetUser returns one user's account state, published snapshot counts and moderation history.
+func AdminGetUser(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		target, ok := loadModerationTarget(c, users)
+		if !ok {
+			return
+		}
+
+		u, err := moderation.GetUser(c.Request.Context(), target.userID)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		actions, err := moderation.ListActions(c.Request.Context(), store.ActionFilter{TargetUserID: target.userID}, 20, 0)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"user_id":                 u.ID,
+			"user_name":               u.UserName,
+			"email":                   u.Email,
+			"university":              u.University,
+			"role":                    u.Role,
+			"email_verified":          u.EmailVerified,
+			"show_on_dashboard":       u.ShowOnDashboard,
+			"suspended":               !u.SuspendedAt.IsZero(),
+			"suspended_at":            timeOrNull(u.SuspendedAt),
+			"suspension_reason":       u.SuspensionReason,
+			"created_at":              timeOrNull(u.CreatedAt),
+			"project_count":           u.ProjectCount,
+			"published_project_count": u.PublishedProjectCount,
+			"moderation_actions":      moderationActionsJSON(actions),
+		})
+	}
+}
+
+// AdminUnpublishProfile removes a user's profile and projects from the dashboard.
+func AdminUnpublishProfile(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		target, ok := loadModerationTarget(c, users)
+		if !ok {
+			return
+		}
+		reason, ok := bindReason(c)
+		if !ok {
+			return
+		}
+
+		if err := moderation.Unpublish(c.Request.Context(), newModerationAction(c, target, store.ModUnpublishProfile, reason)); err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to unpublish profile")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Profile unpublished"})
+	}
+}
+
+// AdminSuspendUser blocks a user from logging in and publishing, unpublishes them and revokes every session.
+func AdminSuspendUser(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		target, ok := loadModerationTarget(c, users)
+		if !ok {
+			return
+		}
+		reason, ok := bindReason(c)
+		if !ok {
+			return
+		}
+		if target.suspended {
+			utils.ErrorJSON(c, http.StatusConflict, "User is already suspended")
+			return
+		}
+
+		if err := moderation.Suspend(c.Request.Context(), newModerationAction(c, target, store.ModSuspendUser, reason)); err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to suspend user")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User suspended"})
+	}
+}
+
+// AdminUnsuspendUser lifts a suspension. The profile stays unpublished until the user publishes again.
+func AdminUnsuspendUser(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		target, ok := loadModerationTarget(c, users)
+		if !ok {
+			return
+		}
+		reason, ok := bindReason(c)
+		if !ok {
+			return
+		}
+		if !target.suspended {
+			utils.ErrorJSON(c, http.StatusConflict, "User is not suspended")
+			return
+		}
+
+		if err := moderation.Unsuspend(c.Request.Context(), newModerationAction(c, target, store.ModUnsuspendUser, reason)); err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to unsuspend user")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User unsuspended"})
+	}
+}
+
+// AdminDeletePublishedProject removes one project from a user's published snapshot (e.g. "7" or "p7").
+// The user's own copy in projects is kept but flagged, so republishing does not bring it back.
+func AdminDeletePublishedProject(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		target, ok := loadModerationTarget(c, users)
+		if !ok {
+			return
+		}
+		projectID, ok := parseProjectID(c, "projectId")
+		if !ok {
+			return
+		}
+		reason, ok := bindReason(c)
+		if !ok {
+			return
+		}
+
+		a := newModerationAction(c, target, store.ModDeletePublishedProject, reason)
+		a.ProjectID = projectID
+		err := moderation.RemovePublishedProject(c.Request.Context(), a)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Published project not found")
+			return
+		}
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to delete published project")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Published project deleted"})
+	}
+}
+
+// AdminListActions returns the moderation log, newest first. Query: user_id, limit, offset.
+// University admins only see actions on users from their own university.
+func AdminListActions(users store.UserStore, moderation store.ModerationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		adminUniversity, ok := adminScope(c, users)
+		if !ok {
+			return
+		}
+
+		f := store.ActionFilter{University: adminUniversity}
+		if v := c.Query("user_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				utils.ErrorJSON(c, http.StatusBadRequest, "Invalid user id")
+				return
+			}
+			f.TargetUserID = id
+		}
+
+		limit, offset := pageParams(c)
+		actions, err := moderation.ListActions(c.Request.Context(), f, limit, offset)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		c.JSON(http.StatusOK, moderationActionsJSON(actions))
+	}
+}
+
+// adminScope returns the university a university admin is limited to ("" for platform admins).
+func adminScope(c *gin.Context, users store.UserStore) (string, bool) {
+	adminID, ok := getUserID(c)
+	if !ok {
+		utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
+		return "", false
+	}
+
+	if c.GetString("role") == utils.RolePlatformAdmin {
+		return "", true
+	}
+
+	admin, err := users.Get(c.Request.Context(), adminID)
+	if err != nil {
+		utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+		return "", false
+	}
+	if strings.TrimSpace(admin.University) == "" {
+		utils.ErrorJSON(c, http.StatusForbidden, "University admin has no university set")
+		return "", false
+	}
+
+	return admin.University, true
+}
+
+// loadModerationTarget reads :id and checks the caller may moderate that user.
+// Admins can't moderate themselves; university admins can only moderate students and recruiters of their university.
+func loadModerationTarget(c *gin.Context, users store.UserStore) (moderationTarget, bool) {
+	adminUniversity, ok := adminScope(c, users)
+	if !ok {
+		return moderationTarget{}, false
+	}
+
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorJSON(c, http.StatusBadRequest, "Invalid user id")
+		return moderationTarget{}, false
+	}
+
+	adminID, _ := getUserID(c)
+	if targetID == adminID {
+		utils.ErrorJSON(c, http.StatusForbidden, "Admins cannot moderate their own account")
+		return moderationTarget{}, false
+	}
+
+	u, err := users.Get(c.Request.Context(), targetID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.ErrorJSON(c, http.StatusNotFound, "User not found")
+		return moderationTarget{}, false
+	}
+	if err != nil {
+		utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+		return moderationTarget{}, false
+	}
+
+	if adminUniversity != "" {
+		// ตอบ 404 แทน 403 เพื่อไม่ให้รู้ว่ามี user นี้อยู่นอกมหาวิทยาลัยตัวเอง
+		if !strings.EqualFold(strings.TrimSpace(u.University), strings.TrimSpace(adminUniversity)) {
+			utils.ErrorJSON(c, http.StatusNotFound, "User not found")
+			return moderationTarget{}, false
+		}
+		if u.Role != utils.RoleStudent && u.Role != utils.RoleRecruiter {
+			utils.ErrorJSON(c, http.StatusForbidden, "University admins can only moderate students and recruiters")
+			return moderationTarget{}, false
+		}
+	}
+
+	return moderationTarget{userID: targetID, suspended: u.Suspended}, true
+}
+
+// bindReason reads the required moderation reason from the JSON body ({"reason": "..."}) or ?reason=.
+func bindReason(c *gin.Context) (string, bool) {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&input)
+	if input.Reason == "" {
+		input.Reason = c.Query("reason")
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		utils.ErrorJSON(c, http.StatusBadRequest, "A reason is required")
+		return "", false
+	}
+	if len(reason) > 1000 {
+		utils.ErrorJSON(c, http.StatusBadRequest, "Reason must not exceed 1000 characters")
+		return "", false
+	}
+	return reason, true
+}
+
+func newModerationAction(c *gin.Context, target moderationTarget, action, reason string) store.ModerationAction {
+	adminID, _ := getUserID(c)
+	return store.ModerationAction{AdminID: adminID, TargetUserID: target.userID, Action: action, Reason: reason}
+}
+
+func moderationActionsJSON(actions []store.ModerationAction) []gin.H {
+	list := make([]gin.H, 0, len(actions))
+	for _, a := range actions {
+		entry := gin.H{
+			"id":             a.ID,
+			"admin_id":       zeroAsNull(a.AdminID),
+			"admin_email":    a.AdminEmail,
+			"target_user_id": zeroAsNull(a.TargetUserID),
+			"action":         a.Action,
+			"reason":         a.Reason,
+			"project_id":     nil,
+			"created_at":     a.CreatedAt,
+		}
+		if a.ProjectID != 0 {
+			entry["project_id"] = strconv.Itoa(a.ProjectID)
+		}
+		list = append(list, entry)
+	}
+	return list
+}
+
+// pageParams reads ?limit= (1-100, default 50) and ?offset= (default 0).
+func pageParams(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func timeOrNull(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
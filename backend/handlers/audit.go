@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/store"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMySecurityEvents lists the current user's own audit events (logins, password / email changes, 2FA, sessions).
+func GetMySecurityEvents(audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		userID, ok := getUserID(c)
+		if !ok {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		limit, offset := pageParams(c)
+		events, total, err := audit.List(c.Request.Context(), store.AuditFilter{UserID: userID}, limit, offset)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"events": auditEventsJSON(events),
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+}
+
+// AdminListAuditEvents queries audit_events with filters: user_id, actor_id, action (exact, or a prefix ending in "."),
+// ip, since and until (RFC 3339 or YYYY-MM-DD). University admins only see events of users at their university.
+func AdminListAuditEvents(users store.UserStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		adminUniversity, ok := adminScope(c, users)
+		if !ok {
+			return
+		}
+
+		f := store.AuditFilter{
+			University: adminUniversity,
+			IPAddress:  strings.TrimSpace(c.Query("ip")),
+		}
+		for _, p := range []struct {
+			param string
+			dst   *int
+		}{{"user_id", &f.UserID}, {"actor_id", &f.ActorID}} {
+			v := c.Query(p.param)
+			if v == "" {
+				continue
+			}
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				utils.ErrorJSON(c, http.StatusBadRequest, "Invalid "+p.param)
+				return
+			}
+			*p.dst = id
+		}
+		if action := strings.TrimSpace(c.Query("action")); strings.HasSuffix(action, ".") {
+			f.ActionPrefix = action
+		} else {
+			f.Action = action
+		}
+		for _, p := range []struct {
+			param string
+			dst   *time.Time
+		}{{"since", &f.Since}, {"until", &f.Until}} {
+			v := c.Query(p.param)
+			if v == "" {
+				continue
+			}
+			t, err := parseAuditTime(v)
+			if err != nil {
+				utils.ErrorJSON(c, http.StatusBadRequest, "Invalid "+p.param)
+				return
+			}
+			*p.dst = t
+		}
+
+		limit, offset := pageParams(c)
+		events, total, err := audit.List(c.Request.Context(), f, limit, offset)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"events": auditEventsJSON(events),
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+}
+
+func auditEventsJSON(events []store.AuditEvent) []gin.H {
+	list := make([]gin.H, 0, len(events))
+	for _, e := range events {
+		metadata := e.Metadata
+		if metadata == "" {
+			metadata = "{}"
+		}
+		list = append(list, gin.H{
+			"id":         e.ID,
+			"user_id":    zeroAsNull(e.UserID),
+			"actor_id":   zeroAsNull(e.ActorID),
+			"action":     e.Action,
+			"ip_address": e.IPAddress,
+			"user_agent": e.UserAgent,
+			"metadata":   json.RawMessage(metadata),
+			"created_at": e.CreatedAt,
+		})
+	}
+	return list
+}
+
+func parseAuditTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// zeroAsNull ส่ง id ที่เป็น 0 (ไม่ทราบ / บัญชีถูกลบแล้ว) เป็น null
+func zeroAsNull(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"backend/config"
+	"backend/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout จำกัดเวลาตรวจ DB ต่อ probe — DB ช้าเกินนี้ถือว่ายังไม่พร้อม
+const readinessTimeout = 2 * time.Second
+
+// Healthz is the liveness probe: the process is up and serving HTTP.
+// It does not touch the database so a DB outage doesn't get the container restarted.
+func Healthz() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// Readyz is the readiness probe: the DB pool answers and the schema matches this build's migrations.
+// It reports 503 once shutdown has started so load balancers stop sending new requests while in-flight ones drain.
+// storage is cfg.Storage — sqlite applies its schema at startup so only the ping is checked, memory has nothing to check.
+func Readyz(storage string, db *sql.DB, shuttingDown *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if shuttingDown.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		// STORAGE=memory — ไม่มี database ให้ตรวจ
+		if storage == config.StorageMemory {
+			c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": gin.H{"database": "memory"}})
+			return
+		}
+
+		checks := gin.H{"database": "ok", "migrations": "ok"}
+		ready := true
+
+		// รายละเอียด error ลง log เท่านั้น — endpoint นี้เปิดสาธารณะ
+		if err := db.PingContext(ctx); err != nil {
+			slog.WarnContext(ctx, "readyz: database unreachable", "error", err)
+			checks["database"] = "unreachable"
+			checks["migrations"] = "unknown"
+			ready = false
+		} else if storage == config.StoragePostgres {
+			if err := database.CheckMigrations(ctx, db); err != nil {
+				slog.WarnContext(ctx, "readyz: schema behind", "error", err)
+				checks["migrations"] = "behind"
+				ready = false
+			}
+		}
+
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+	}
+}
@@ -1,175 +1,104 @@
 package handlers
 
 import (
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"backend/store"
+	"backend/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
-// GetMyProjects returns all projects for the current user.
-func GetMyProjects(db *sql.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
+// parseProjectID อ่าน id ของโปรเจคจาก path param ชื่อ param — frontend ส่งมาได้ทั้ง "7" และ "p7"
+func parseProjectID(c *gin.Context, param string) (int, bool) {
+	idStr := c.Param(param)
+	if len(idStr) > 1 && idStr[0] == 'p' {
+		idStr = idStr[1:]
+	}
+	projectID, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.ErrorJSON(c, http.StatusBadRequest, "Invalid project id")
+		return 0, false
+	}
+	return projectID, true
+}
 
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
+// projectJSON คือรูปแบบโปรเจคที่ frontend ใช้ (img = รูปแรก สำหรับการ์ด)
+func projectJSON(id int, title, desc string, images []string) gin.H {
+	if images == nil {
+		images = []string{}
+	}
+	img := ""
+	if len(images) > 0 {
+		img = images[0]
+	}
+	return gin.H{
+		"id":     strconv.Itoa(id),
+		"title":  title,
+		"desc":   desc,
+		"img":    img,
+		"images": images,
+	}
+}
 
-		userID, ok := userIDValue.(int)
+// GetMyProjects returns all projects for the current user.
+func GetMyProjects(projects store.ProjectStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
-		rows, err := db.Query(`
-			SELECT project_id, project_name, description, image_url
-			FROM projects
-			WHERE user_id = $1
-			ORDER BY created_at DESC
-		`, userID)
+		list, err := projects.List(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
 			return
 		}
-		defer rows.Close()
-
-		var list []gin.H
-
-		for rows.Next() {
-			var (
-				projectID int
-				name      sql.NullString
-				desc      sql.NullString
-				imageURL  sql.NullString
-			)
-
-			if err := rows.Scan(&projectID, &name, &desc, &imageURL); err != nil {
-				continue
-			}
 
-			images := []string{}
-			if imageURL.Valid && imageURL.String != "" {
-				_ = json.Unmarshal([]byte(imageURL.String), &images)
-			}
-			if images == nil {
-				images = []string{}
-			}
-
-			img := ""
-			if len(images) > 0 {
-				img = images[0]
-			}
-
-			list = append(list, gin.H{
-				"id":     strconv.Itoa(projectID),
-				"title":  name.String,
-				"desc":   desc.String,
-				"img":    img,
-				"images": images,
-			})
+		out := make([]gin.H, 0, len(list))
+		for _, p := range list {
+			out = append(out, projectJSON(p.ID, p.Title, p.Desc, p.Images))
 		}
-
-		if list == nil {
-			list = []gin.H{}
-		}
-
-		c.JSON(http.StatusOK, list)
+		c.JSON(http.StatusOK, out)
 	}
 }
 
 // GetProjectByID returns a single project by id (e.g. "7" or "p7"). Auth required; returns only current user's project.
-func GetProjectByID(db *sql.DB) gin.HandlerFunc {
+func GetProjectByID(projects store.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		userID, ok := getUserID(c)
+		if !ok {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
-
-		userID, ok := userIDValue.(int)
+		projectID, ok := parseProjectID(c, "id")
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 			return
 		}
 
-		idStr := c.Param("id")
-		if idStr == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		p, err := projects.Get(c.Request.Context(), userID, projectID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Project not found")
 			return
 		}
-
-		if len(idStr) > 1 && idStr[0] == 'p' {
-			idStr = idStr[1:]
-		}
-
-		projectID, err := strconv.Atoi(idStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
-			return
-		}
-
-		var (
-			name     sql.NullString
-			desc     sql.NullString
-			imageURL sql.NullString
-		)
-
-		err = db.QueryRow(`
-			SELECT project_name, description, image_url
-			FROM projects
-			WHERE project_id = $1 AND user_id = $2
-		`, projectID, userID).Scan(&name, &desc, &imageURL)
-
 		if err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
 			return
 		}
 
-		images := []string{}
-		if imageURL.Valid && imageURL.String != "" {
-			_ = json.Unmarshal([]byte(imageURL.String), &images)
-		}
-		if images == nil {
-			images = []string{}
-		}
-
-		img := ""
-		if len(images) > 0 {
-			img = images[0]
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"id":     strconv.Itoa(projectID),
-			"title":  name.String,
-			"desc":   desc.String,
-			"img":    img,
-			"images": images,
-		})
+		c.JSON(http.StatusOK, projectJSON(p.ID, p.Title, p.Desc, p.Images))
 	}
 }
 
 // CreateProject creates a new project for the current user.
-func CreateProject(db *sql.DB) gin.HandlerFunc {
+func CreateProject(projects store.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		userID, ok := userIDValue.(int)
+		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -178,95 +107,47 @@ func CreateProject(db *sql.DB) gin.HandlerFunc {
 			Desc   string   `json:"desc"`
 			Images []string `json:"images"`
 		}
-
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid input")
 			return
 		}
 
 		// Validate title length
 		if len(input.Title) > 255 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Title must not exceed 255 characters"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Title must not exceed 255 characters")
 			return
 		}
 
-		imageJSON, _ := json.Marshal(input.Images)
-		if input.Images == nil {
-			imageJSON = []byte("[]")
-		}
-
-		var projectID int
-
-		err := db.QueryRow(`
-			INSERT INTO projects (user_id, project_name, description, image_url)
-			VALUES ($1, $2, $3, $4)
-			RETURNING project_id
-		`, userID, input.Title, input.Desc, string(imageJSON)).Scan(&projectID)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		p := store.Project{UserID: userID, Title: input.Title, Desc: input.Desc, Images: input.Images}
+		if err := projects.Create(c.Request.Context(), &p); err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to create project")
 			return
 		}
 
-		img := ""
-		if len(input.Images) > 0 {
-			img = input.Images[0]
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"id":     strconv.Itoa(projectID),
-			"title":  input.Title,
-			"desc":   input.Desc,
-			"img":    img,
-			"images": input.Images,
-		})
+		c.JSON(http.StatusOK, projectJSON(p.ID, p.Title, p.Desc, p.Images))
 	}
 }
 
 // DeleteProject deletes a project by id (e.g. "123" or "p123" -> project_id 123).
-func DeleteProject(db *sql.DB) gin.HandlerFunc {
+func DeleteProject(projects store.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		userID, ok := userIDValue.(int)
+		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
-
-		idStr := c.Param("id")
-		if idStr == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		projectID, ok := parseProjectID(c, "id")
+		if !ok {
 			return
 		}
 
-		if len(idStr) > 1 && idStr[0] == 'p' {
-			idStr = idStr[1:]
-		}
-
-		projectID, err := strconv.Atoi(idStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		err := projects.Delete(c.Request.Context(), userID, projectID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Project not found")
 			return
 		}
-
-		result, err := db.Exec(`
-			DELETE FROM projects WHERE project_id = $1 AND user_id = $2
-		`, projectID, userID)
-
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
-			return
-		}
-
-		rows, _ := result.RowsAffected()
-		if rows == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to delete")
 			return
 		}
 
@@ -274,51 +155,26 @@ func DeleteProject(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-// UpdateProject updates a project by id for the current user.
-func UpdateProject(db *sql.DB) gin.HandlerFunc {
+// UpdateProject updates a project by id for the current user. Fields not sent keep their current value.
+func UpdateProject(projects store.ProjectStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		userID, ok := userIDValue.(int)
+		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
-			return
-		}
-
-		idStr := c.Param("id")
-		if idStr == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
-
-		if len(idStr) > 1 && idStr[0] == 'p' {
-			idStr = idStr[1:]
-		}
-
-		projectID, err := strconv.Atoi(idStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		projectID, ok := parseProjectID(c, "id")
+		if !ok {
 			return
 		}
 
-		// Get existing project data first
-		var existingTitle, existingDesc, existingImageURL sql.NullString
-		err = db.QueryRow(`
-			SELECT project_name, description, image_url
-			FROM projects
-			WHERE project_id = $1 AND user_id = $2
-		`, projectID, userID).Scan(&existingTitle, &existingDesc, &existingImageURL)
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		p, err := projects.Get(c.Request.Context(), userID, projectID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Project not found")
 			return
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
 			return
 		}
 
@@ -327,70 +183,37 @@ func UpdateProject(db *sql.DB) gin.HandlerFunc {
 			Desc   *string  `json:"desc"`
 			Images []string `json:"images"`
 		}
-
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid input")
 			return
 		}
 
 		// Validate title length
 		if input.Title != nil && len(*input.Title) > 255 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Title must not exceed 255 characters"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Title must not exceed 255 characters")
 			return
 		}
 
-		// Use existing values if not provided
-		finalTitle := existingTitle.String
 		if input.Title != nil {
-			finalTitle = *input.Title
+			p.Title = *input.Title
 		}
-
-		finalDesc := existingDesc.String
 		if input.Desc != nil {
-			finalDesc = *input.Desc
+			p.Desc = *input.Desc
 		}
-
-		// Handle images
-		var finalImages []string
 		if input.Images != nil {
-			finalImages = input.Images
-		} else if existingImageURL.Valid && existingImageURL.String != "" {
-			_ = json.Unmarshal([]byte(existingImageURL.String), &finalImages)
+			p.Images = input.Images
 		}
-		if finalImages == nil {
-			finalImages = []string{}
-		}
-
-		imageJSON, _ := json.Marshal(finalImages)
-
-		result, err := db.Exec(`
-			UPDATE projects
-			SET project_name = $1, description = $2, image_url = $3
-			WHERE project_id = $4 AND user_id = $5
-		`, finalTitle, finalDesc, string(imageJSON), projectID, userID)
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		err = projects.Update(c.Request.Context(), p)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Project not found")
 			return
 		}
-
-		rows, _ := result.RowsAffected()
-		if rows == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to update project")
 			return
 		}
 
-		img := ""
-		if len(finalImages) > 0 {
-			img = finalImages[0]
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"id":     strconv.Itoa(projectID),
-			"title":  finalTitle,
-			"desc":   finalDesc,
-			"img":    img,
-			"images": finalImages,
-		})
+		c.JSON(http.StatusOK, projectJSON(p.ID, p.Title, p.Desc, p.Images))
 	}
 }
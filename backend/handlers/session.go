@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/store"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMySessions lists the current user's active sessions (one per login / device).
+func GetMySessions(sessions store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		userID, ok := getUserID(c)
+		if !ok {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		currentSessionID := c.GetString("session_id")
+
+		active, err := sessions.ListActive(c.Request.Context(), userID)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
+		}
+
+		list := make([]gin.H, 0, len(active))
+		for _, s := range active {
+			list = append(list, gin.H{
+				"id":           s.ID,
+				"user_agent":   s.UserAgent,
+				"ip_address":   s.IPAddress,
+				"created_at":   s.CreatedAt,
+				"last_used_at": s.LastUsedAt,
+				"expires_at":   s.ExpiresAt,
+				"current":      s.ID == currentSessionID,
+			})
+		}
+
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// RevokeMySessions signs out every other device. The session making the request stays active.
+func RevokeMySessions(sessions store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		userID, ok := getUserID(c)
+		if !ok {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		revoked, err := sessions.RevokeOthers(c.Request.Context(), userID, c.GetString("session_id"))
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to revoke sessions")
+			return
+		}
+
+		utils.RecordAuditEvent(audit, c, utils.AuditEvent{
+			UserID: userID, ActorID: userID, Action: utils.AuditSessionsRevoked,
+			Metadata: map[string]interface{}{"revoked": revoked},
+		})
+		c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked", "revoked": revoked})
+	}
+}
+
+// RevokeMySession revokes a single session by id. Revoking the current session is the same as logging out.
+func RevokeMySession(sessions store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		userID, ok := getUserID(c)
+		if !ok {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		err := sessions.RevokeForUser(c.Request.Context(), userID, c.Param("id"))
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Session not found")
+			return
+		}
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to revoke session")
+			return
+		}
+
+		utils.RecordAuditEvent(audit, c, utils.AuditEvent{
+			UserID: userID, ActorID: userID, Action: utils.AuditSessionRevoked,
+		})
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	}
+}
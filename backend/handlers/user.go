@@ -1,13 +1,17 @@
 package handlers
 
 import (
-	"database/sql"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"backend/mail"
+	"backend/metrics"
+	"backend/store"
+	"backend/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,140 +24,71 @@ func getUserID(c *gin.Context) (int, bool) {
 	return userID, ok
 }
 
-func GetMe(db *sql.DB) gin.HandlerFunc {
+func GetMe(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
-		var (
-			userIDDB        int
-			userName        sql.NullString
-			email           string
-			phone           sql.NullString
-			university      sql.NullString
-			faculty         sql.NullString
-			major           sql.NullString
-			gpaStr          sql.NullString
-			jobInterest     sql.NullString
-			profileImageURL sql.NullString
-		)
-
-		err := db.QueryRow(`
-			SELECT user_id, user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url
-			FROM users WHERE user_id = $1
-		`, userID).Scan(
-			&userIDDB,
-			&userName,
-			&email,
-			&phone,
-			&university,
-			&faculty,
-			&major,
-			&gpaStr,
-			&jobInterest,
-			&profileImageURL,
-		)
-
-		if err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		u, err := users.Get(c.Request.Context(), userID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "User not found")
 			return
 		}
-
-		var gpa float64
-		if gpaStr.Valid {
-			fmt.Sscanf(gpaStr.String, "%f", &gpa)
-		}
-
-		// skills
-		var skills []string
-		rows, err := db.Query(`
-			SELECT s.skill_name
-			FROM user_skills us
-			JOIN skills s ON us.skill_id = s.skill_id
-			WHERE us.user_id = $1
-		`, userID)
-
-		if err == nil {
-			defer rows.Close()
-			for rows.Next() {
-				var skill string
-				if rows.Scan(&skill) == nil {
-					skills = append(skills, skill)
-				}
-			}
-		}
-
-		if skills == nil {
-			skills = []string{}
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to fetch user")
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"user_id":           userIDDB,
-			"user_name":         userName.String,
-			"email":             email,
-			"phone":             phone.String,
-			"university":        university.String,
-			"faculty":           faculty.String,
-			"major":             major.String,
-			"gpa":               gpa,
-			"job_interest":      jobInterest.String,
-			"profile_image_url": profileImageURL.String,
-			"skills":            skills,
+			"user_id":           u.ID,
+			"user_name":         u.UserName,
+			"email":             u.Email,
+			"phone":             u.Phone,
+			"university":        u.University,
+			"faculty":           u.Faculty,
+			"major":             u.Major,
+			"gpa":               u.GPA,
+			"job_interest":      u.JobInterest,
+			"profile_image_url": u.ProfileImageURL,
+			"skills":            u.Skills,
+			"role":              u.Role,
+			"locale":            u.Locale,
+			"email_verified":    u.EmailVerified,
 		})
 	}
 }
 
-func GetMySkills(db *sql.DB) gin.HandlerFunc {
+func GetMySkills(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
-		rows, err := db.Query(`
-			SELECT s.skill_name
-			FROM user_skills us
-			JOIN skills s ON us.skill_id = s.skill_id
-			WHERE us.user_id = $1
-		`, userID)
-
+		skills, err := users.Skills(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
 			return
 		}
-		defer rows.Close()
-
-		var skills []string
-		for rows.Next() {
-			var skill string
-			rows.Scan(&skill)
-			skills = append(skills, skill)
-		}
-
-		if skills == nil {
-			skills = []string{}
-		}
 
 		c.JSON(http.StatusOK, skills)
 	}
 }
 
-func UpdateMe(db *sql.DB) gin.HandlerFunc {
+// UpdateMe updates the caller's profile. The university can't be changed by admins (it scopes a university admin's
+// powers, see adminScope) or by users who have been moderated (it would move them out of their university admin's reach).
+func UpdateMe(users store.UserStore, moderation store.ModerationStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -167,136 +102,127 @@ func UpdateMe(db *sql.DB) gin.HandlerFunc {
 			JobInterest     string   `json:"job_interest"`
 			ProfileImageURL string   `json:"profile_image_url"`
 			Skills          []string `json:"skills"`
+			Locale          string   `json:"locale"` // ภาษาของอีเมล (th / en) — ไม่ส่งมา = ใช้ค่าเดิม
 		}
 
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid input")
 			return
 		}
 
+		locale := ""
+		if input.Locale != "" {
+			locale = mail.NormalizeLocale(input.Locale)
+		}
+
 		if input.GPA < 0 || input.GPA > 4 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "GPAX must be between 0 and 4.00"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "GPAX must be between 0 and 4.00")
 			return
 		}
 
-		tx, err := db.Begin()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		if !checkUniversityChange(c, users, moderation, userID, input.University) {
 			return
 		}
-		defer func() { _ = tx.Rollback() }()
-
-		_, err = tx.Exec(`
-			UPDATE users SET
-				user_name=$1, phone=$2, university=$3,
-				faculty=$4, major=$5, gpa=$6,
-				job_interest=$7, profile_image_url=$8
-			WHERE user_id=$9
-		`,
-			input.UserName,
-			input.Phone,
-			input.University,
-			input.Faculty,
-			input.Major,
-			input.GPA,
-			input.JobInterest,
-			input.ProfileImageURL,
-			userID,
-		)
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		err := users.UpdateProfile(c.Request.Context(), userID, store.ProfileUpdate{
+			UserName:        input.UserName,
+			Phone:           input.Phone,
+			University:      input.University,
+			Faculty:         input.Faculty,
+			Major:           input.Major,
+			GPA:             input.GPA,
+			JobInterest:     input.JobInterest,
+			ProfileImageURL: input.ProfileImageURL,
+			Locale:          locale,
+			Skills:          input.Skills,
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "User not found")
 			return
 		}
-
-		// skills
-		_, err = tx.Exec("DELETE FROM user_skills WHERE user_id=$1", userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update skills"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to update profile")
 			return
 		}
 
-		for _, s := range input.Skills {
-			s = strings.TrimSpace(s)
-			if s == "" {
-				continue
-			}
-
-			var skillID int
-			err := tx.QueryRow("SELECT skill_id FROM skills WHERE LOWER(skill_name)=LOWER($1)", s).Scan(&skillID)
+		utils.RecordAuditEvent(audit, c, utils.AuditEvent{
+			UserID: userID, ActorID: userID, Action: utils.AuditProfileUpdated,
+		})
 
-			if err == sql.ErrNoRows {
-				err = tx.QueryRow("INSERT INTO skills (skill_name) VALUES ($1) RETURNING skill_id", s).Scan(&skillID)
-			}
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Skill error"})
-				return
-			}
+		c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
+	}
+}
 
-			_, err = tx.Exec("INSERT INTO user_skills (user_id, skill_id) VALUES ($1,$2) ON CONFLICT DO NOTHING", userID, skillID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Insert skill error"})
-				return
-			}
-		}
+// checkUniversityChange answers 403 if university differs from the stored one and the user may not change it.
+func checkUniversityChange(c *gin.Context, users store.UserStore, moderation store.ModerationStore, userID int, university string) bool {
+	ctx := c.Request.Context()
+	u, err := users.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		utils.ErrorJSON(c, http.StatusNotFound, "User not found")
+		return false
+	}
+	if err != nil {
+		utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+		return false
+	}
+	if strings.EqualFold(strings.TrimSpace(u.University), strings.TrimSpace(university)) {
+		return true
+	}
 
-		if err := tx.Commit(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
-			return
-		}
+	if utils.IsAdminRole(u.Role) {
+		utils.ErrorJSON(c, http.StatusForbidden, "Admins cannot change their own university")
+		return false
+	}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
+	// เคยถูก moderate (ระงับ / ลบออกจาก Dashboard) — ย้ายมหาวิทยาลัยแล้วจะหลุดจาก university admin ที่ดูแลอยู่
+	actions, err := moderation.ListActions(ctx, store.ActionFilter{TargetUserID: userID}, 1, 0)
+	if err != nil {
+		utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+		return false
 	}
+	if u.Suspended || len(actions) > 0 {
+		utils.ErrorJSON(c, http.StatusForbidden, "University cannot be changed after moderation; contact an admin")
+		return false
+	}
+	return true
 }
 
-func DeleteMe(db *sql.DB) gin.HandlerFunc {
+func DeleteMe(users store.UserStore, audit store.AuditStore, outbox store.OutboxStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
-		// Start transaction to ensure all deletions succeed or fail together
-		tx, err := db.Begin()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		// เก็บอีเมลไว้ก่อนลบ — ใช้ส่งอีเมลแจ้งและบันทึก audit (audit_events ไม่ถูกลบตาม user)
+		u, err := users.Get(c.Request.Context(), userID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "User not found")
 			return
 		}
-		defer func() { _ = tx.Rollback() }()
-
-		// Delete from published_profiles (dashboard data)
-		_, err = tx.Exec(`DELETE FROM published_profiles WHERE user_id = $1`, userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete published profile"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to fetch user")
 			return
 		}
 
-		// Delete from published_projects (dashboard data)
-		_, err = tx.Exec(`DELETE FROM published_projects WHERE user_id = $1`, userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete published projects"})
+		err = users.Delete(c.Request.Context(), userID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "User not found")
 			return
 		}
-
-		// Delete from users (this will cascade delete projects, user_skills, verification_codes)
-		result, err := tx.Exec(`DELETE FROM users WHERE user_id = $1`, userID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to delete account")
 			return
 		}
 
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit deletion"})
-			return
+		utils.RecordAuditEvent(audit, c, utils.AuditEvent{
+			UserID: userID, ActorID: userID, Action: utils.AuditAccountDeleted,
+			Metadata: map[string]interface{}{"email": u.Email},
+		})
+		if err := utils.SendAccountDeletedEmail(c.Request.Context(), outbox, u.Email, u.Locale); err != nil {
+			slog.WarnContext(c.Request.Context(), "delete account: queue notice email", "error", err)
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
@@ -304,138 +230,58 @@ func DeleteMe(db *sql.DB) gin.HandlerFunc {
 }
 
 // SetDashboardVisibility publishes current profile and projects to dashboard (creates snapshot).
-func SetDashboardVisibility(db *sql.DB) gin.HandlerFunc {
+func SetDashboardVisibility(users store.UserStore, dashboard store.DashboardStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-		userID, ok := userIDValue.(int)
+		userID, ok := getUserID(c)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 		var input struct {
 			ShowOnDashboard bool `json:"show_on_dashboard"`
 		}
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
-			return
-		}
-
-		tx, err := db.Begin()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid input")
 			return
 		}
-		defer func() { _ = tx.Rollback() }()
 
 		if input.ShowOnDashboard {
-			// Publish: สร้าง snapshot ของ profile และ projects
-
-			// 1. ดึงข้อมูล profile ปัจจุบัน
-			var userName, email, phone, university, faculty, major, jobInterest, profileImageURL sql.NullString
-			var gpaStr sql.NullString
-			err := tx.QueryRow(`
-				SELECT user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url
-				FROM users WHERE user_id = $1
-			`, userID).Scan(&userName, &email, &phone, &university, &faculty, &major, &gpaStr, &jobInterest, &profileImageURL)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+			// ต้องยืนยันอีเมลและไม่ถูกระงับก่อนถึงจะ publish ได้ (unpublish ทำได้เสมอ)
+			u, err := users.Get(c.Request.Context(), userID)
+			if errors.Is(err, store.ErrNotFound) {
+				utils.ErrorJSON(c, http.StatusNotFound, "User not found")
 				return
 			}
-
-			// 2. ดึง skills
-			skillRows, err := tx.Query(`
-				SELECT s.skill_name FROM user_skills us
-				JOIN skills s ON us.skill_id = s.skill_id
-				WHERE us.user_id = $1
-			`, userID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch skills"})
+				utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
 				return
 			}
-			var skills []string
-			for skillRows.Next() {
-				var skill string
-				if skillRows.Scan(&skill) == nil {
-					skills = append(skills, skill)
-				}
-			}
-			skillRows.Close()
-			skillsJSON, _ := json.Marshal(skills)
-
-			// 3. บันทึก published_profile
-			_, err = tx.Exec(`
-				INSERT INTO published_profiles 
-				(user_id, user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url, skills, updated_at)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
-				ON CONFLICT (user_id) DO UPDATE SET
-					user_name = EXCLUDED.user_name,
-					email = EXCLUDED.email,
-					phone = EXCLUDED.phone,
-					university = EXCLUDED.university,
-					faculty = EXCLUDED.faculty,
-					major = EXCLUDED.major,
-					gpa = EXCLUDED.gpa,
-					job_interest = EXCLUDED.job_interest,
-					profile_image_url = EXCLUDED.profile_image_url,
-					skills = EXCLUDED.skills,
-					updated_at = NOW()
-			`, userID, userName.String, email.String, phone.String, university.String, faculty.String, major.String, gpaStr.String, jobInterest.String, profileImageURL.String, string(skillsJSON))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish profile"})
+			if u.Suspended {
+				utils.ErrorJSON(c, http.StatusForbidden, "Your account is suspended and cannot publish to the dashboard")
 				return
 			}
-
-			// 4. ลบ published_projects เก่า
-			_, err = tx.Exec("DELETE FROM published_projects WHERE user_id = $1", userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear old projects"})
+			if !u.EmailVerified {
+				utils.ErrorJSON(c, http.StatusForbidden, "Please verify your email before publishing to the dashboard")
 				return
 			}
 
-			// 5. คัดลอก projects ปัจจุบันไป published_projects
-			_, err = tx.Exec(`
-				INSERT INTO published_projects (user_id, project_id, project_name, description, image_url)
-				SELECT user_id, project_id, project_name, description, image_url
-				FROM projects WHERE user_id = $1
-			`, userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish projects"})
-				return
-			}
-
-			// 6. ตั้งค่า show_on_dashboard = true
-			_, err = tx.Exec("UPDATE users SET show_on_dashboard = true WHERE user_id = $1", userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update visibility"})
-				return
-			}
-		} else {
-			// Unpublish: ลบ snapshot และตั้งค่า show_on_dashboard = false
-			_, err = tx.Exec("DELETE FROM published_profiles WHERE user_id = $1", userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpublish profile"})
-				return
-			}
-			_, err = tx.Exec("DELETE FROM published_projects WHERE user_id = $1", userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpublish projects"})
-				return
-			}
-			_, err = tx.Exec("UPDATE users SET show_on_dashboard = false WHERE user_id = $1", userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update visibility"})
+			if err := dashboard.Publish(c.Request.Context(), userID); err != nil {
+				utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to publish profile")
 				return
 			}
+		} else if err := dashboard.Unpublish(c.Request.Context(), userID); err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to unpublish profile")
+			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit"})
-			return
+		action := utils.AuditDashboardUnpublished
+		if input.ShowOnDashboard {
+			action = utils.AuditDashboardPublished
+			metrics.IncDashboardPublish()
+		} else {
+			metrics.IncDashboardUnpublish()
 		}
+		utils.RecordAuditEvent(audit, c, utils.AuditEvent{UserID: userID, ActorID: userID, Action: action})
 
 		c.JSON(http.StatusOK, gin.H{
 			"message":           "Dashboard visibility updated",
@@ -444,206 +290,74 @@ func SetDashboardVisibility(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-// GetDashboardProfiles returns users who have published to dashboard (from published_profiles), excluding the current user.
-// 🚀 Optimized query with limited fields for better performance
-func GetDashboardProfiles(db *sql.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userIDValue, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-		currentID, ok := userIDValue.(int)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
-			return
-		}
-		rows, err := db.Query(`
-			SELECT user_id, user_name, profile_image_url, job_interest, university, faculty, major, gpa
-			FROM published_profiles
-			WHERE user_id != $1
-			ORDER BY updated_at DESC
-			LIMIT 100
-		`, currentID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
-			return
-		}
-		defer rows.Close()
-		var list []gin.H
-		for rows.Next() {
-			var uid int
-			var userName, profileImageURL, jobInterest, university, faculty, major sql.NullString
-			var gpaStr sql.NullString
-			if err := rows.Scan(&uid, &userName, &profileImageURL, &jobInterest, &university, &faculty, &major, &gpaStr); err != nil {
-				continue
-			}
-			var gpa float64
-			if gpaStr.Valid {
-				fmt.Sscanf(gpaStr.String, "%f", &gpa)
-			}
-			list = append(list, gin.H{
-				"user_id":           uid,
-				"user_name":         userName.String,
-				"profile_image_url": profileImageURL.String,
-				"job_interest":      jobInterest.String,
-				"university":        university.String,
-				"faculty":           faculty.String,
-				"major":             major.String,
-				"gpa":               gpa,
-			})
-		}
-		if list == nil {
-			list = []gin.H{}
-		}
-		c.JSON(http.StatusOK, list)
-	}
-}
+// dashboardListLimit คือจำนวนการ์ดสูงสุดที่ส่งให้หน้า Dashboard ต่อครั้ง
+const dashboardListLimit = 100
 
-// GetPublicDashboardProfiles returns all published profiles. No auth required (for guests).
-// 🚀 Optimized query with limited fields for better performance
-func GetPublicDashboardProfiles(db *sql.DB) gin.HandlerFunc {
+// GetDashboardProfiles returns published profiles, newest first.
+// เมื่อ login แล้ว (route ที่มี AuthMiddleware) จะไม่รวม profile ของตัวเอง — guest เห็นทั้งหมด
+func GetDashboardProfiles(dashboard store.DashboardStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 🚀 Use prepared statement for better performance
-		rows, err := db.Query(`
-			SELECT user_id, user_name, profile_image_url, job_interest, university, faculty, major, gpa
-			FROM published_profiles
-			ORDER BY updated_at DESC
-			LIMIT 100
-		`)
+		currentID, _ := getUserID(c)
+
+		profiles, err := dashboard.List(c.Request.Context(), currentID, dashboardListLimit)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB error"})
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
 			return
 		}
-		defer rows.Close()
-		var list []gin.H
-		for rows.Next() {
-			var uid int
-			var userName, profileImageURL, jobInterest, university, faculty, major sql.NullString
-			var gpaStr sql.NullString
-			if err := rows.Scan(&uid, &userName, &profileImageURL, &jobInterest, &university, &faculty, &major, &gpaStr); err != nil {
-				continue
-			}
-			var gpa float64
-			if gpaStr.Valid {
-				fmt.Sscanf(gpaStr.String, "%f", &gpa)
-			}
+
+		list := make([]gin.H, 0, len(profiles))
+		for _, p := range profiles {
 			list = append(list, gin.H{
-				"user_id":           uid,
-				"user_name":         userName.String,
-				"profile_image_url": profileImageURL.String,
-				"job_interest":      jobInterest.String,
-				"university":        university.String,
-				"faculty":           faculty.String,
-				"major":             major.String,
-				"gpa":               gpa,
+				"user_id":           p.UserID,
+				"user_name":         p.UserName,
+				"profile_image_url": p.ProfileImageURL,
+				"job_interest":      p.JobInterest,
+				"university":        p.University,
+				"faculty":           p.Faculty,
+				"major":             p.Major,
+				"gpa":               p.GPA,
 			})
 		}
-		if list == nil {
-			list = []gin.H{}
-		}
 		c.JSON(http.StatusOK, list)
 	}
 }
 
 // GetPublicProfile returns a user's published profile (from published_profiles). No auth required.
-func GetPublicProfile(db *sql.DB) gin.HandlerFunc {
+func GetPublicProfile(dashboard store.DashboardStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		idStr := c.Param("id")
-		targetID, err := strconv.Atoi(idStr)
+		targetID, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+			utils.ErrorJSON(c, http.StatusBadRequest, "Invalid user id")
 			return
 		}
 
-		// ดึงข้อมูลจาก published_profiles
-		var (
-			userName        sql.NullString
-			email           sql.NullString
-			phone           sql.NullString
-			university      sql.NullString
-			faculty         sql.NullString
-			major           sql.NullString
-			gpaStr          sql.NullString
-			jobInterest     sql.NullString
-			profileImageURL sql.NullString
-			skillsJSON      sql.NullString
-		)
-		row := db.QueryRow(`
-			SELECT user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url, skills
-			FROM published_profiles
-			WHERE user_id = $1
-		`, targetID)
-		if err := row.Scan(&userName, &email, &phone, &university, &faculty, &major, &gpaStr, &jobInterest, &profileImageURL, &skillsJSON); err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Profile not published"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		p, err := dashboard.Get(c.Request.Context(), targetID)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.ErrorJSON(c, http.StatusNotFound, "Profile not published")
 			return
 		}
-
-		var gpa float64
-		if gpaStr.Valid {
-			fmt.Sscanf(gpaStr.String, "%f", &gpa)
-		}
-
-		// Parse skills
-		var skills []string
-		if skillsJSON.Valid && skillsJSON.String != "" {
-			_ = json.Unmarshal([]byte(skillsJSON.String), &skills)
-		}
-		if skills == nil {
-			skills = []string{}
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "DB error")
+			return
 		}
 
-		// ดึง projects จาก published_projects
-		var projects []gin.H
-		projRows, err := db.Query(`
-			SELECT project_id, project_name, description, image_url
-			FROM published_projects WHERE user_id = $1 ORDER BY published_at DESC
-		`, targetID)
-		if err == nil {
-			defer projRows.Close()
-			for projRows.Next() {
-				var projectID int
-				var name, desc, imageURL sql.NullString
-				if projRows.Scan(&projectID, &name, &desc, &imageURL) != nil {
-					continue
-				}
-				images := []string{}
-				if imageURL.Valid && imageURL.String != "" {
-					_ = json.Unmarshal([]byte(imageURL.String), &images)
-				}
-				img := ""
-				if len(images) > 0 {
-					img = images[0]
-				}
-				projects = append(projects, gin.H{
-					"id":     strconv.Itoa(projectID),
-					"title":  name.String,
-					"desc":   desc.String,
-					"img":    img,
-					"images": images,
-				})
-			}
-		}
-		if projects == nil {
-			projects = []gin.H{}
+		projects := make([]gin.H, 0, len(p.Projects))
+		for _, proj := range p.Projects {
+			projects = append(projects, projectJSON(proj.ProjectID, proj.Title, proj.Desc, proj.Images))
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"user_id":           targetID,
-			"user_name":         userName.String,
-			"email":             email.String,
-			"phone":             phone.String,
-			"university":        university.String,
-			"faculty":           faculty.String,
-			"major":             major.String,
-			"gpa":               gpa,
-			"job_interest":      jobInterest.String,
-			"profile_image_url": profileImageURL.String,
-			"skills":            skills,
+			"user_id":           p.UserID,
+			"user_name":         p.UserName,
+			"email":             p.Email,
+			"phone":             p.Phone,
+			"university":        p.University,
+			"faculty":           p.Faculty,
+			"major":             p.Major,
+			"gpa":               p.GPA,
+			"job_interest":      p.JobInterest,
+			"profile_image_url": p.ProfileImageURL,
+			"skills":            p.Skills,
 			"projects":          projects,
 		})
 	}
@@ -0,0 +1,104 @@
+// Package logging ตั้งค่า log/slog ของทั้ง backend (JSON เป็นค่าเริ่มต้น)
+// และแนบ request_id / user_id / trace_id ที่อยู่ใน context ให้ทุกบรรทัดที่ log ด้วย *Context (เช่น slog.ErrorContext)
+package logging
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"backend/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+)
+
+// Setup สร้าง logger ตาม LOG_FORMAT / LOG_LEVEL แล้วตั้งเป็น slog.Default
+// log.Printf เดิมจะออกผ่าน logger นี้ด้วย (ระดับ INFO)
+func Setup(cfg config.Log) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	logger := slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger
+}
+
+// ParseLevel แปลง debug | info | warn | error (ค่าอื่น = info)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID เก็บ request ID ไว้ใน context ของ request
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID คืน request ID จาก context ("" ถ้าไม่มี)
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID เก็บ user_id ของคนที่ login ไว้ใน context (ตั้งโดย AuthMiddleware)
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID คืน user_id จาก context (0 ถ้ายังไม่ได้ login)
+func UserID(ctx context.Context) int {
+	id, _ := ctx.Value(userIDKey).(int)
+	return id
+}
+
+// contextHandler เติม request_id / user_id และ trace_id / span_id (ถ้ามี span) จาก context ให้ทุก record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id := UserID(ctx); id != 0 {
+			r.AddAttrs(slog.Int("user_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
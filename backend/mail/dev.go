@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer พิมพ์อีเมลลง log แทนการส่งจริง — ใช้ตอน dev (เห็น OTP ใน console)
+type LogMailer struct {
+	From string
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	body := msg.Text
+	if body == "" {
+		body = msg.HTML
+	}
+	slog.Info("mail:log", "to", msg.To, "subject", msg.Subject, "body", body)
+	return nil
+}
+
+// FileMailer เขียนอีเมลแต่ละฉบับเป็นไฟล์ .eml ใน Dir (เปิดดูด้วยโปรแกรมอีเมลได้)
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+	raw, err := buildMIME(m.From, msg, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	to := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), to)
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o644)
+}
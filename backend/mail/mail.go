@@ -0,0 +1,72 @@
+// Package mail ส่งอีเมลผ่าน Mailer ที่เลือกด้วย MAIL_DRIVER (config.Mail)
+// อีเมลจาก request ทุกฉบับเข้าคิว mail_outbox ก่อน แล้ว Worker ค่อยส่งพร้อม retry
+package mail
+
+import (
+	"backend/config"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Message คืออีเมล 1 ฉบับ — HTML และ Text เป็นเนื้อหาเดียวกันคนละรูปแบบ (ว่างได้อย่างใดอย่างหนึ่ง)
+type Message struct {
+	To       string
+	Subject  string
+	HTML     string
+	Text     string
+	Template string // ชื่อ template ที่ Render ใช้ — ใช้เป็น label ของ metrics (ว่างได้)
+}
+
+// Mailer ส่งอีเมล 1 ฉบับ — error ที่คืนมาจะทำให้ Worker retry ภายหลัง
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Driver ที่รองรับใน MAIL_DRIVER
+const (
+	DriverSMTP      = "smtp"      // SMTP จริง (ต้องมี username/password, ใช้ STARTTLS)
+	DriverLocalSMTP = "localsmtp" // SMTP จำลองบนเครื่อง เช่น Mailpit / MailHog (ไม่ auth ไม่ TLS)
+	DriverLog       = "log"       // พิมพ์อีเมลลง log (ค่าเริ่มต้นตอน dev)
+	DriverFile      = "file"      // เขียนไฟล์ .eml ลง MAIL_FILE_DIR
+)
+
+// FromConfig สร้าง Mailer ตาม MAIL_DRIVER (ค่าเริ่มต้น log)
+func FromConfig(cfg config.Mail) (Mailer, error) {
+	from := cfg.From
+
+	switch driver := strings.ToLower(cfg.Driver); driver {
+	case DriverSMTP:
+		m := &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     orDefault(cfg.SMTPPort, "587"),
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     from,
+		}
+		if m.Host == "" || m.Username == "" || m.Password == "" {
+			return nil, fmt.Errorf("mail: driver smtp ต้องตั้ง MAIL_SMTP_HOST, MAIL_SMTP_USERNAME และ MAIL_SMTP_PASSWORD")
+		}
+		return m, nil
+	case DriverLocalSMTP:
+		return &SMTPMailer{
+			Host:     orDefault(cfg.SMTPHost, "localhost"),
+			Port:     orDefault(cfg.SMTPPort, "1025"),
+			From:     from,
+			Insecure: true,
+		}, nil
+	case DriverLog:
+		return &LogMailer{From: from}, nil
+	case DriverFile:
+		return &FileMailer{Dir: orDefault(cfg.FileDir, "tmp/mail"), From: from}, nil
+	default:
+		return nil, fmt.Errorf("mail: ไม่รู้จัก MAIL_DRIVER %q", driver)
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if v := strings.TrimSpace(value); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+// buildMIME สร้างอีเมลแบบ multipart/alternative (text + html) หรือ part เดียวถ้ามีแค่อย่างเดียว
+func buildMIME(from string, msg Message, date time.Time) ([]byte, error) {
+	// กัน header injection จากอีเมลที่ผู้ใช้กรอกเอง
+	if strings.ContainsAny(from+msg.To+msg.Subject, "\r\n") {
+		return nil, errors.New("mail: header มีอักขระขึ้นบรรทัดใหม่")
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" || msg.Text == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		if err := writePart(&buf, contentType, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	// text ก่อน html — โปรแกรมอีเมลจะเลือก part สุดท้ายที่แสดงได้
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		if err := writePart(&buf, part.contentType, part.body); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writePart เขียน header ของ part (Content-Type + encoding) ตามด้วยเนื้อหาแบบ quoted-printable
+func writePart(buf *bytes.Buffer, contentType, body string) error {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=\"UTF-8\"\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("\r\n")
+	return nil
+}
+
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "porthub-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"backend/store"
+)
+
+// สถานะของอีเมลใน mail_outbox
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed" // retry ครบแล้วยังส่งไม่ได้ — last_error เก็บสาเหตุสุดท้าย
+)
+
+// Enqueue ใส่อีเมลลงคิว outbox ให้ Worker ส่งทีหลัง — ไม่เปิด SMTP ใน request
+// ctx คือ context ของ request (INSERT จะอยู่ใน trace ของ request นั้น)
+func Enqueue(ctx context.Context, outbox store.OutboxStore, m Message) error {
+	m.To = strings.TrimSpace(m.To)
+	if m.To == "" {
+		return errors.New("mail: ไม่มีผู้รับ")
+	}
+	if m.HTML == "" && m.Text == "" {
+		return errors.New("mail: ไม่มีเนื้อหา")
+	}
+
+	return outbox.Enqueue(ctx, store.OutboxMessage{
+		To:       m.To,
+		Subject:  m.Subject,
+		HTML:     m.HTML,
+		Text:     m.Text,
+		Template: m.Template,
+	})
+}
@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// ภาษาที่มี template — ภาษาอื่นหรือค่าว่างจะใช้ DefaultLocale
+const (
+	LocaleTH      = "th"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleTH
+)
+
+// template แต่ละไฟล์ชื่อ templates/<name>.<locale>.tmpl และต้อง define "subject", "text", "html"
+// ส่วน "html" ถูกครอบด้วย "layout" ใน templates/layout.tmpl
+// เพิ่มอีเมลประเภทใหม่ = เพิ่มไฟล์ template ทั้งสองภาษา ไม่ต้องแก้ Go
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// โหลดครั้งเดียวตอนเริ่มโปรแกรม — template พังให้รู้ทันทีแทนที่จะไปพังตอนส่งเมล
+var templates = mustLoadTemplates()
+
+func mustLoadTemplates() map[string]emailTemplate {
+	files, err := fs.Glob(templateFS, "templates/*.*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := map[string]emailTemplate{}
+	for _, file := range files {
+		key := strings.TrimSuffix(path.Base(file), ".tmpl") // <name>.<locale>
+
+		text, err := texttemplate.ParseFS(templateFS, file)
+		if err != nil {
+			panic(fmt.Sprintf("mail: parse %s: %v", file, err))
+		}
+		html, err := htmltemplate.ParseFS(templateFS, "templates/layout.tmpl", file)
+		if err != nil {
+			panic(fmt.Sprintf("mail: parse %s: %v", file, err))
+		}
+		for _, name := range []string{"subject", "text"} {
+			if text.Lookup(name) == nil {
+				panic(fmt.Sprintf("mail: %s ไม่มี {{define %q}}", file, name))
+			}
+		}
+		if html.Lookup("html") == nil {
+			panic(fmt.Sprintf("mail: %s ไม่มี {{define \"html\"}}", file))
+		}
+
+		loaded[key] = emailTemplate{text: text, html: html}
+	}
+	return loaded
+}
+
+// NormalizeLocale คืนภาษาที่รองรับ (th / en) — ภาษาอื่นหรือค่าว่างคืน DefaultLocale
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i] // en-US → en
+	}
+	switch locale {
+	case LocaleTH, LocaleEN:
+		return locale
+	default:
+		return DefaultLocale
+	}
+}
+
+// Render สร้างอีเมลจาก template name ในภาษา locale (ไม่มีภาษานั้นจะใช้ DefaultLocale)
+func Render(name, locale, to string, data interface{}) (Message, error) {
+	tmpl, ok := templates[name+"."+NormalizeLocale(locale)]
+	if !ok {
+		tmpl, ok = templates[name+"."+DefaultLocale]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("mail: ไม่พบ template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:       to,
+		Subject:  strings.TrimSpace(subject.String()),
+		Text:     strings.TrimSpace(text.String()),
+		HTML:     html.String(),
+		Template: name,
+	}, nil
+}
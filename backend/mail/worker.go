@@ -0,0 +1,119 @@
+package mail
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"backend/metrics"
+	"backend/store"
+)
+
+// Worker ดึงอีเมลจาก outbox มาส่งผ่าน Mailer
+// ส่งไม่สำเร็จจะ retry แบบ exponential backoff (BaseBackoff, x2 ทุกครั้ง, ไม่เกิน MaxBackoff)
+// ครบ MaxAttempts แล้วยังไม่ได้ → status = failed พร้อม last_error
+// ส่งสำเร็จหรือเลิกส่งแล้ว outbox จะล้าง html_body / text_body ทิ้ง — เนื้อหามี OTP / รหัสจริงที่ไม่ควรค้างใน DB
+type Worker struct {
+	Outbox      store.OutboxStore
+	Mailer      Mailer
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	SendTimeout time.Duration
+}
+
+// NewWorker สร้าง Worker พร้อมค่าเริ่มต้น
+func NewWorker(outbox store.OutboxStore, mailer Mailer) *Worker {
+	return &Worker{
+		Outbox:      outbox,
+		Mailer:      mailer,
+		Interval:    5 * time.Second,
+		BatchSize:   20,
+		MaxAttempts: 6,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  time.Hour,
+		SendTimeout: 30 * time.Second,
+	}
+}
+
+// Run วนส่งอีเมลจนกว่า ctx จะถูกยกเลิก
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Drain(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("mail worker: drain outbox", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain ส่งอีเมลที่ถึงกำหนดทีละ batch จนคิวว่าง
+func (w *Worker) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		// lease = 2 เท่าของ SendTimeout — ถ้า instance นี้ตายระหว่างส่ง ตัวอื่นจะรับไปส่งต่อหลังหมด lease
+		items, err := w.Outbox.Claim(ctx, 2*w.SendTimeout, w.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		for _, item := range items {
+			w.deliver(ctx, item)
+		}
+	}
+	return ctx.Err()
+}
+
+func (w *Worker) deliver(ctx context.Context, item store.OutboxMessage) {
+	msg := Message{To: item.To, Subject: item.Subject, HTML: item.HTML, Text: item.Text, Template: item.Template}
+
+	sendCtx, cancel := context.WithTimeout(ctx, w.SendTimeout)
+	err := w.Mailer.Send(sendCtx, msg)
+	cancel()
+
+	// บันทึกผลแม้ ctx ถูกยกเลิกระหว่างส่ง (ตอน shutdown) — ไม่อย่างนั้นอีเมลที่ส่งไปแล้วจะถูกส่งซ้ำ
+	markCtx := context.WithoutCancel(ctx)
+
+	if err == nil {
+		metrics.IncMailSent(msg.Template)
+		if err := w.Outbox.MarkSent(markCtx, item.ID); err != nil {
+			slog.Error("mail worker: mark sent", "outbox_id", item.ID, "error", err)
+		}
+		return
+	}
+
+	attempts := item.Attempts + 1
+	if attempts >= w.MaxAttempts {
+		metrics.IncMailFailed(msg.Template)
+		slog.Error("mail worker: ส่งไม่สำเร็จครบจำนวนครั้ง เลิกส่ง", "outbox_id", item.ID, "to", msg.To, "attempts", attempts, "error", err)
+		err = w.Outbox.MarkFailed(markCtx, item.ID, attempts, err.Error())
+	} else {
+		err = w.Outbox.MarkRetry(markCtx, item.ID, attempts, err.Error(), w.backoff(attempts))
+	}
+	if err != nil {
+		slog.Error("mail worker: update outbox", "outbox_id", item.ID, "error", err)
+	}
+}
+
+// backoff คืนระยะรอก่อน retry ครั้งถัดไป: BaseBackoff * 2^(attempts-1) ไม่เกิน MaxBackoff
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.BaseBackoff
+	for i := 1; i < attempts && d < w.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.MaxBackoff {
+		d = w.MaxBackoff
+	}
+	return d
+}
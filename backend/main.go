@@ -1,224 +1,203 @@
 package main
 
 import (
+	"backend/config"
+	"backend/database"
+	"backend/handlers"
+	"backend/logging"
+	"backend/mail"
+	"backend/metrics"
 	"backend/middleware"
 	"backend/routes"
+	"backend/store"
+	"backend/store/memory"
+	"backend/store/postgres"
+	"backend/store/sqlite"
+	"backend/tracing"
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
+const commandUsage = `usage: server [--storage postgres|sqlite|memory] [command]
+
+  serve      รัน HTTP server (ค่าเริ่มต้นเมื่อไม่ใส่ command)
+  migrate    up | down [n] | status — จัดการ schema ของ Postgres
+  user       list | show | create | delete | set-role | reset-password
+  publish    resync — สร้าง snapshot บน dashboard ใหม่ (แทนการแก้ published_* ด้วย psql)
+  codes      purge-expired — ลบรหัส OTP / reset token ที่หมดอายุ`
+
 func main() {
-	// 1. เชื่อมต่อ Database (ปรับให้รองรับทั้ง Local และ Docker)
-	host := os.Getenv("DB_HOST")
-	if host == "" {
-		host = "localhost"
-	}
-	portDB := os.Getenv("DB_PORT")
-	if portDB == "" {
-		portDB = "5432"
-	}
-	userDB := os.Getenv("DB_USER")
-	if userDB == "" {
-		userDB = "postgres"
+	// --storage=memory รันได้โดยไม่ต้องมี Postgres (ข้อมูลหายเมื่อปิด server) — มีผลเหนือ STORAGE ใน env / CONFIG_FILE
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, commandUsage) }
+	storage := flag.String("storage", "", "postgres | sqlite | memory (แทน STORAGE)")
+	flag.Parse()
+	if *storage != "" {
+		os.Setenv("STORAGE", *storage)
 	}
-	passDB := os.Getenv("DB_PASSWORD")
-	if passDB == "" {
-		passDB = "190946"
+	args := flag.Args()
+
+	command := "serve"
+	if len(args) > 0 {
+		command = args[0]
 	}
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "porthub_db"
+	switch command {
+	case "serve", "migrate", "user", "publish", "codes":
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		os.Exit(2)
 	}
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, portDB, userDB, passDB, dbName)
-	db, err := sql.Open("postgres", connStr)
+	// 0. โหลด config (CONFIG_FILE + env) — APP_ENV=production จะหยุดทันทีถ้า secret ยังเป็นค่า dev
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("❌ Error opening database:", err)
+		fatal("config load failed", err)
 	}
-	defer db.Close()
-
-	// 🚀 Connection Pool Optimization
-	db.SetMaxOpenConns(100)        // เพิ่มจำนวน connections สูงสุด
-	db.SetMaxIdleConns(25)         // เก็บ idle connections ไว้
-	db.SetConnMaxLifetime(300)     // connection อายุ 5 นาที
-
-	if err = db.Ping(); err != nil {
-		log.Fatal("❌ ไม่สามารถเชื่อมต่อ Database ได้ (Ping failed):", err)
+	// คำสั่งของ operator พิมพ์ผลลง stdout — log ระดับ info จะปนกับผลลัพธ์
+	if command != "serve" && cfg.Log.Level == "info" {
+		cfg.Log.Level = "warn"
 	}
-	fmt.Println("✅ Database connected successfully (Pool: 100 max, 25 idle)")
+	logging.Setup(cfg.Log)
+	slog.Info("config loaded", "env", cfg.Env)
 
-	// รัน migration: เพิ่มคอลัมน์ show_on_dashboard ถ้ายังไม่มี (ไม่ต้องรัน SQL เอง)
-	_, err = db.Exec("ALTER TABLE users ADD COLUMN IF NOT EXISTS show_on_dashboard BOOLEAN DEFAULT false")
+	// Tracing — TRACING_EXPORTER=stdout | otlp (ค่าเริ่มต้น none)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Printf("⚠️ Migration show_on_dashboard (อาจมีอยู่แล้ว): %v", err)
-	} else {
-		fmt.Println("✅ Migration: show_on_dashboard column OK")
+		fatal("tracing setup failed", err)
 	}
 
-	// อัปเดตค่า NULL เป็น false สำหรับ user เก่าๆ
-	_, err = db.Exec("UPDATE users SET show_on_dashboard = false WHERE show_on_dashboard IS NULL")
-	if err != nil {
-		log.Printf("⚠️ Migration update NULL values: %v", err)
-	} else {
-		fmt.Println("✅ Migration: Updated NULL values to false")
+	// 1. เลือกที่เก็บข้อมูล — handler ใช้แค่ store, db คือ Postgres ที่ migrate / rate limit ใช้
+	// STORAGE=sqlite / memory ไม่มี db (store ของทุก backend มี API ครบเท่ากัน)
+	var db *sql.DB
+	var storeDB *sql.DB // pool ที่ store ใช้ (postgres = db) — /readyz ping ตัวนี้
+	var st *store.Store
+	if cfg.Storage != config.StoragePostgres && command == "migrate" {
+		fatal("migrate ใช้ได้กับ STORAGE=postgres เท่านั้น (sqlite สร้าง schema เองตอนเริ่ม server)", fmt.Errorf("storage is %s", cfg.Storage))
 	}
-
-	// สร้างตาราง published_profiles และ published_projects (ไม่ใช้ foreign key ก่อน)
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS published_profiles (
-			user_id INTEGER PRIMARY KEY,
-			user_name VARCHAR(255),
-			email VARCHAR(255),
-			phone VARCHAR(50),
-			university VARCHAR(255),
-			faculty VARCHAR(255),
-			major VARCHAR(255),
-			gpa DECIMAL(3,2),
-			job_interest TEXT,
-			profile_image_url TEXT,
-			skills TEXT,
-			published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		log.Printf("⚠️ Migration published_profiles: %v", err)
-	} else {
-		fmt.Println("✅ Migration: published_profiles table OK")
+	if cfg.Storage == config.StorageMemory && command != "serve" {
+		fatal("STORAGE=memory ใช้ได้กับ serve เท่านั้น (ข้อมูลอยู่ใน process ของ server)", fmt.Errorf("command %s", command))
 	}
+	switch cfg.Storage {
+	case config.StorageMemory:
+		st = memory.New()
+		slog.Warn("using in-memory storage — data is lost on restart")
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS published_projects (
-			published_project_id SERIAL PRIMARY KEY,
-			user_id INTEGER NOT NULL,
-			project_id INTEGER NOT NULL,
-			project_name VARCHAR(255),
-			description TEXT,
-			image_url TEXT,
-			published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(user_id, project_id)
-		)
-	`)
-	if err != nil {
-		log.Printf("⚠️ Migration published_projects: %v", err)
-	} else {
-		fmt.Println("✅ Migration: published_projects table OK")
-	}
+	case config.StorageSQLite:
+		// ไฟล์เดียว ไม่ต้องมี Postgres — pure Go driver (modernc.org/sqlite) จึงยัง build แบบ CGO_ENABLED=0 ได้
+		storeDB, err = tracing.OpenDB("sqlite", sqlite.DSN(cfg.Database.SQLitePath))
+		if err != nil {
+			fatal("open sqlite failed", err)
+		}
+		defer storeDB.Close()
 
-	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_published_projects_user_id ON published_projects(user_id)")
-	if err != nil {
-		log.Printf("⚠️ Migration index: %v", err)
-	} else {
-		fmt.Println("✅ Migration: Index created")
-	}
+		applied, err := sqlite.Migrate(context.Background(), storeDB)
+		if err != nil {
+			fatal("sqlite schema failed", err)
+		}
+		slog.Info("sqlite ready", "path", cfg.Database.SQLitePath, "applied", applied)
+		metrics.RegisterDB(storeDB, "porthub")
+		st = sqlite.New(storeDB)
 
-	// คัดลอกข้อมูล users ที่มี show_on_dashboard = true ไปยัง published_profiles
-	_, err = db.Exec(`
-		INSERT INTO published_profiles (
-			user_id, user_name, email, phone, university, faculty, major, gpa, 
-			job_interest, profile_image_url, skills, published_at, updated_at
-		)
-		SELECT 
-			u.user_id,
-			u.user_name,
-			u.email,
-			u.phone,
-			u.university,
-			u.faculty,
-			u.major,
-			u.gpa,
-			u.job_interest,
-			u.profile_image_url,
-			COALESCE(
-				(
-					SELECT json_agg(s.skill_name)::text
-					FROM user_skills us
-					JOIN skills s ON us.skill_id = s.skill_id
-					WHERE us.user_id = u.user_id
-				),
-				'[]'
-			) as skills,
-			NOW(),
-			NOW()
-		FROM users u
-		WHERE u.show_on_dashboard = true
-		ON CONFLICT (user_id) DO UPDATE SET
-			user_name = EXCLUDED.user_name,
-			email = EXCLUDED.email,
-			phone = EXCLUDED.phone,
-			university = EXCLUDED.university,
-			faculty = EXCLUDED.faculty,
-			major = EXCLUDED.major,
-			gpa = EXCLUDED.gpa,
-			job_interest = EXCLUDED.job_interest,
-			profile_image_url = EXCLUDED.profile_image_url,
-			skills = EXCLUDED.skills,
-			updated_at = NOW()
-	`)
-	if err != nil {
-		log.Printf("⚠️ Migration copy users: %v", err)
-	} else {
-		fmt.Println("✅ Migration: Copied existing users to published_profiles")
+	default:
+		// เชื่อมต่อ Database (ปรับให้รองรับทั้ง Local และ Docker)
+		db, err = tracing.OpenDB("postgres", cfg.Database.DSN()) // ทุก query ใน request เป็น span
+		if err != nil {
+			fatal("open database failed", err)
+		}
+		defer db.Close()
+
+		// 🚀 Connection Pool Optimization
+		db.SetMaxOpenConns(100)                // เพิ่มจำนวน connections สูงสุด
+		db.SetMaxIdleConns(25)                 // เก็บ idle connections ไว้
+		db.SetConnMaxLifetime(5 * time.Minute) // connection อายุ 5 นาที
+
+		if err = db.Ping(); err != nil {
+			fatal("ไม่สามารถเชื่อมต่อ Database ได้ (Ping failed)", err)
+		}
+		slog.Info("database connected", "max_open_conns", 100, "max_idle_conns", 25)
+
+		// Prometheus: stats ของ pool (go_sql_*) — ดูว่า max 100 / idle 25 เหมาะกับ load จริงหรือไม่
+		metrics.RegisterDB(db, "porthub")
+
+		// `server migrate up|down|status` — จัดการ schema แล้วจบ ไม่เริ่ม server
+		if command == "migrate" {
+			code := runMigrateCommand(db, args[1:])
+			db.Close()
+			os.Exit(code)
+		}
+
+		// schema ต้องตรงกับ migration ใน build นี้ — ถ้ายังค้างให้รัน `server migrate up` ก่อน
+		if err := database.CheckMigrations(context.Background(), db); err != nil {
+			fatal("database schema check failed", err)
+		}
+		slog.Info("database schema is up to date")
+
+		// handler อ่าน/เขียนข้อมูลผ่าน store (store/postgres) แทนการเขียน SQL เอง
+		st = postgres.New(db)
+		storeDB = db
 	}
 
-	// คัดลอก projects ของ users ที่ publish แล้ว
-	_, err = db.Exec(`
-		INSERT INTO published_projects (
-			user_id, project_id, project_name, description, image_url, published_at
-		)
-		SELECT 
-			p.user_id,
-			p.project_id,
-			p.project_name,
-			p.description,
-			p.image_url,
-			NOW()
-		FROM projects p
-		WHERE p.user_id IN (SELECT user_id FROM users WHERE show_on_dashboard = true)
-		ON CONFLICT (user_id, project_id) DO UPDATE SET
-			project_name = EXCLUDED.project_name,
-			description = EXCLUDED.description,
-			image_url = EXCLUDED.image_url,
-			published_at = NOW()
-	`)
-	if err != nil {
-		log.Printf("⚠️ Migration copy projects: %v", err)
-	} else {
-		fmt.Println("✅ Migration: Copied existing projects to published_projects")
+	// `server user|publish|codes ...` — งานประจำของ operator แทนการเปิด psql แล้วจบ ไม่เริ่ม server
+	if command != "serve" {
+		code := runAdminCommand(st, args)
+		storeDB.Close()
+		os.Exit(code)
 	}
 
 	// 2. สร้าง Server
 	gin.SetMode(gin.ReleaseMode) // 🚀 Production mode
 	r := gin.New()
-	
+
+	// 🔒 Client IP — เชื่อ X-Forwarded-For / header ของ platform เฉพาะจาก proxy ที่ตั้งไว้ (rate limit, audit log, sessions ใช้ c.ClientIP())
+	if err := middleware.ConfigureTrustedProxies(r, cfg.Proxy); err != nil {
+		fatal("trusted proxy config error", err)
+	}
+
 	// 🚀 Performance Middleware
 	r.Use(gin.Recovery()) // Panic recovery
-	r.Use(gin.Logger())   // Logging
-	
+	r.Use(middleware.Tracing(cfg.Tracing.ServiceName))
+
+	// Request ID — รับ X-Request-ID จาก proxy หรือสร้างใหม่ แนบใน log และ error response ทุกตัว
+	r.Use(middleware.RequestID())
+
+	// Health probes + /metrics — ลงทะเบียนก่อน Logger / rate limit เพื่อไม่ให้ probe / scrape ถี่ๆ รก log หรือโดน limit
+	var shuttingDown atomic.Bool
+	r.GET("/healthz", handlers.Healthz())
+	r.GET("/readyz", handlers.Readyz(cfg.Storage, storeDB, &shuttingDown))
+	r.GET("/metrics", middleware.MetricsAuth(cfg.MetricsToken), gin.WrapH(metrics.Handler()))
+
+	r.Use(middleware.RequestLogger()) // Logging (JSON ผ่าน slog)
+	r.Use(middleware.Metrics())       // Prometheus: requests / latency ต่อ route
+
 	// 🚀 Rate Limiting: 200 requests per minute per IP
+	// RATE_LIMIT_STORE=postgres เมื่อรันหลาย replica — limit จะนับรวมกันทุกตัว
+	rateLimitStore, err := middleware.NewRateLimitStore(cfg.RateLimitStore, db)
+	if err != nil {
+		fatal("rate limit config error", err)
+	}
+	middleware.SetRateLimitStore(rateLimitStore)
 	r.Use(middleware.RateLimitMiddleware(200, time.Minute))
 
 	// --- Middleware สำหรับ CORS (แก้ไขให้ครอบคลุม) ---
-	allowOrigin := os.Getenv("CORS_ORIGIN")
-	if allowOrigin == "" {
-		allowOrigin = "http://localhost:3000"
-	}
+	allowOrigin := cfg.CORSOrigin
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Origin, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Origin, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		
-		// 🚀 Cache headers for static content
-		if c.Request.Method == "GET" {
-			c.Writer.Header().Set("Cache-Control", "public, max-age=300") // 5 minutes
-		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -227,32 +206,99 @@ func main() {
 		c.Next()
 	})
 
+	// 🚀 Cache: response ส่วนใหญ่เป็นข้อมูลของ user จึงห้าม cache — Dashboard สาธารณะตั้ง public เองใน DashboardRoutes
+	r.Use(middleware.NoStore())
+
+	// Mail worker — ส่งอีเมลจาก outbox ของ store ผ่าน driver ที่ตั้งใน MAIL_DRIVER
+	mailer, err := mail.FromConfig(cfg.Mail)
+	if err != nil {
+		fatal("mail config error", err)
+	}
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		mail.NewWorker(st.Outbox, mailer).Run(workerCtx)
+	}()
+	slog.Info("mail worker started", "driver", fmt.Sprintf("%T", mailer))
+
 	// 3. จัดกลุ่ม API
 	// ถ้า Group เป็น "/api" แล้วข้างใน routes.AuthRoutes มี "/forgot-password"
 	// URL ของจริงจะเป็น http://localhost:8080/api/forgot-password
 	api := r.Group("/api")
 	{
-		routes.AuthRoutes(api, db)
-		routes.UserRoutes(api, db)
-		routes.ProjectRoutes(api, db)
-		routes.DashboardRoutes(api, db)
+		routes.AuthRoutes(api, st)
+		routes.UserRoutes(api, st)
+		routes.ProjectRoutes(api, st)
+		routes.DashboardRoutes(api, st)
+		routes.AdminRoutes(api, st)
 	}
 
 	// 4. เริ่มรัน Server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := cfg.Port
 
-	fmt.Printf("\n🔥 [SERVER START] http://localhost:%s\n", port)
-	fmt.Println("📌 Available Routes:")
-	// บรรทัดนี้จะช่วยนายเช็คว่า Route เข้าไปในระบบหรือยัง
+	slog.Info("server start", "addr", "http://localhost:"+port)
+	// บรรทัดนี้จะช่วยนายเช็คว่า Route เข้าไปในระบบหรือยัง (เห็นเมื่อ LOG_LEVEL=debug)
 	for _, route := range r.Routes() {
-		fmt.Printf("   %s %s\n", route.Method, route.Path)
+		slog.Debug("route", "method", route.Method, "path", route.Path)
 	}
-	fmt.Println("------------------------------------------")
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("❌ Server run error:", err)
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           r,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// 5. Graceful shutdown — SIGINT / SIGTERM: /readyz ตอบ 503 ไป SHUTDOWN_DELAY, รอ request ที่ค้างอยู่ให้เสร็จ แล้วหยุด mail worker
+	stop, cancelSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancelSignals()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal("server run error", err)
+		}
+	case <-stop.Done():
+	}
+
+	slog.Info("shutting down")
+	shuttingDown.Store(true)
+
+	// ให้ load balancer เห็น /readyz ตอบ 503 และเลิกส่ง request ใหม่มาก่อน — Shutdown ปิด listener ทันที
+	// ถ้าปิดเลย request ที่ LB ยังส่งมาระหว่างนั้นจะโดน connection refused
+	if delay := time.Duration(cfg.Server.ShutdownDelay); delay > 0 {
+		slog.Info("draining before shutdown", "delay", delay.String())
+		time.Sleep(delay)
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("http shutdown", "error", err)
+	}
+
+	stopWorker()
+	select {
+	case <-workerDone:
+	case <-shutdownCtx.Done():
+		slog.Warn("mail worker did not stop before SHUTDOWN_TIMEOUT")
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("tracing shutdown", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// fatal log แล้วจบ process (แทน log.Fatal ให้ออกเป็น JSON เหมือนบรรทัดอื่น)
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,118 @@
+// Package metrics เก็บ Prometheus metrics ของ backend ไว้ที่เดียว (registry แยกจาก global)
+// middleware / mail / handlers เรียก Observe* / Inc* แล้ว main เปิด /metrics ด้วย Handler
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "porthub"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	rateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected with 429 by rate limit policy.",
+	}, []string{"policy"})
+
+	mailSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mail_sent_total",
+		Help:      "Emails delivered by the outbox worker, by template.",
+	}, []string{"template"})
+
+	mailFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mail_failed_total",
+		Help:      "Emails the outbox worker gave up on after MaxAttempts, by template.",
+	}, []string{"template"})
+
+	dashboardVisibility = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dashboard_visibility_changes_total",
+		Help:      "Dashboard publish / unpublish operations that committed.",
+	}, []string{"action"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		rateLimitRejections,
+		mailSent,
+		mailFailed,
+		dashboardVisibility,
+	)
+}
+
+// Handler คืน http.Handler ที่ตอบ /metrics ในรูปแบบ Prometheus text
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RegisterDB เพิ่ม stats ของ connection pool (go_sql_*{db_name="<name>"}) — ใช้ดูว่า SetMaxOpenConns พอหรือไม่
+// เรียกครั้งเดียวต่อ *sql.DB
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveHTTPRequest นับ request และเวลาที่ใช้ — route คือ path template ของ gin (เช่น /api/users/:id)
+func ObserveHTTPRequest(method, route string, status int, elapsed time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// IncRateLimitRejection นับ request ที่ถูก policy ตอบ 429
+func IncRateLimitRejection(policy string) {
+	rateLimitRejections.WithLabelValues(policy).Inc()
+}
+
+// IncMailSent นับอีเมลที่ส่งสำเร็จ (template ว่าง = อีเมลที่เข้าคิวก่อนมีคอลัมน์ template)
+func IncMailSent(template string) {
+	mailSent.WithLabelValues(templateLabel(template)).Inc()
+}
+
+// IncMailFailed นับอีเมลที่เลิกส่งหลัง retry ครบ
+func IncMailFailed(template string) {
+	mailFailed.WithLabelValues(templateLabel(template)).Inc()
+}
+
+// IncDashboardPublish / IncDashboardUnpublish นับการเปลี่ยน show_on_dashboard ที่ commit แล้ว
+func IncDashboardPublish() {
+	dashboardVisibility.WithLabelValues("publish").Inc()
+}
+
+func IncDashboardUnpublish() {
+	dashboardVisibility.WithLabelValues("unpublish").Inc()
+}
+
+func templateLabel(template string) string {
+	if template == "" {
+		return "unknown"
+	}
+	return template
+}
@@ -2,54 +2,59 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
+	"backend/logging"
+	"backend/store"
+	"backend/utils"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func getJWTKey() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "porthub_dev_secret_change_in_production_2024"
-	}
-	return []byte(secret)
-}
-
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(sessions store.SessionStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Authorization header missing")
 			c.Abort()
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims := &jwt.RegisteredClaims{}
-
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return getJWTKey(), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		claims, err := utils.ParseToken(tokenString)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Invalid token")
 			c.Abort()
 			return
 		}
 
 		userID, err := strconv.Atoi(claims.Subject)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Invalid user ID")
+			c.Abort()
+			return
+		}
+
+		// session ต้องยังไม่ถูก revoke (logout, reset password) และ user ต้องยังอยู่ (ลบ user แล้ว session หายตาม)
+		active, err := sessions.IsActive(c.Request.Context(), claims.SessionID, userID)
+		if err != nil {
+			utils.ErrorJSON(c, http.StatusInternalServerError, "Failed to verify session")
+			c.Abort()
+			return
+		}
+		if !active {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Session revoked")
 			c.Abort()
 			return
 		}
 
 		c.Set("user_id", userID)
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), userID))
+		c.Set("session_id", claims.SessionID)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoStore ห้าม browser / proxy เก็บ response ของ GET ไว้ — ค่าเริ่มต้นของทุก route
+// เพราะ response ส่วนใหญ่เป็นข้อมูลของ user ที่ login อยู่ (profile, session, security events)
+func NoStore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet {
+			c.Header("Cache-Control", "private, no-store")
+		}
+		c.Next()
+	}
+}
+
+// PublicCache ให้ cache response ร่วมกันได้ maxAge — ใช้เฉพาะ route ที่ไม่ต้อง login และไม่ขึ้นกับผู้เรียก
+// (ต้องอยู่หลัง NoStore เพื่อเขียนทับ header)
+func PublicCache(maxAge time.Duration) gin.HandlerFunc {
+	value := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", value)
+		c.Next()
+	}
+}
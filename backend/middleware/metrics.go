@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/metrics"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics นับ request และ latency ต่อ route ลง Prometheus
+// ใช้ c.FullPath() (template เช่น /api/users/:id) เป็น label — path จริงจะทำให้ series บวมไม่จำกัด
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAuth ป้องกัน /metrics ด้วย "Authorization: Bearer <METRICS_TOKEN>" — token ว่าง = ไม่ตรวจ
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			utils.ErrorJSON(c, http.StatusUnauthorized, "Invalid metrics token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"backend/config"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigureTrustedProxies ตั้งค่าว่า c.ClientIP() เชื่อ header ของ proxy ตัวไหน
+// ค่าเริ่มต้นของ gin เชื่อ X-Forwarded-For จากทุกที่ — client ปลอม IP เพื่อหลบ rate limit หรือ audit log ได้
+// จึงเริ่มจากไม่เชื่อ proxy ใดเลย (ใช้ IP ของ connection) แล้วเปิดเฉพาะที่ตั้งไว้ใน config.Proxy:
+//   - TRUSTED_PROXIES — IP / CIDR ของ reverse proxy ที่เชื่อ คั่นด้วย comma (เช่น 10.0.0.0/8,172.16.0.0/12)
+//   - TRUSTED_PLATFORM — cloudflare, google-app-engine, flyio หรือชื่อ header ที่ platform ใส่ IP จริงมาให้
+//     (header ที่ตั้งชื่อเองจะอ่านเฉพาะจาก proxy ใน TRUSTED_PROXIES — ไม่งั้น client ส่ง header นี้มาเองได้)
+//   - REMOTE_IP_HEADERS — header ที่อ่านจาก proxy ที่เชื่อ (ค่าเริ่มต้น X-Forwarded-For,X-Real-IP)
+func ConfigureTrustedProxies(r *gin.Engine, cfg config.Proxy) error {
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	if len(cfg.RemoteIPHeaders) > 0 {
+		r.RemoteIPHeaders = cfg.RemoteIPHeaders
+	}
+
+	switch platform := strings.TrimSpace(cfg.TrustedPlatform); strings.ToLower(platform) {
+	case "":
+	case "cloudflare":
+		r.TrustedPlatform = gin.PlatformCloudflare
+	case "google-app-engine", "appengine":
+		r.TrustedPlatform = gin.PlatformGoogleAppEngine
+	case "flyio", "fly":
+		r.TrustedPlatform = gin.PlatformFlyIO
+	default:
+		// gin เชื่อ TrustedPlatform จากทุก connection โดยไม่ดู TrustedProxies — ใช้ได้กับ platform ข้างบนที่ edge
+		// เขียนทับ header ให้เสมอ แต่ header ที่ตั้งชื่อเองไม่มีอะไรรับประกัน จึงอ่านแบบ remote IP header แทน
+		// (เชื่อเฉพาะเมื่อ request มาจาก proxy ใน TRUSTED_PROXIES)
+		if len(cfg.TrustedProxies) == 0 {
+			return fmt.Errorf("TRUSTED_PLATFORM=%q เป็น header ที่ตั้งชื่อเอง ต้องตั้ง TRUSTED_PROXIES ของ proxy ที่ใส่ header นี้ด้วย", platform)
+		}
+		r.RemoteIPHeaders = append([]string{platform}, r.RemoteIPHeaders...)
+	}
+
+	return nil
+}
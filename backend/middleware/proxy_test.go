@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPRouter คืน router ที่ตอบ c.ClientIP() หลังตั้งค่า proxy ตาม cfg
+func clientIPRouter(t *testing.T, cfg config.Proxy) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := ConfigureTrustedProxies(r, cfg); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+	return r
+}
+
+func TestConfigureTrustedProxiesClientIP(t *testing.T) {
+	internal := []string{"10.0.0.0/8"}
+	tests := []struct {
+		name    string
+		cfg     config.Proxy
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "no proxies ignores X-Forwarded-For",
+			remote:  "203.0.113.9",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "trusted proxy forwards client IP",
+			cfg:     config.Proxy{TrustedProxies: internal},
+			remote:  "10.0.0.5",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "proxy chain skips trusted hops",
+			cfg:     config.Proxy{TrustedProxies: internal},
+			remote:  "10.0.0.5",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 10.0.0.7"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "spoofed hop before the real client is ignored",
+			cfg:     config.Proxy{TrustedProxies: internal},
+			remote:  "10.0.0.5",
+			headers: map[string]string{"X-Forwarded-For": "6.6.6.6, 1.2.3.4"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "untrusted peer cannot forward",
+			cfg:     config.Proxy{TrustedProxies: internal},
+			remote:  "203.0.113.9",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "remote IP headers limit what is read",
+			cfg:     config.Proxy{TrustedProxies: internal, RemoteIPHeaders: []string{"X-Real-IP"}},
+			remote:  "10.0.0.5",
+			headers: map[string]string{"X-Forwarded-For": "6.6.6.6", "X-Real-IP": "1.2.3.4"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "cloudflare header",
+			cfg:     config.Proxy{TrustedPlatform: "Cloudflare"},
+			remote:  "203.0.113.9",
+			headers: map[string]string{"CF-Connecting-IP": "1.2.3.4", "X-Forwarded-For": "6.6.6.6"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "fly.io header",
+			cfg:     config.Proxy{TrustedPlatform: "flyio"},
+			remote:  "203.0.113.9",
+			headers: map[string]string{"Fly-Client-IP": "1.2.3.4"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "custom platform header from trusted proxy",
+			cfg:     config.Proxy{TrustedProxies: internal, TrustedPlatform: "X-Client-IP"},
+			remote:  "10.0.0.5",
+			headers: map[string]string{"X-Client-IP": "1.2.3.4", "X-Forwarded-For": "6.6.6.6"},
+			want:    "1.2.3.4",
+		},
+		{
+			name:    "custom platform header from untrusted peer",
+			cfg:     config.Proxy{TrustedProxies: internal, TrustedPlatform: "X-Client-IP"},
+			remote:  "203.0.113.9",
+			headers: map[string]string{"X-Client-IP": "1.2.3.4"},
+			want:    "203.0.113.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := clientIPRouter(t, tt.cfg)
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote + ":40000"
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tt.want {
+				t.Fatalf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureTrustedProxiesRejectsBadConfig(t *testing.T) {
+	for name, cfg := range map[string]config.Proxy{
+		"invalid CIDR":                  {TrustedProxies: []string{"10.0.0.0/99"}},
+		"custom header without proxies": {TrustedPlatform: "X-Client-IP"},
+	} {
+		if err := ConfigureTrustedProxies(gin.New(), cfg); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestRateLimitUsesResolvedClientIP(t *testing.T) {
+	r := clientIPRouter(t, config.Proxy{})
+	s, _ := newTestRateLimitStore()
+	r.GET("/limited", RateLimit(RateLimitPolicy{Name: "test", Rate: 1, Window: time.Minute, Store: s}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// ไม่ได้ตั้ง proxy — เปลี่ยน X-Forwarded-For ทุกครั้งก็ยังนับเป็น IP เดียวกัน
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest("GET", "/limited", nil)
+		req.RemoteAddr = "203.0.113.9:40000"
+		req.Header.Set("X-Forwarded-For", []string{"1.1.1.1", "2.2.2.2"}[i])
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("request #%d: status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
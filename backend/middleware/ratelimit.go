@@ -1,100 +1,173 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
+	"backend/metrics"
+	"backend/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
-// 🚀 Simple in-memory rate limiter
+// 🚀 Token-bucket rate limiter
+// แต่ละ key มีถัง (bucket) จุ rate token เติมกลับเต็มถังภายใน window — ยิงรัวได้ไม่เกิน rate ครั้ง
+// แล้วได้ token คืนทีละนิดแทนการรีเซ็ตทั้งก้อนทุก window แบบ fixed window
+// ถังเก็บใน RateLimitStore — ใช้ store ร่วม (postgres) เมื่อรันหลาย replica เพื่อให้ limit รวมกันทุกตัว
 type RateLimiter struct {
-	visitors map[string]*Visitor
-	mu       sync.RWMutex
-	rate     int           // requests per window
-	window   time.Duration // time window
+	store  RateLimitStore
+	rate   int           // ขนาดถัง (requests ต่อ window)
+	window time.Duration // เวลาที่ใช้เติมถังจนเต็ม
 }
 
-type Visitor struct {
-	lastSeen time.Time
-	count    int
+// RateLimitResult คือผลของการขอ 1 token — ใช้ตั้ง header X-RateLimit-*
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // รอเท่าไหร่จึงมี token ถัดไป (0 ถ้า Allowed)
+	Reset      time.Duration // รอเท่าไหร่ถังจึงเต็ม
 }
 
-func NewRateLimiter(rate int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		visitors: make(map[string]*Visitor),
-		rate:     rate,
-		window:   window,
+// defaultRateLimitStore คือ store ของ policy ที่ไม่ได้ระบุ Store — ตั้งด้วย SetRateLimitStore ก่อนสร้าง route
+var defaultRateLimitStore RateLimitStore = NewMemoryRateLimitStore()
+
+// SetRateLimitStore เปลี่ยน store เริ่มต้นของ limiter ที่สร้างหลังจากนี้
+func SetRateLimitStore(store RateLimitStore) {
+	defaultRateLimitStore = store
+}
+
+func NewRateLimiter(store RateLimitStore, rate int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		rate:   rate,
+		window: window,
 	}
-	
-	// Cleanup old visitors every minute
-	go rl.cleanupVisitors()
-	
-	return rl
 }
 
-func (rl *RateLimiter) cleanupVisitors() {
-	for {
-		time.Sleep(time.Minute)
-		rl.mu.Lock()
-		for ip, v := range rl.visitors {
-			if time.Since(v.lastSeen) > rl.window {
-				delete(rl.visitors, ip)
-			}
-		}
-		rl.mu.Unlock()
+// Allow ขอ 1 token ของ key
+// ถ้า store ใช้งานไม่ได้ (เช่น database ล่ม) จะปล่อยผ่าน — limiter ล่มไม่ควรทำให้ทั้งระบบใช้ไม่ได้
+func (rl *RateLimiter) Allow(ctx context.Context, key string) RateLimitResult {
+	result := RateLimitResult{Limit: rl.rate}
+
+	tokens, allowed, err := rl.store.Take(ctx, key, rl.rate, rl.window)
+	if err != nil {
+		logRateLimitStoreError("take", err)
+		result.Allowed = true
+		result.Remaining = rl.rate
+		return result
+	}
+
+	perToken := rl.window / time.Duration(rl.rate)
+	result.Allowed = allowed
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration((float64(rl.rate) - tokens) * float64(perToken))
+	return result
+}
+
+func logRateLimitStoreError(op string, err error) {
+	slog.Warn("rate limit store error", "op", op, "error", err)
+}
+
+// RateLimitKeyFunc คืน key ที่ใช้นับ limit ของ request
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP นับตาม IP ของ client
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUserID นับตาม user ที่ login (ต้องอยู่หลัง AuthMiddleware) — ถ้าไม่มี user_id จะนับตาม IP
+func KeyByUserID(c *gin.Context) string {
+	if userID := c.GetInt("user_id"); userID != 0 {
+		return "user:" + strconv.Itoa(userID)
 	}
+	return KeyByIP(c)
 }
 
-func (rl *RateLimiter) Allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	
-	v, exists := rl.visitors[ip]
-	now := time.Now()
-	
-	if !exists {
-		rl.visitors[ip] = &Visitor{
-			lastSeen: now,
-			count:    1,
+// KeyByJSONField นับตามค่าของ field ใน JSON body (เช่น email ของ /forgot-password)
+// อ่าน body แล้วใส่คืนให้ handler bind ต่อได้ — ถ้าไม่มี field นี้จะนับตาม IP
+func KeyByJSONField(field string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return KeyByIP(c)
+		}
+		raw, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+		if err != nil {
+			return KeyByIP(c)
+		}
+
+		var body map[string]interface{}
+		if json.Unmarshal(raw, &body) != nil {
+			return KeyByIP(c)
+		}
+		value, _ := body[field].(string)
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			return KeyByIP(c)
 		}
-		return true
+		return field + ":" + value
 	}
-	
-	// Reset counter if window has passed
-	if now.Sub(v.lastSeen) > rl.window {
-		v.count = 1
-		v.lastSeen = now
-		return true
+}
+
+// RateLimitPolicy กำหนด limit ของ route / route group
+type RateLimitPolicy struct {
+	Name   string        // ใช้แยก key ของแต่ละ policy
+	Rate   int           // requests ต่อ Window
+	Window time.Duration // เวลาที่ใช้เติม token จนเต็ม
+	Key    RateLimitKeyFunc
+	Store  RateLimitStore // nil = store เริ่มต้น (SetRateLimitStore)
+}
+
+// RateLimit สร้าง middleware ตาม policy พร้อม header X-RateLimit-Limit / Remaining / Reset และ Retry-After เมื่อเกิน
+func RateLimit(policy RateLimitPolicy) gin.HandlerFunc {
+	store := policy.Store
+	if store == nil {
+		store = defaultRateLimitStore
 	}
-	
-	// Check if rate limit exceeded
-	if v.count >= rl.rate {
-		return false
+	limiter := NewRateLimiter(store, policy.Rate, policy.Window)
+	keyFunc := policy.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP
 	}
-	
-	v.count++
-	v.lastSeen = now
-	return true
-}
 
-// RateLimitMiddleware creates a rate limiting middleware
-// Example: 100 requests per minute per IP
-func RateLimitMiddleware(rate int, window time.Duration) gin.HandlerFunc {
-	limiter := NewRateLimiter(rate, window)
-	
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		
-		if !limiter.Allow(ip) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please try again later.",
-			})
+		result := limiter.Allow(c.Request.Context(), policy.Name+"|"+keyFunc(c))
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			metrics.IncRateLimitRejection(policy.Name)
+			utils.ErrorJSON(c, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.")
 			c.Abort()
 			return
 		}
-		
+
 		c.Next()
 	}
 }
+
+// RateLimitMiddleware creates a rate limiting middleware keyed by IP
+// Example: 100 requests per minute per IP
+func RateLimitMiddleware(rate int, window time.Duration) gin.HandlerFunc {
+	return RateLimit(RateLimitPolicy{Name: "ip", Rate: rate, Window: window, Key: KeyByIP})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitStore เก็บถัง token ของแต่ละ key
+// Take ต้องเติม token ตามเวลาที่ผ่านไปแล้วหัก 1 token แบบ atomic — คืน token ที่เหลือหลังหัก และหักได้หรือไม่
+// ctx คือ context ของ request — query ของ store ที่ใช้ database จะเป็น span ลูกใน trace ของ request นั้น
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rate int, window time.Duration) (tokens float64, allowed bool, err error)
+}
+
+// NewRateLimitStore เลือก store ตาม RATE_LIMIT_STORE
+//   - memory (ค่าเริ่มต้น) — map ใน process เดียว ใช้ได้เมื่อรัน backend ตัวเดียว
+//   - postgres — ตาราง rate_limit_buckets ใช้ร่วมกันทุก replica
+func NewRateLimitStore(driver string, db *sql.DB) (RateLimitStore, error) {
+	switch driver = strings.ToLower(strings.TrimSpace(driver)); driver {
+	case "", "memory":
+		return NewMemoryRateLimitStore(), nil
+	case "postgres":
+		return NewPostgresRateLimitStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", driver)
+	}
+}
+
+// ---------------- Memory ----------------
+
+// MemoryRateLimitStore เก็บถังไว้ใน map ของ process นี้
+type MemoryRateLimitStore struct {
+	buckets   map[string]*bucket
+	mu        sync.Mutex
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	last    time.Time
+	expires time.Time // หลังจากนี้ถังเต็มแล้ว ลบทิ้งได้
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rate int, window time.Duration) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, exists := s.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(rate), last: now}
+		s.buckets[key] = b
+	}
+
+	// เติม token ตามเวลาที่ผ่านไปตั้งแต่ครั้งก่อน
+	b.tokens = math.Min(float64(rate), b.tokens+now.Sub(b.last).Seconds()*float64(rate)/window.Seconds())
+	b.last = now
+	b.expires = now.Add(window)
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// sweep ลบถังที่เต็มแล้ว — ทำใน Take แทน goroutine จึงไม่มีอะไรค้างให้ต้องหยุด
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.After(b.expires) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// ---------------- Postgres ----------------
+
+// PostgresRateLimitStore เก็บถังในตาราง rate_limit_buckets — ทุก replica เห็นถังเดียวกัน
+// เติมและหัก token ใน statement เดียว (INSERT ... ON CONFLICT) ด้วยเวลาของ database จึงไม่ต้องพึ่งนาฬิกาของแต่ละเครื่อง
+type PostgresRateLimitStore struct {
+	db        *sql.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresRateLimitStore(db *sql.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db, lastSweep: time.Now()}
+}
+
+// ใน DO UPDATE ค่า rate_limit_buckets.* คือแถวเดิมก่อนอัปเดต — refill (LEAST(...)) จึงคำนวณซ้ำได้โดยได้ค่าเท่ากันทุกที่
+const takeRateLimitTokenSQL = `
+	INSERT INTO rate_limit_buckets (bucket_key, tokens, allowed, updated_at, expires_at)
+	VALUES ($1, $2::float8 - 1, TRUE, NOW(), NOW() + $3::float8 * INTERVAL '1 second')
+	ON CONFLICT (bucket_key) DO UPDATE SET
+		tokens = LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * $2::float8 / $3::float8)
+			- (LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * $2::float8 / $3::float8) >= 1)::int,
+		allowed = LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * $2::float8 / $3::float8) >= 1,
+		updated_at = NOW(),
+		expires_at = NOW() + $3::float8 * INTERVAL '1 second'
+	RETURNING tokens, allowed
+`
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, rate int, window time.Duration) (float64, bool, error) {
+	s.sweep()
+
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, takeRateLimitTokenSQL, key, rate, window.Seconds()).Scan(&tokens, &allowed)
+	if err != nil {
+		return 0, false, err
+	}
+	return tokens, allowed, nil
+}
+
+// sweep ลบถังที่เต็มแล้ว นาทีละครั้งต่อ replica — replica ไหนลบก่อนก็ได้ผลเหมือนกัน
+func (s *PostgresRateLimitStore) sweep() {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	// ทำเบื้องหลังหลัง request จบได้ จึงไม่ผูกกับ context ของ request
+	go func() {
+		if _, err := s.db.ExecContext(context.Background(), "DELETE FROM rate_limit_buckets WHERE expires_at < NOW()"); err != nil {
+			logRateLimitStoreError("sweep", err)
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStoreBurstAndRefill(t *testing.T) {
+	s, clock := newTestRateLimitStore()
+	ctx := context.Background()
+	const rate, window = 4, time.Minute
+
+	// ถังเต็มตอนเริ่ม — ยิงรัวได้ rate ครั้ง
+	for i := 0; i < rate; i++ {
+		tokens, allowed, err := s.Take(ctx, "k", rate, window)
+		if err != nil || !allowed {
+			t.Fatalf("take #%d: allowed = %v, err = %v", i+1, allowed, err)
+		}
+		if want := float64(rate - i - 1); tokens != want {
+			t.Fatalf("take #%d: tokens = %v, want %v", i+1, tokens, want)
+		}
+	}
+	if _, allowed, _ := s.Take(ctx, "k", rate, window); allowed {
+		t.Fatal("take after burst allowed")
+	}
+
+	// ได้ token คืนทีละ window/rate
+	clock.advance(window/rate - time.Second)
+	if _, allowed, _ := s.Take(ctx, "k", rate, window); allowed {
+		t.Fatal("allowed before a token was refilled")
+	}
+	clock.advance(time.Second)
+	if _, allowed, _ := s.Take(ctx, "k", rate, window); !allowed {
+		t.Fatal("refilled token not allowed")
+	}
+
+	// รอนานเกิน window ก็เติมได้ไม่เกินขนาดถัง
+	clock.advance(10 * window)
+	tokens, allowed, _ := s.Take(ctx, "k", rate, window)
+	if !allowed || tokens != rate-1 {
+		t.Fatalf("after long idle: tokens = %v allowed = %v, want %v true", tokens, allowed, rate-1)
+	}
+
+	// key อื่นมีถังของตัวเอง
+	if tokens, _, _ := s.Take(ctx, "other", rate, window); tokens != rate-1 {
+		t.Fatalf("other key: tokens = %v, want %v", tokens, rate-1)
+	}
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	s, clock := newTestRateLimitStore()
+	ctx := context.Background()
+
+	s.Take(ctx, "short", 1, 30*time.Second)
+	s.Take(ctx, "long", 1, time.Hour)
+
+	// ยังไม่ครบนาทีนับจาก sweep ครั้งก่อน — ไม่ลบอะไร แม้ถัง short จะหมดอายุแล้ว
+	clock.advance(59 * time.Second)
+	s.Take(ctx, "long", 1, time.Hour)
+	if _, ok := s.buckets["short"]; !ok {
+		t.Fatal("bucket swept before a minute passed")
+	}
+
+	clock.advance(time.Second)
+	s.Take(ctx, "long", 1, time.Hour)
+	if _, ok := s.buckets["short"]; ok {
+		t.Fatal("expired bucket not swept")
+	}
+	if _, ok := s.buckets["long"]; !ok {
+		t.Fatal("live bucket swept")
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock คือนาฬิกาที่ test เลื่อนเองได้ — ใส่แทน time.Now ของ MemoryRateLimitStore
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestRateLimitStore() (*MemoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryRateLimitStore()
+	s.now = clock.now
+	s.lastSweep = clock.t
+	return s, clock
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, clock := newTestRateLimitStore()
+	r := gin.New()
+	r.GET("/", RateLimit(RateLimitPolicy{Name: "test", Rate: 2, Window: time.Minute, Store: s}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	check := func(w *httptest.ResponseRecorder, code int, remaining, reset, retryAfter string) {
+		t.Helper()
+		if w.Code != code {
+			t.Fatalf("status = %d, want %d", w.Code, code)
+		}
+		for header, want := range map[string]string{
+			"X-RateLimit-Limit":     "2",
+			"X-RateLimit-Remaining": remaining,
+			"X-RateLimit-Reset":     reset,
+			"Retry-After":           retryAfter,
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s = %q, want %q", header, got, want)
+			}
+		}
+	}
+
+	// token ละ 30 วินาที — Reset คือเวลาจนถังเต็ม
+	check(get("10.0.0.1"), http.StatusOK, "1", "30", "")
+	check(get("10.0.0.1"), http.StatusOK, "0", "60", "")
+	check(get("10.0.0.1"), http.StatusTooManyRequests, "0", "60", "30")
+
+	clock.advance(20 * time.Second)
+	check(get("10.0.0.1"), http.StatusTooManyRequests, "0", "40", "10")
+
+	// IP อื่นไม่โดน limit ของ IP นี้
+	check(get("10.0.0.2"), http.StatusOK, "1", "30", "")
+
+	clock.advance(10 * time.Second)
+	check(get("10.0.0.1"), http.StatusOK, "0", "60", "")
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, int, time.Duration) (float64, bool, error) {
+	return 0, false, errors.New("database is down")
+}
+
+func TestRateLimiterAllowsWhenStoreFails(t *testing.T) {
+	result := NewRateLimiter(failingRateLimitStore{}, 5, time.Minute).Allow(context.Background(), "k")
+	if !result.Allowed || result.Remaining != 5 {
+		t.Fatalf("result = %+v, want allowed with full bucket", result)
+	}
+}
+
+func TestKeyByJSONFieldKeepsBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"email":" Student@Example.com "}`
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/", strings.NewReader(body))
+
+	if got, want := KeyByJSONField("email")(c), "email:student@example.com"; got != want {
+		t.Fatalf("key = %q, want %q", got, want)
+	}
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Email != " Student@Example.com " {
+		t.Fatalf("handler could not read the body again: %q, %v", input.Email, err)
+	}
+}
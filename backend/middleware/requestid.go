@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"backend/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader ใช้ทั้งรับ ID จาก proxy / client และส่งกลับใน response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength — ID ที่ยาวกว่านี้หรือมีตัวอักษรแปลกๆ จะถูกแทนด้วย ID ใหม่ (กัน log injection)
+const maxRequestIDLength = 128
+
+// RequestID ใช้ X-Request-ID ที่ส่งมา (ถ้ารูปแบบถูกต้อง) หรือสร้างใหม่
+// เก็บไว้ใน c ("request_id") และ context ของ request ให้ slog แนบทุกบรรทัด แล้วส่งกลับใน header
+// ถ้ามี span (Tracing) จะแนบ request.id ไว้ด้วย — ค้น trace จาก request ID ได้
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", id))
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestLogger เขียน 1 บรรทัดต่อ request (แทน gin.Logger) — ต้องอยู่หลัง RequestID
+// user_id มาจาก AuthMiddleware จึงมีเฉพาะ route ที่ต้อง login
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		// ใช้ context ของ request ล่าสุด — AuthMiddleware เติม user_id ไว้ในนั้น
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
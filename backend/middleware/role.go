@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole อนุญาตเฉพาะ user ที่มี role ตรงกับที่ระบุ — ต้องใช้หลัง AuthMiddleware
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorJSON(c, http.StatusForbidden, "Forbidden")
+		c.Abort()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedPaths — probe / scrape ถี่ๆ ไม่ต้องมี trace
+var untracedPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Tracing สร้าง span ต่อ request (ชื่อ span = route template) และรับ traceparent จาก upstream
+// ต้องอยู่ก่อน RequestID เพื่อให้ request ID ถูกแนบเป็น attribute ของ span
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untracedPaths[r.URL.Path]
+	}))
+}
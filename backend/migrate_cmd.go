@@ -0,0 +1,83 @@
+package main
+
+import (
+	"backend/database"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+  up          apply migration ที่ค้างทั้งหมด
+  down [n]    ย้อน migration ล่าสุด n ตัว (ค่าเริ่มต้น 1)
+  status      แสดงสถานะของทุก migration`
+
+// runMigrateCommand จัดการ `server migrate up|down|status` แล้วคืน exit code
+func runMigrateCommand(db *sql.DB, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := database.MigrateUp(ctx, db)
+		for _, m := range done {
+			fmt.Printf("✅ Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("✅ Schema is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+			steps = n
+		}
+		done, err := database.MigrateDown(ctx, db, steps)
+		for _, m := range done {
+			fmt.Printf("↩️  Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+
+	case "status":
+		states, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			return 1
+		}
+		for _, s := range states {
+			status := "applied " + s.AppliedAtString()
+			switch {
+			case s.Pending:
+				status = "pending"
+			case s.Modified:
+				status += " (MODIFIED — checksum ไม่ตรง)"
+			case s.Missing:
+				status += " (ไม่มีไฟล์ใน build นี้)"
+			}
+			fmt.Printf("%04d  %-30s %s\n", s.Version, s.Name, status)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -4,71 +4,169 @@ import (
 	"backend/controllers"
 	"backend/handlers"
 	"backend/middleware"
-	"database/sql"
+	"backend/store"
+	"backend/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(rg *gin.RouterGroup, db *sql.DB) {
-	// Auth endpoints — ใช้ rate limit เข้มงวดกว่า (10 req/min) ป้องกัน brute force
-	authLimiter := middleware.RateLimitMiddleware(10, time.Minute)
+func AuthRoutes(rg *gin.RouterGroup, st *store.Store) {
+	// Auth endpoints — ใช้ rate limit เข้มงวดกว่า (10 req/min ต่อ IP) ป้องกัน brute force
+	authLimiter := middleware.RateLimit(middleware.RateLimitPolicy{
+		Name: "auth", Rate: 10, Window: time.Minute, Key: middleware.KeyByIP,
+	})
+
+	// endpoint ที่ส่งอีเมล — จำกัดต่ออีเมลด้วย (3 ครั้ง / 15 นาที) กันการยิงอีเมลใส่คนเดียวจากหลาย IP
+	emailLimiter := middleware.RateLimit(middleware.RateLimitPolicy{
+		Name: "auth-email", Rate: 3, Window: 15 * time.Minute, Key: middleware.KeyByJSONField("email"),
+	})
 
 	rg.POST("/register", authLimiter, func(c *gin.Context) {
-		controllers.Register(c, db)
+		controllers.Register(c, st)
 	})
 
 	rg.POST("/login", authLimiter, func(c *gin.Context) {
-		controllers.Login(c, db)
+		controllers.Login(c, st)
 	})
 
-	rg.POST("/forgot-password", authLimiter, func(c *gin.Context) {
-		controllers.ForgotPassword(c, db)
+	rg.POST("/forgot-password", authLimiter, emailLimiter, func(c *gin.Context) {
+		controllers.ForgotPassword(c, st)
 	})
 
 	rg.POST("/verify-otp", authLimiter, func(c *gin.Context) {
-		controllers.VerifyOTP(c, db)
+		controllers.VerifyOTP(c, st)
 	})
 
 	rg.POST("/reset-password", authLimiter, func(c *gin.Context) {
-		controllers.ResetPassword(c, db)
+		controllers.ResetPassword(c, st)
+	})
+
+	rg.POST("/verify-email", authLimiter, func(c *gin.Context) {
+		controllers.VerifyEmail(c, st)
+	})
+
+	rg.POST("/resend-verification", authLimiter, emailLimiter, func(c *gin.Context) {
+		controllers.ResendVerification(c, st)
+	})
+
+	rg.POST("/login/2fa", authLimiter, func(c *gin.Context) {
+		controllers.LoginTwoFactor(c, st)
+	})
+
+	rg.POST("/token/refresh", authLimiter, func(c *gin.Context) {
+		controllers.RefreshToken(c, st)
+	})
+
+	rg.POST("/logout", middleware.AuthMiddleware(st.Sessions), func(c *gin.Context) {
+		controllers.Logout(c, st)
 	})
 }
 
-func UserRoutes(rg *gin.RouterGroup, db *sql.DB) {
+func UserRoutes(rg *gin.RouterGroup, st *store.Store) {
 
 	users := rg.Group("/users")
-	users.Use(middleware.AuthMiddleware())
+	users.Use(middleware.AuthMiddleware(st.Sessions), middleware.RateLimit(middleware.RateLimitPolicy{
+		Name: "users", Rate: 120, Window: time.Minute, Key: middleware.KeyByUserID,
+	}))
+
 	{
-		users.GET("/me", handlers.GetMe(db))
-		users.PUT("/me", handlers.UpdateMe(db))
-		users.DELETE("/me", handlers.DeleteMe(db))
-		users.GET("/me/skills", handlers.GetMySkills(db))
-		users.GET("/me/projects", handlers.GetMyProjects(db))
-		users.GET("/me/projects/:id", handlers.GetProjectByID(db))
-		users.POST("/me/projects", handlers.CreateProject(db))
-		users.PUT("/me/projects/:id", handlers.UpdateProject(db))
-		users.DELETE("/me/projects/:id", handlers.DeleteProject(db))
-		users.PUT("/me/dashboard-visibility", handlers.SetDashboardVisibility(db))
+		users.GET("/me", handlers.GetMe(st.Users))
+		users.PUT("/me", handlers.UpdateMe(st.Users, st.Moderation, st.Audit))
+		users.DELETE("/me", handlers.DeleteMe(st.Users, st.Audit, st.Outbox))
+	}
+
+	// เปลี่ยนรหัสผ่าน / อีเมล / 2FA ต้องใส่รหัสผ่านหรือรหัส 6 หลัก — จำกัดต่อ user กันการเดา
+	accountLimiter := middleware.RateLimit(middleware.RateLimitPolicy{
+		Name: "account", Rate: 10, Window: 15 * time.Minute, Key: middleware.KeyByUserID,
+	})
+
+	users.GET("/me/sessions", handlers.GetMySessions(st.Sessions))
+	users.DELETE("/me/sessions", handlers.RevokeMySessions(st.Sessions, st.Audit))
+	users.DELETE("/me/sessions/:id", handlers.RevokeMySession(st.Sessions, st.Audit))
+
+	// เปลี่ยนรหัสผ่าน / อีเมล (ต้องยืนยันรหัสผ่านเดิม)
+	users.PUT("/me/password", accountLimiter, func(c *gin.Context) {
+		controllers.ChangePassword(c, st)
+	})
+	users.POST("/me/email", accountLimiter, func(c *gin.Context) {
+		controllers.RequestEmailChange(c, st)
+	})
+	users.POST("/me/email/confirm", accountLimiter, func(c *gin.Context) {
+		controllers.ConfirmEmailChange(c, st)
+	})
+
+	users.GET("/me/security-events", handlers.GetMySecurityEvents(st.Audit))
+
+	// Two-factor authentication (TOTP)
+	users.GET("/me/2fa", func(c *gin.Context) {
+		controllers.TwoFactorStatus(c, st)
+	})
+	users.POST("/me/2fa/enroll", accountLimiter, func(c *gin.Context) {
+		controllers.TwoFactorEnroll(c, st)
+	})
+	users.POST("/me/2fa/confirm", accountLimiter, func(c *gin.Context) {
+		controllers.TwoFactorConfirm(c, st)
+	})
+	users.POST("/me/2fa/disable", accountLimiter, func(c *gin.Context) {
+		controllers.TwoFactorDisable(c, st)
+	})
+	users.POST("/me/2fa/recovery-codes", accountLimiter, func(c *gin.Context) {
+		controllers.TwoFactorRegenerateRecoveryCodes(c, st)
+	})
+
+	// Portfolio (skills, projects, publish) มีเฉพาะ student — recruiter/admin ไม่มี portfolio
+	portfolio := users.Group("/me")
+	portfolio.Use(middleware.RequireRole(utils.RoleStudent))
+	{
+		portfolio.GET("/skills", handlers.GetMySkills(st.Users))
+		portfolio.GET("/projects", handlers.GetMyProjects(st.Projects))
+		portfolio.GET("/projects/:id", handlers.GetProjectByID(st.Projects))
+		portfolio.POST("/projects", handlers.CreateProject(st.Projects))
+		portfolio.PUT("/projects/:id", handlers.UpdateProject(st.Projects))
+		portfolio.DELETE("/projects/:id", handlers.DeleteProject(st.Projects))
+		portfolio.PUT("/dashboard-visibility", handlers.SetDashboardVisibility(st.Users, st.Dashboard, st.Audit))
 	}
 }
 
-// ProjectRoutes registers GET /api/projects/:id for fetching a single project by id (auth required).
-func ProjectRoutes(rg *gin.RouterGroup, db *sql.DB) {
+// ProjectRoutes registers GET /api/projects/:id for fetching a single project by id (auth required, students only).
+func ProjectRoutes(rg *gin.RouterGroup, st *store.Store) {
 	projects := rg.Group("/projects")
-	projects.Use(middleware.AuthMiddleware())
+	projects.Use(middleware.AuthMiddleware(st.Sessions), middleware.RequireRole(utils.RoleStudent))
 	{
-		projects.GET("", handlers.GetMyProjects(db))
-		projects.GET("/:id", handlers.GetProjectByID(db))
+		projects.GET("", handlers.GetMyProjects(st.Projects))
+		projects.GET("/:id", handlers.GetProjectByID(st.Projects))
 	}
 }
 
-// DashboardRoutes registers dashboard APIs: list (auth) and public profile (no auth).
-func DashboardRoutes(rg *gin.RouterGroup, db *sql.DB) {
+// DashboardRoutes registers dashboard APIs: list (auth, any role) and public profile (no auth).
+// Only the public endpoints may be cached by browsers and shared proxies (5 minutes).
+func DashboardRoutes(rg *gin.RouterGroup, st *store.Store) {
 	dashboard := rg.Group("/dashboard")
+	publicCache := middleware.PublicCache(5 * time.Minute)
+	{
+		dashboard.GET("/profiles", middleware.AuthMiddleware(st.Sessions), handlers.GetDashboardProfiles(st.Dashboard))
+		dashboard.GET("/public-profiles", publicCache, handlers.GetDashboardProfiles(st.Dashboard))
+		dashboard.GET("/profiles/:id", publicCache, handlers.GetPublicProfile(st.Dashboard))
+	}
+}
+
+// AdminRoutes registers moderation APIs under /api/admin (platform and university admins only).
+func AdminRoutes(rg *gin.RouterGroup, st *store.Store) {
+	admin := rg.Group("/admin")
+	admin.Use(
+		middleware.AuthMiddleware(st.Sessions),
+		middleware.RequireRole(utils.RolePlatformAdmin, utils.RoleUniversityAdmin),
+		middleware.RateLimit(middleware.RateLimitPolicy{Name: "admin", Rate: 60, Window: time.Minute, Key: middleware.KeyByUserID}),
+	)
 	{
-		dashboard.GET("/profiles", middleware.AuthMiddleware(), handlers.GetDashboardProfiles(db))
-		dashboard.GET("/public-profiles", handlers.GetPublicDashboardProfiles(db))
-		dashboard.GET("/profiles/:id", handlers.GetPublicProfile(db))
+		admin.GET("/users", handlers.AdminListUsers(st.Users, st.Moderation))
+		admin.GET("/users/:id", handlers.AdminGetUser(st.Users, st.Moderation))
+		admin.POST("/users/:id/unpublish", handlers.AdminUnpublishProfile(st.Users, st.Moderation))
+		admin.POST("/users/:id/suspend", handlers.AdminSuspendUser(st.Users, st.Moderation))
+		admin.POST("/users/:id/unsuspend", handlers.AdminUnsuspendUser(st.Users, st.Moderation))
+		admin.DELETE("/users/:id/published-projects/:projectId", handlers.AdminDeletePublishedProject(st.Users, st.Moderation))
+		admin.GET("/actions", handlers.AdminListActions(st.Users, st.Moderation))
+		admin.GET("/audit-events", handlers.AdminListAuditEvents(st.Users, st.Audit))
 	}
 }
@@ -0,0 +1,715 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/mail"
+	"backend/middleware"
+	"backend/store"
+	"backend/store/memory"
+	"backend/store/sqlite"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// captureMailer เก็บอีเมลที่ Worker ส่งไว้ให้ test อ่านรหัส OTP
+type captureMailer struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (m *captureMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	st     *store.Store
+	mailer *captureMailer
+	ip     string // IP ของ client — เปลี่ยนได้เมื่อ test ยิง /api/login ฯลฯ เกิน authLimiter (10 ครั้ง/นาที ต่อ IP)
+}
+
+// testStores คือ backend ที่ route tests รันซ้ำทุกตัว — memory กับ SQLite ต้องทำงานเหมือนกัน
+var testStores = []struct {
+	name string
+	open func(t *testing.T) *store.Store
+}{
+	{"memory", func(*testing.T) *store.Store { return memory.New() }},
+	{"sqlite", func(t *testing.T) *store.Store {
+		db, err := sqlite.Open(context.Background(), ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return sqlite.New(db)
+	}},
+}
+
+// forEachStore รัน test บน server ใหม่ของทุก backend ใน testStores (เป็น subtest ตามชื่อ backend)
+func forEachStore(t *testing.T, test func(t *testing.T, s *testServer)) {
+	for _, backend := range testStores {
+		t.Run(backend.name, func(t *testing.T) {
+			test(t, newTestServer(t, backend.open(t)))
+		})
+	}
+}
+
+// newTestServer สร้าง router แบบเดียวกับ main.go บน st — ไม่ต้องมี Postgres
+func newTestServer(t *testing.T, st *store.Store) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	// rate limit แยกถังต่อ test — ไม่งั้น test หลังๆ จะโดน limit ของ test ก่อนหน้า
+	middleware.SetRateLimitStore(middleware.NewMemoryRateLimitStore())
+
+	r := gin.New()
+	r.Use(middleware.NoStore())
+	api := r.Group("/api")
+	AuthRoutes(api, st)
+	UserRoutes(api, st)
+	ProjectRoutes(api, st)
+	DashboardRoutes(api, st)
+	AdminRoutes(api, st)
+
+	return &testServer{t: t, router: r, st: st, mailer: &captureMailer{}, ip: "192.0.2.1"}
+}
+
+// do ส่ง request แล้ว decode JSON ของ response ลง out (ถ้าไม่ใช่ nil)
+func (s *testServer) do(method, path, token string, body interface{}, out interface{}) int {
+	s.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.RemoteAddr = s.ip + ":40000"
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: อ่าน response ไม่ได้: %v\n%s", method, path, err, w.Body.String())
+		}
+	}
+	return w.Code
+}
+
+// mustDo เหมือน do แต่ fail ทันทีถ้า status ไม่ตรง
+func (s *testServer) mustDo(want int, method, path, token string, body interface{}, out interface{}) {
+	s.t.Helper()
+	var raw json.RawMessage
+	code := s.do(method, path, token, body, &raw)
+	if code != want {
+		s.t.Fatalf("%s %s: status = %d, want %d\n%s", method, path, code, want, raw)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+}
+
+// lastOTP ส่งอีเมลที่ค้างใน outbox แล้วคืนรหัส OTP ในฉบับล่าสุดที่ส่งถึง to
+func (s *testServer) lastOTP(to string) string {
+	s.t.Helper()
+	if err := mail.NewWorker(s.st.Outbox, s.mailer).Drain(context.Background()); err != nil {
+		s.t.Fatal(err)
+	}
+
+	otp := regexp.MustCompile(fmt.Sprintf(`\b\d{%d}\b`, utils.OTPLength()))
+	s.mailer.mu.Lock()
+	defer s.mailer.mu.Unlock()
+	for i := len(s.mailer.sent) - 1; i >= 0; i-- {
+		if m := s.mailer.sent[i]; m.To == to {
+			if code := otp.FindString(m.Text); code != "" {
+				return code
+			}
+		}
+	}
+	s.t.Fatalf("ไม่มีอีเมลที่มี OTP ส่งถึง %s", to)
+	return ""
+}
+
+// sentTo ส่งอีเมลที่ค้างใน outbox แล้วคืนชื่อ template ของทุกฉบับที่ส่งถึง to ตามลำดับ
+func (s *testServer) sentTo(to string) []string {
+	s.t.Helper()
+	if err := mail.NewWorker(s.st.Outbox, s.mailer).Drain(context.Background()); err != nil {
+		s.t.Fatal(err)
+	}
+
+	s.mailer.mu.Lock()
+	defer s.mailer.mu.Unlock()
+	var templates []string
+	for _, m := range s.mailer.sent {
+		if m.To == to {
+			templates = append(templates, m.Template)
+		}
+	}
+	return templates
+}
+
+// registerStudent สมัคร ยืนยันอีเมล และ login — คืน user_id กับ access token
+func (s *testServer) registerStudent(email, university string) (int, string) {
+	s.t.Helper()
+	const password = "Passw0rd!123"
+
+	var reg struct {
+		Data struct {
+			UserID int `json:"user_id"`
+		} `json:"data"`
+	}
+	s.mustDo(http.StatusCreated, "POST", "/api/register", "", gin.H{
+		"email": email, "password": password, "user_name": "Student", "university": university,
+	}, &reg)
+
+	s.mustDo(http.StatusOK, "POST", "/api/verify-email", "", gin.H{"email": email, "otp": s.lastOTP(email)}, nil)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	s.mustDo(http.StatusOK, "POST", "/api/login", "", gin.H{"email": email, "password": password}, &login)
+	if login.Token == "" {
+		s.t.Fatal("login ไม่ได้ token")
+	}
+	return reg.Data.UserID, login.Token
+}
+
+// createAdmin สร้างบัญชี admin ตรงใน store (เหมือน `server user create --role`) แล้ว login — คืน access token
+func (s *testServer) createAdmin(email, role, university string) string {
+	s.t.Helper()
+	const password = "Adm1nPassw0rd!"
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if _, err := s.st.Users.Create(context.Background(), store.NewUser{
+		Email: email, PasswordHash: string(hash), UserName: "Admin", University: university, Role: role, Locale: "th",
+	}); err != nil {
+		s.t.Fatal(err)
+	}
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	s.mustDo(http.StatusOK, "POST", "/api/login", "", gin.H{"email": email, "password": password}, &login)
+	return login.Token
+}
+
+// totpAt คำนวณรหัส TOTP ของ step ที่ระบุแบบเดียวกับแอป Authenticator (RFC 6238, SHA-1, 6 หลัก)
+func (s *testServer) totpAt(secret string, step int64) string {
+	s.t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000)
+}
+
+// enableTwoFactor enroll + confirm 2FA ด้วยรหัสของ step ปัจจุบัน — คืน secret, step ที่ใช้ confirm ไปแล้ว และ recovery codes
+func (s *testServer) enableTwoFactor(token string) (string, int64, []string) {
+	s.t.Helper()
+	var enroll struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	s.mustDo(http.StatusOK, "POST", "/api/users/me/2fa/enroll", token, nil, &enroll)
+
+	var confirm struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	step := time.Now().Unix() / 30
+	s.mustDo(http.StatusOK, "POST", "/api/users/me/2fa/confirm", token, gin.H{"code": s.totpAt(enroll.Data.Secret, step)}, &confirm)
+	return enroll.Data.Secret, step, confirm.Data.RecoveryCodes
+}
+
+// loginResult คือ data ของ /login และ /login/2fa
+type loginResult struct {
+	Data struct {
+		Token             string `json:"token"`
+		RefreshToken      string `json:"refresh_token"`
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+	} `json:"data"`
+}
+
+// login เรียก /login ด้วยรหัสผ่านที่ถูก — ถ้าเปิด 2FA จะได้ challenge แทน token
+func (s *testServer) login(email, password string) loginResult {
+	s.t.Helper()
+	var out loginResult
+	s.mustDo(http.StatusOK, "POST", "/api/login", "", gin.H{"email": email, "password": password}, &out)
+	return out
+}
+
+func TestRegisterPublishDashboard(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		userID, token := s.registerStudent("student@example.com", "KU")
+
+		var project struct {
+			ID string `json:"id"`
+		}
+		s.mustDo(http.StatusOK, "POST", "/api/users/me/projects", token, gin.H{
+			"title": "PortHub", "desc": "portfolio platform",
+		}, &project)
+
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me/dashboard-visibility", token, gin.H{"show_on_dashboard": true}, nil)
+
+		var list []struct {
+			UserID     int    `json:"user_id"`
+			University string `json:"university"`
+		}
+		s.mustDo(http.StatusOK, "GET", "/api/dashboard/public-profiles", "", nil, &list)
+		if len(list) != 1 || list[0].UserID != userID || list[0].University != "KU" {
+			t.Fatalf("public-profiles = %+v, want user %d", list, userID)
+		}
+
+		var profile struct {
+			Email    string `json:"email"`
+			Projects []struct {
+				ID    string `json:"id"`
+				Title string `json:"title"`
+			} `json:"projects"`
+		}
+		s.mustDo(http.StatusOK, "GET", fmt.Sprintf("/api/dashboard/profiles/%d", userID), "", nil, &profile)
+		if profile.Email != "student@example.com" || len(profile.Projects) != 1 || profile.Projects[0].ID != project.ID {
+			t.Fatalf("profile = %+v, want project %s", profile, project.ID)
+		}
+
+		// การ publish ถูกบันทึกเป็น security event ของเจ้าของบัญชี
+		var events struct {
+			Events []struct {
+				Action string `json:"action"`
+			} `json:"events"`
+		}
+		s.mustDo(http.StatusOK, "GET", "/api/users/me/security-events", token, nil, &events)
+		if len(events.Events) == 0 || events.Events[0].Action != utils.AuditDashboardPublished {
+			t.Fatalf("security-events = %+v, want %s first", events.Events, utils.AuditDashboardPublished)
+		}
+	})
+}
+
+func TestCacheControl(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		userID, token := s.registerStudent("student@example.com", "KU")
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me/dashboard-visibility", token, gin.H{"show_on_dashboard": true}, nil)
+
+		for _, tc := range []struct {
+			path, token, want string
+		}{
+			{"/api/users/me", token, "private, no-store"},
+			{"/api/users/me/sessions", token, "private, no-store"},
+			{"/api/dashboard/profiles", token, "private, no-store"},
+			{"/api/dashboard/public-profiles", "", "public, max-age=300"},
+			{fmt.Sprintf("/api/dashboard/profiles/%d", userID), "", "public, max-age=300"},
+		} {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s: status = %d\n%s", tc.path, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); got != tc.want {
+				t.Errorf("GET %s: Cache-Control = %q, want %q", tc.path, got, tc.want)
+			}
+		}
+	})
+}
+
+func TestUnverifiedCannotPublish(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+
+		s.mustDo(http.StatusCreated, "POST", "/api/register", "", gin.H{
+			"email": "new@example.com", "password": "Passw0rd!123", "user_name": "New",
+		}, nil)
+		var login struct {
+			Token string `json:"token"`
+		}
+		s.mustDo(http.StatusOK, "POST", "/api/login", "", gin.H{"email": "new@example.com", "password": "Passw0rd!123"}, &login)
+
+		if code := s.do("PUT", "/api/users/me/dashboard-visibility", login.Token, gin.H{"show_on_dashboard": true}, nil); code != http.StatusForbidden {
+			t.Fatalf("publish before verify: status = %d, want %d", code, http.StatusForbidden)
+		}
+	})
+}
+
+func TestUniversityAdminSeesOnlyOwnModerationActions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		kuID, _ := s.registerStudent("ku@example.com", "KU")
+		cuID, _ := s.registerStudent("cu@example.com", "CU")
+
+		platform := s.createAdmin("platform@example.com", utils.RolePlatformAdmin, "")
+		for _, id := range []int{kuID, cuID} {
+			s.mustDo(http.StatusOK, "POST", fmt.Sprintf("/api/admin/users/%d/suspend", id), platform, gin.H{"reason": "spam"}, nil)
+		}
+
+		var actions []struct {
+			TargetUserID int `json:"target_user_id"`
+		}
+		s.mustDo(http.StatusOK, "GET", "/api/admin/actions", platform, nil, &actions)
+		if len(actions) != 2 {
+			t.Fatalf("platform admin actions = %+v, want 2", actions)
+		}
+
+		ku := s.createAdmin("admin@ku.example.com", utils.RoleUniversityAdmin, "ku")
+		s.mustDo(http.StatusOK, "GET", "/api/admin/actions", ku, nil, &actions)
+		if len(actions) != 1 || actions[0].TargetUserID != kuID {
+			t.Fatalf("university admin actions = %+v, want only user %d", actions, kuID)
+		}
+	})
+}
+
+func TestModeratedProjectStaysHiddenAfterRepublish(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		userID, token := s.registerStudent("student@example.com", "KU")
+
+		var spam, keep struct {
+			ID string `json:"id"`
+		}
+		s.mustDo(http.StatusOK, "POST", "/api/users/me/projects", token, gin.H{"title": "Spam", "desc": "buy now"}, &spam)
+		s.mustDo(http.StatusOK, "POST", "/api/users/me/projects", token, gin.H{"title": "PortHub", "desc": "portfolio platform"}, &keep)
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me/dashboard-visibility", token, gin.H{"show_on_dashboard": true}, nil)
+
+		admin := s.createAdmin("platform@example.com", utils.RolePlatformAdmin, "")
+		s.mustDo(http.StatusOK, "DELETE", fmt.Sprintf("/api/admin/users/%d/published-projects/%s", userID, spam.ID), admin, gin.H{"reason": "spam"}, nil)
+
+		// เจ้าของ unpublish แล้ว publish ใหม่ — โปรเจคที่ถูกลบต้องไม่กลับขึ้น Dashboard
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me/dashboard-visibility", token, gin.H{"show_on_dashboard": false}, nil)
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me/dashboard-visibility", token, gin.H{"show_on_dashboard": true}, nil)
+
+		var profile struct {
+			Projects []struct {
+				ID string `json:"id"`
+			} `json:"projects"`
+		}
+		s.mustDo(http.StatusOK, "GET", fmt.Sprintf("/api/dashboard/profiles/%d", userID), "", nil, &profile)
+		if len(profile.Projects) != 1 || profile.Projects[0].ID != keep.ID {
+			t.Fatalf("projects = %+v, want only %s", profile.Projects, keep.ID)
+		}
+	})
+}
+
+func TestUniversityChangeCannotEscapeAdminScope(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		kuID, kuToken := s.registerStudent("ku@example.com", "KU")
+		cuID, _ := s.registerStudent("cu@example.com", "CU")
+		ku := s.createAdmin("admin@ku.example.com", utils.RoleUniversityAdmin, "KU")
+
+		// admin ย้ายตัวเองไปมหาวิทยาลัยอื่นไม่ได้ — ยัง moderate user ของ CU ไม่ได้เหมือนเดิม
+		if code := s.do("PUT", "/api/users/me", ku, gin.H{"user_name": "Admin", "university": "CU"}, nil); code != http.StatusForbidden {
+			t.Fatalf("admin changes university: status = %d, want %d", code, http.StatusForbidden)
+		}
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me", ku, gin.H{"user_name": "KU Admin", "university": "ku"}, nil)
+		// user นอก scope ได้ 404 (ไม่บอกว่ามี user นี้อยู่)
+		if code := s.do("POST", fmt.Sprintf("/api/admin/users/%d/suspend", cuID), ku, gin.H{"reason": "spam"}, nil); code != http.StatusNotFound {
+			t.Fatalf("suspend CU user after university change: status = %d, want %d", code, http.StatusNotFound)
+		}
+
+		// user ที่ถูก moderate แล้วย้ายออกจาก KU ไม่ได้ — admin ของ KU ยังระงับได้
+		s.mustDo(http.StatusOK, "POST", fmt.Sprintf("/api/admin/users/%d/unpublish", kuID), ku, gin.H{"reason": "spam"}, nil)
+		if code := s.do("PUT", "/api/users/me", kuToken, gin.H{"user_name": "Student", "university": "CU"}, nil); code != http.StatusForbidden {
+			t.Fatalf("moderated user changes university: status = %d, want %d", code, http.StatusForbidden)
+		}
+		s.mustDo(http.StatusOK, "POST", fmt.Sprintf("/api/admin/users/%d/suspend", kuID), ku, gin.H{"reason": "spam"}, nil)
+
+		// user ที่ไม่เคยถูก moderate ย้ายได้ตามปกติ
+		_, otherToken := s.registerStudent("other@example.com", "KU")
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me", otherToken, gin.H{"user_name": "Student", "university": "CU"}, nil)
+	})
+}
+
+func TestOTPCancelledAfterMaxAttempts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		s.mustDo(http.StatusCreated, "POST", "/api/register", "", gin.H{
+			"email": email, "password": "Passw0rd!123", "user_name": "Student",
+		}, nil)
+		otp := s.lastOTP(email)
+
+		wrong := "0000"
+		if otp == wrong {
+			wrong = "1111"
+		}
+		for i := 0; i < utils.OTPMaxAttempts(); i++ {
+			if code := s.do("POST", "/api/verify-email", "", gin.H{"email": email, "otp": wrong}, nil); code != http.StatusUnauthorized {
+				t.Fatalf("wrong otp #%d: status = %d, want %d", i+1, code, http.StatusUnauthorized)
+			}
+		}
+
+		// ผิดครบแล้วรหัสถูกยกเลิก — รหัสที่ถูกก็ใช้ไม่ได้ ต้องขอใหม่
+		if code := s.do("POST", "/api/verify-email", "", gin.H{"email": email, "otp": otp}, nil); code != http.StatusUnauthorized {
+			t.Fatalf("correct otp after %d failures: status = %d, want %d", utils.OTPMaxAttempts(), code, http.StatusUnauthorized)
+		}
+
+		s.mustDo(http.StatusOK, "POST", "/api/resend-verification", "", gin.H{"email": email}, nil)
+		s.mustDo(http.StatusOK, "POST", "/api/verify-email", "", gin.H{"email": email, "otp": s.lastOTP(email)}, nil)
+	})
+}
+
+func TestTwoFactorCodeCannotBeReused(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		userID, token := s.registerStudent(email, "KU")
+		secret, step, _ := s.enableTwoFactor(token)
+
+		login := func() string {
+			out := s.login(email, "Passw0rd!123")
+			if !out.Data.TwoFactorRequired || out.Data.ChallengeToken == "" {
+				t.Fatal("login with 2FA enabled did not return a challenge")
+			}
+			return out.Data.ChallengeToken
+		}
+
+		// รหัสที่ใช้ confirm ไปแล้วใช้ login ไม่ได้
+		challenge := login()
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{
+			"challenge_token": challenge, "code": s.totpAt(secret, step),
+		}, nil)
+
+		// รหัสของ step ถัดไปยังอยู่ใน skew จึงใช้ได้ครั้งเดียว
+		next := s.totpAt(secret, step+1)
+		var ok struct {
+			Token string `json:"token"`
+		}
+		s.mustDo(http.StatusOK, "POST", "/api/login/2fa", "", gin.H{"challenge_token": challenge, "code": next}, &ok)
+		if ok.Token == "" {
+			t.Fatal("login/2fa did not return a token")
+		}
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": login(), "code": next}, nil)
+
+		// store ต้องไม่ยอมบันทึก step เดิมซ้ำ แม้สอง request จะผ่าน ValidateTOTP พร้อมกัน
+		advanced, err := s.st.TwoFactor.AdvanceStep(context.Background(), userID, step+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if advanced {
+			t.Fatal("AdvanceStep accepted a step that was already used")
+		}
+	})
+}
+
+func TestChangePasswordClearsLockout(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		_, token := s.registerStudent(email, "KU")
+
+		for i := 0; i < utils.LoginMaxFailures(); i++ {
+			s.mustDo(http.StatusUnauthorized, "POST", "/api/login", "", gin.H{"email": email, "password": "wrong-password"}, nil)
+		}
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login", "", gin.H{"email": email, "password": "Passw0rd!123"}, nil)
+
+		// เจ้าของบัญชีที่ยัง login อยู่เปลี่ยนรหัสผ่านได้ และการล็อกต้องหายไปพร้อมกัน
+		s.mustDo(http.StatusOK, "PUT", "/api/users/me/password", token, gin.H{
+			"current_password": "Passw0rd!123", "new_password": "N3wPassw0rd!",
+		}, nil)
+		u, err := s.st.Users.GetByEmail(context.Background(), email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.LockedFor > 0 {
+			t.Fatalf("account still locked for %s after password change", u.LockedFor)
+		}
+		s.mustDo(http.StatusOK, "POST", "/api/login", "", gin.H{"email": email, "password": "N3wPassw0rd!"}, nil)
+	})
+}
+
+func TestEmailChangeToTakenEmailDoesNotLeak(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		_, token := s.registerStudent("student@example.com", "KU")
+		s.registerStudent("taken@example.com", "KU")
+		before := len(s.sentTo("taken@example.com"))
+
+		// ตอบเหมือนกันทั้งอีเมลที่ว่างและอีเมลที่มีบัญชีแล้ว
+		var taken, free json.RawMessage
+		s.mustDo(http.StatusOK, "POST", "/api/users/me/email", token, gin.H{"new_email": "taken@example.com", "password": "Passw0rd!123"}, &taken)
+		s.mustDo(http.StatusOK, "POST", "/api/users/me/email", token, gin.H{"new_email": "nobody@example.com", "password": "Passw0rd!123"}, &free)
+		if got, want := strings.ReplaceAll(string(taken), "taken@", "nobody@"), string(free); got != want {
+			t.Fatalf("responses differ:\n%s\n%s", taken, free)
+		}
+
+		// เจ้าของอีเมลได้อีเมลแจ้ง ไม่ใช่รหัสยืนยัน
+		sent := s.sentTo("taken@example.com")[before:]
+		if len(sent) != 1 || sent[0] != utils.EmailChangeTaken {
+			t.Fatalf("mail to taken address = %v, want [%s]", sent, utils.EmailChangeTaken)
+		}
+	})
+}
+
+func TestForgotPasswordDoesNotRevealUnknownEmail(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		s.registerStudent("student@example.com", "KU")
+
+		var known, unknown json.RawMessage
+		s.mustDo(http.StatusOK, "POST", "/api/forgot-password", "", gin.H{"email": "student@example.com"}, &known)
+		s.mustDo(http.StatusOK, "POST", "/api/forgot-password", "", gin.H{"email": "nobody@example.com"}, &unknown)
+		if string(known) != string(unknown) {
+			t.Fatalf("responses differ:\n%s\n%s", known, unknown)
+		}
+		if sent := s.sentTo("nobody@example.com"); len(sent) != 0 {
+			t.Fatalf("mail sent to unknown address: %v", sent)
+		}
+
+		// อีเมลที่มีบัญชีได้ OTP จริง
+		s.mustDo(http.StatusOK, "POST", "/api/verify-otp", "", gin.H{"email": "student@example.com", "otp": s.lastOTP("student@example.com")}, nil)
+	})
+}
+
+func TestLoginRefreshLogout(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		s.registerStudent(email, "KU")
+
+		first := s.login(email, "Passw0rd!123")
+		if first.Data.Token == "" || first.Data.RefreshToken == "" {
+			t.Fatal("login did not return both tokens")
+		}
+		s.mustDo(http.StatusOK, "GET", "/api/users/me", first.Data.Token, nil, nil)
+
+		// refresh หมุน token — refresh token เดิมใช้ซ้ำไม่ได้
+		var refreshed loginResult
+		s.mustDo(http.StatusOK, "POST", "/api/token/refresh", "", gin.H{"refresh_token": first.Data.RefreshToken}, &refreshed)
+		if refreshed.Data.RefreshToken == "" || refreshed.Data.RefreshToken == first.Data.RefreshToken {
+			t.Fatal("refresh did not rotate the refresh token")
+		}
+		s.mustDo(http.StatusOK, "GET", "/api/users/me", refreshed.Data.Token, nil, nil)
+
+		// นำ refresh token เก่ากลับมาใช้ = อาจถูกขโมย — session ทั้งก้อนถูก revoke
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/token/refresh", "", gin.H{"refresh_token": first.Data.RefreshToken}, nil)
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/token/refresh", "", gin.H{"refresh_token": refreshed.Data.RefreshToken}, nil)
+		s.mustDo(http.StatusUnauthorized, "GET", "/api/users/me", refreshed.Data.Token, nil, nil)
+
+		// logout revoke เฉพาะ session นี้
+		second := s.login(email, "Passw0rd!123")
+		third := s.login(email, "Passw0rd!123")
+		s.mustDo(http.StatusOK, "POST", "/api/logout", second.Data.Token, nil, nil)
+		s.mustDo(http.StatusUnauthorized, "GET", "/api/users/me", second.Data.Token, nil, nil)
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/token/refresh", "", gin.H{"refresh_token": second.Data.RefreshToken}, nil)
+		s.mustDo(http.StatusOK, "GET", "/api/users/me", third.Data.Token, nil, nil)
+	})
+}
+
+func TestLoginLockout(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		s.registerStudent(email, "KU")
+
+		var unknown json.RawMessage
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login", "", gin.H{"email": "nobody@example.com", "password": "Passw0rd!123"}, &unknown)
+
+		for i := 0; i < utils.LoginMaxFailures(); i++ {
+			var wrong json.RawMessage
+			s.mustDo(http.StatusUnauthorized, "POST", "/api/login", "", gin.H{"email": email, "password": "wrong-password"}, &wrong)
+			// ไม่บอกว่าอีเมลนี้มีบัญชี
+			if string(wrong) != string(unknown) {
+				t.Fatalf("wrong password response differs from unknown email:\n%s\n%s", wrong, unknown)
+			}
+		}
+
+		// ล็อกแล้ว — รหัสผ่านที่ถูกก็ยังไม่ได้ และตอบเหมือนรหัสผ่านผิด
+		var locked json.RawMessage
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login", "", gin.H{"email": email, "password": "Passw0rd!123"}, &locked)
+		if string(locked) != string(unknown) {
+			t.Fatalf("locked response differs from unknown email:\n%s\n%s", locked, unknown)
+		}
+
+		// เจ้าของบัญชีรู้จากอีเมลแจ้งล็อก
+		sent := s.sentTo(email)
+		if len(sent) == 0 || sent[len(sent)-1] != utils.EmailAccountLocked {
+			t.Fatalf("mail to %s = %v, want %s last", email, sent, utils.EmailAccountLocked)
+		}
+	})
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		_, token := s.registerStudent(email, "KU")
+		_, _, recovery := s.enableTwoFactor(token)
+		if len(recovery) == 0 {
+			t.Fatal("confirm did not return recovery codes")
+		}
+
+		// เปิด 2FA แล้ว /login ไม่ออก token
+		first := s.login(email, "Passw0rd!123")
+		if !first.Data.TwoFactorRequired || first.Data.ChallengeToken == "" || first.Data.Token != "" {
+			t.Fatalf("login with 2FA = %+v, want a challenge and no token", first.Data)
+		}
+		s.mustDo(http.StatusBadRequest, "POST", "/api/login/2fa", "", gin.H{"code": "000000"}, nil)
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": "bogus", "recovery_code": recovery[0]}, nil)
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": first.Data.ChallengeToken, "recovery_code": "not-a-code"}, nil)
+
+		// recovery code ใช้แทน TOTP ได้ครั้งเดียว และ challenge ใช้ได้ครั้งเดียว
+		var ok loginResult
+		s.mustDo(http.StatusOK, "POST", "/api/login/2fa", "", gin.H{"challenge_token": first.Data.ChallengeToken, "recovery_code": recovery[0]}, &ok)
+		if ok.Data.Token == "" || ok.Data.RefreshToken == "" {
+			t.Fatal("login/2fa did not return both tokens")
+		}
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": first.Data.ChallengeToken, "recovery_code": recovery[1]}, nil)
+
+		s.ip = "192.0.2.2"
+		second := s.login(email, "Passw0rd!123")
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": second.Data.ChallengeToken, "recovery_code": recovery[0]}, nil)
+
+		// ปิด 2FA ต้องใช้ทั้งรหัสผ่านและรหัส 2FA — แล้ว /login ออก token ได้ทันที
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/users/me/2fa/disable", ok.Data.Token, gin.H{"password": "wrong-password", "recovery_code": recovery[1]}, nil)
+		s.mustDo(http.StatusOK, "POST", "/api/users/me/2fa/disable", ok.Data.Token, gin.H{"password": "Passw0rd!123", "recovery_code": recovery[1]}, nil)
+		if third := s.login(email, "Passw0rd!123"); third.Data.TwoFactorRequired || third.Data.Token == "" {
+			t.Fatalf("login after disabling 2FA = %+v, want a token", third.Data)
+		}
+	})
+}
+
+func TestTwoFactorChallengeAttemptLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *testServer) {
+		const email = "student@example.com"
+		_, token := s.registerStudent(email, "KU")
+		_, _, recovery := s.enableTwoFactor(token)
+
+		challenge := s.login(email, "Passw0rd!123").Data.ChallengeToken
+		for i := 0; i < 5; i++ {
+			s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": challenge, "code": "000000"}, nil)
+		}
+		// ผิดครบแล้ว challenge ใช้ไม่ได้ แม้จะส่ง recovery code ที่ถูก — ต้อง login ใหม่
+		s.mustDo(http.StatusUnauthorized, "POST", "/api/login/2fa", "", gin.H{"challenge_token": challenge, "recovery_code": recovery[0]}, nil)
+		s.ip = "192.0.2.2"
+		fresh := s.login(email, "Passw0rd!123").Data.ChallengeToken
+		s.mustDo(http.StatusOK, "POST", "/api/login/2fa", "", gin.H{"challenge_token": fresh, "recovery_code": recovery[0]}, nil)
+	})
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"backend/store"
+)
+
+type AuditStore struct {
+	d *data
+}
+
+func (s *AuditStore) Record(_ context.Context, e store.AuditEvent) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.lastEventID++
+	e.ID = s.d.lastEventID
+	e.CreatedAt = s.d.now()
+	if e.Metadata == "" {
+		e.Metadata = "{}"
+	}
+	s.d.events = append(s.d.events, e)
+	return nil
+}
+
+func (s *AuditStore) List(_ context.Context, f store.AuditFilter, limit, offset int) ([]store.AuditEvent, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	// เหตุการณ์ใหม่อยู่ท้าย slice — ไล่จากท้ายได้ลำดับ created_at DESC, event_id DESC
+	matched := []store.AuditEvent{}
+	for i := len(s.d.events) - 1; i >= 0; i-- {
+		e := s.d.events[i]
+		if s.matches(e, f) {
+			matched = append(matched, e)
+		}
+	}
+	start, end := pageBounds(len(matched), limit, offset)
+	return matched[start:end], len(matched), nil
+}
+
+// matches — ผู้เรียกต้องถือ mu
+func (s *AuditStore) matches(e store.AuditEvent, f store.AuditFilter) bool {
+	if f.University != "" {
+		u, ok := s.d.users[e.UserID]
+		if !ok || !strings.EqualFold(u.University, f.University) {
+			return false
+		}
+	}
+	switch {
+	case f.UserID != 0 && e.UserID != f.UserID,
+		f.ActorID != 0 && e.ActorID != f.ActorID,
+		f.Action != "" && e.Action != f.Action,
+		f.ActionPrefix != "" && !strings.HasPrefix(e.Action, f.ActionPrefix),
+		f.IPAddress != "" && e.IPAddress != f.IPAddress,
+		!f.Since.IsZero() && e.CreatedAt.Before(f.Since),
+		!f.Until.IsZero() && !e.CreatedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// pageBounds คืนช่วง [start:end] ของ list ยาว n ตาม LIMIT / OFFSET
+func pageBounds(n, limit, offset int) (int, int) {
+	start := min(offset, n)
+	return start, min(start+limit, n)
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"backend/store"
+)
+
+type CodeStore struct {
+	d *data
+}
+
+func (s *CodeStore) Issue(_ context.Context, userID int, codeType, codeHash string, expiresAt time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.issue(userID, codeType, codeHash, expiresAt)
+	return nil
+}
+
+func (s *CodeStore) IssueEmailChange(_ context.Context, userID int, newEmail, codeHash string, expiresAt time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.issue(userID, store.CodeEmailChange, codeHash, expiresAt).newEmail = newEmail
+	return nil
+}
+
+// issue แทนที่รหัสเดิมของ user ในประเภทเดียวกัน — ผู้เรียกต้องถือ mu
+func (s *CodeStore) issue(userID int, codeType, codeHash string, expiresAt time.Time) *codeRow {
+	for id, c := range s.d.codes {
+		if c.UserID == userID && c.Type == codeType {
+			delete(s.d.codes, id)
+		}
+	}
+
+	s.d.lastCodeID++
+	c := &codeRow{
+		VerificationCode: store.VerificationCode{
+			ID:     s.d.lastCodeID,
+			UserID: userID,
+			Type:   codeType,
+			Hash:   codeHash,
+		},
+		expiresAt: expiresAt,
+		createdAt: s.d.now(),
+	}
+	s.d.codes[c.ID] = c
+	return c
+}
+
+func (s *CodeStore) Reserve(_ context.Context, email, codeType string, maxAttempts int) (*store.VerificationCode, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u := s.d.userByEmail(strings.TrimSpace(email))
+	if u == nil {
+		return nil, store.ErrNotFound
+	}
+
+	// รหัสล่าสุดที่ยังใช้ได้ (Issue ลบรหัสเก่าประเภทเดียวกันทิ้งแล้ว จึงมีไม่เกิน 1 ตัว)
+	var latest *codeRow
+	now := s.d.now()
+	for _, c := range s.d.codes {
+		if c.UserID != u.ID || c.Type != codeType || c.used || !c.expiresAt.After(now) {
+			continue
+		}
+		if latest == nil || c.createdAt.After(latest.createdAt) || (c.createdAt.Equal(latest.createdAt) && c.ID > latest.ID) {
+			latest = c
+		}
+	}
+	if latest == nil || latest.Attempts >= maxAttempts {
+		return nil, store.ErrNotFound
+	}
+
+	latest.Attempts++
+	out := latest.VerificationCode
+	return &out, nil
+}
+
+func (s *CodeStore) MarkUsed(_ context.Context, codeID int) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, ok := s.d.codes[codeID]
+	if !ok || c.used {
+		return false, nil
+	}
+	c.used = true
+	return true, nil
+}
+
+func (s *CodeStore) CreateResetToken(_ context.Context, codeID, userID int, tokenHash string, expiresAt time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, ok := s.d.codes[codeID]
+	if !ok || c.used {
+		return store.ErrNotFound
+	}
+	c.used = true
+
+	// ใช้ได้เฉพาะ token ล่าสุดของ user
+	for hash, t := range s.d.resetTokens {
+		if t.userID == userID {
+			delete(s.d.resetTokens, hash)
+		}
+	}
+	s.d.resetTokens[tokenHash] = &resetTokenRow{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *CodeStore) PurgeExpired(_ context.Context) (int, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := s.d.now()
+	codes, tokens := 0, 0
+	for id, c := range s.d.codes {
+		if c.used || !c.expiresAt.After(now) {
+			delete(s.d.codes, id)
+			codes++
+		}
+	}
+	for hash, t := range s.d.resetTokens {
+		if t.used || !t.expiresAt.After(now) {
+			delete(s.d.resetTokens, hash)
+			tokens++
+		}
+	}
+	return codes, tokens, nil
+}
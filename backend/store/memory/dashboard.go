@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"backend/store"
+)
+
+type DashboardStore struct {
+	d *data
+}
+
+func (s *DashboardStore) List(_ context.Context, excludeUserID, limit int) ([]store.ProfileSummary, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	profiles := make([]*store.PublishedProfile, 0, len(s.d.published))
+	for _, p := range s.d.published {
+		if p.UserID != excludeUserID {
+			profiles = append(profiles, p)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if !profiles[i].UpdatedAt.Equal(profiles[j].UpdatedAt) {
+			return profiles[i].UpdatedAt.After(profiles[j].UpdatedAt)
+		}
+		return profiles[i].UserID > profiles[j].UserID
+	})
+	if len(profiles) > limit {
+		profiles = profiles[:limit]
+	}
+
+	list := make([]store.ProfileSummary, 0, len(profiles))
+	for _, p := range profiles {
+		list = append(list, p.ProfileSummary)
+	}
+	return list, nil
+}
+
+func (s *DashboardStore) Get(_ context.Context, userID int) (*store.PublishedProfile, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	p, ok := s.d.published[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	out := *p
+	out.Skills = copyList(p.Skills)
+	out.Projects = make([]store.PublishedProject, 0, len(p.Projects))
+	for _, proj := range p.Projects {
+		proj.Images = copyList(proj.Images)
+		out.Projects = append(out.Projects, proj)
+	}
+	return &out, nil
+}
+
+func (s *DashboardStore) Publish(_ context.Context, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := s.d.now()
+
+	snapshot := &store.PublishedProfile{
+		ProfileSummary: store.ProfileSummary{
+			UserID:          u.ID,
+			UserName:        u.UserName,
+			ProfileImageURL: u.ProfileImageURL,
+			JobInterest:     u.JobInterest,
+			University:      u.University,
+			Faculty:         u.Faculty,
+			Major:           u.Major,
+			GPA:             u.GPA,
+		},
+		Email:     u.Email,
+		Phone:     u.Phone,
+		Skills:    copyList(u.Skills),
+		Projects:  []store.PublishedProject{},
+		UpdatedAt: now,
+	}
+
+	projects := []store.Project{}
+	for _, p := range s.d.projects {
+		if p.UserID == userID && !s.d.moderated[p.ID] {
+			projects = append(projects, copyProject(p))
+		}
+	}
+	sortProjects(projects)
+	for _, p := range projects {
+		snapshot.Projects = append(snapshot.Projects, store.PublishedProject{
+			ProjectID:   p.ID,
+			Title:       p.Title,
+			Desc:        p.Desc,
+			Images:      p.Images,
+			PublishedAt: now,
+		})
+	}
+
+	s.d.published[userID] = snapshot
+	u.ShowOnDashboard = true
+	return nil
+}
+
+func (s *DashboardStore) Unpublish(_ context.Context, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.unpublish(userID)
+	return nil
+}
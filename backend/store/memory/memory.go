@@ -0,0 +1,160 @@
+// Package memory คือ implementation ของ store ที่เก็บทุกอย่างใน map ของ process (STORAGE=memory)
+// ใช้สำหรับ demo บนเครื่องและ handler test ด้วย httptest — ข้อมูลหายเมื่อ restart
+package memory
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"backend/store"
+)
+
+// New สร้าง store ว่างๆ — store ทุกตัวใช้ข้อมูลชุดเดียวกัน (ลบ user แล้วโปรเจค / session หายตาม)
+func New() *store.Store {
+	d := &data{
+		users:       map[int]*userRow{},
+		skillNames:  map[string]string{},
+		projects:    map[int]*store.Project{},
+		published:   map[int]*store.PublishedProfile{},
+		codes:       map[int]*codeRow{},
+		resetTokens: map[string]*resetTokenRow{},
+		sessions:    map[string]*sessionRow{},
+		totp:        map[int]*store.TOTP{},
+		challenges:  map[string]*challengeRow{},
+		moderated:   map[int]bool{},
+		outbox:      map[int64]*outboxRow{},
+		now:         time.Now,
+	}
+	return &store.Store{
+		Users:      &UserStore{d},
+		Projects:   &ProjectStore{d},
+		Dashboard:  &DashboardStore{d},
+		Codes:      &CodeStore{d},
+		Sessions:   &SessionStore{d},
+		TwoFactor:  &TwoFactorStore{d},
+		Moderation: &ModerationStore{d},
+		Audit:      &AuditStore{d},
+		Outbox:     &OutboxStore{d},
+	}
+}
+
+// data คือ "ตาราง" ทั้งหมด — mu ตัวเดียวคุมทุก map จึงทำงานหลายตารางแบบ atomic ได้เหมือน transaction
+type data struct {
+	mu sync.Mutex
+
+	lastUserID    int
+	lastProjectID int
+	lastCodeID    int
+	lastActionID  int
+	lastEventID   int64
+	lastOutboxID  int64
+
+	users       map[int]*userRow
+	skillNames  map[string]string // lower(skill) → ชื่อที่บันทึกครั้งแรก (เหมือน LOWER(skill_name) ในตาราง skills)
+	projects    map[int]*store.Project
+	published   map[int]*store.PublishedProfile
+	codes       map[int]*codeRow
+	resetTokens map[string]*resetTokenRow // key = token hash
+	sessions    map[string]*sessionRow
+	totp        map[int]*store.TOTP
+	recovery    []*recoveryCodeRow
+	challenges  map[string]*challengeRow // key = challenge hash
+	moderated   map[int]bool             // project id ที่ admin ลบออกจาก Dashboard (projects.moderated_at)
+	actions     []store.ModerationAction
+	events      []store.AuditEvent // append-only เหมือน audit_events
+	outbox      map[int64]*outboxRow
+
+	now func() time.Time
+}
+
+type userRow struct {
+	store.User
+	failedLogins     int
+	lockedUntil      time.Time
+	suspendedAt      time.Time
+	suspensionReason string
+	createdAt        time.Time
+}
+
+type codeRow struct {
+	store.VerificationCode
+	used      bool
+	newEmail  string // เฉพาะ CodeEmailChange
+	expiresAt time.Time
+	createdAt time.Time
+}
+
+type resetTokenRow struct {
+	userID    int
+	expiresAt time.Time
+	used      bool
+}
+
+type sessionRow struct {
+	store.Session
+	previousHash string
+	revoked      bool
+	createdAt    time.Time
+	lastUsedAt   time.Time
+}
+
+type recoveryCodeRow struct {
+	userID int
+	hash   string
+	used   bool
+}
+
+type challengeRow struct {
+	userID    int
+	attempts  int
+	expiresAt time.Time
+	used      bool
+}
+
+type outboxRow struct {
+	store.OutboxMessage
+	status      string // pending | sent | failed
+	nextAttempt time.Time
+	lockedUntil time.Time
+	lastError   string
+}
+
+// userByEmail หา user ด้วยอีเมลแบบไม่สนตัวพิมพ์ — ผู้เรียกต้องถือ mu
+func (d *data) userByEmail(email string) *userRow {
+	email = strings.ToLower(email)
+	for _, u := range d.users {
+		if strings.ToLower(u.Email) == email {
+			return u
+		}
+	}
+	return nil
+}
+
+// normalizeSkills ตัดช่องว่าง ตัดตัวซ้ำ และใช้ชื่อ skill ที่มีอยู่แล้วถ้าต่างกันแค่ตัวพิมพ์ — ผู้เรียกต้องถือ mu
+func (d *data) normalizeSkills(skills []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, s := range skills {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		key := strings.ToLower(s)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if name, ok := d.skillNames[key]; ok {
+			s = name
+		} else {
+			d.skillNames[key] = s
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func copyList(list []string) []string {
+	return append([]string{}, list...)
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/store"
+)
+
+type ModerationStore struct {
+	d *data
+}
+
+func (s *ModerationStore) ListUsers(_ context.Context, f store.UserFilter, limit, offset int) ([]store.UserSummary, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	query := strings.ToLower(f.Query)
+	list := []store.UserSummary{}
+	for _, u := range s.d.users {
+		switch {
+		case f.University != "" && !strings.EqualFold(u.University, f.University),
+			query != "" && !strings.Contains(strings.ToLower(u.UserName), query) && !strings.Contains(strings.ToLower(u.Email), query),
+			f.Role != "" && u.Role != f.Role,
+			f.Status == store.UserStatusActive && u.Suspended,
+			f.Status == store.UserStatusSuspended && !u.Suspended,
+			f.Status == store.UserStatusUnverified && u.EmailVerified,
+			f.Status == store.UserStatusPublished && !u.ShowOnDashboard:
+			continue
+		}
+		list = append(list, userSummary(u))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+
+	start, end := pageBounds(len(list), limit, offset)
+	return list[start:end], len(list), nil
+}
+
+func userSummary(u *userRow) store.UserSummary {
+	return store.UserSummary{
+		ID:              u.ID,
+		UserName:        u.UserName,
+		Email:           u.Email,
+		University:      u.University,
+		Role:            u.Role,
+		EmailVerified:   u.EmailVerified,
+		ShowOnDashboard: u.ShowOnDashboard,
+		SuspendedAt:     u.suspendedAt,
+		CreatedAt:       u.createdAt,
+	}
+}
+
+func (s *ModerationStore) GetUser(_ context.Context, userID int) (*store.UserDetail, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	d := store.UserDetail{UserSummary: userSummary(u), SuspensionReason: u.suspensionReason}
+	for _, p := range s.d.projects {
+		if p.UserID == userID {
+			d.ProjectCount++
+		}
+	}
+	if p, ok := s.d.published[userID]; ok {
+		d.PublishedProjectCount = len(p.Projects)
+	}
+	return &d, nil
+}
+
+func (s *ModerationStore) Unpublish(_ context.Context, a store.ModerationAction) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.unpublish(a.TargetUserID)
+	s.d.recordAction(a)
+	return nil
+}
+
+func (s *ModerationStore) Suspend(_ context.Context, a store.ModerationAction) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[a.TargetUserID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Suspended = true
+	u.suspendedAt = s.d.now()
+	u.suspensionReason = a.Reason
+	s.d.unpublish(u.ID)
+	for _, sess := range s.d.sessions {
+		if sess.UserID == u.ID {
+			sess.revoked = true
+		}
+	}
+	s.d.recordAction(a)
+	return nil
+}
+
+func (s *ModerationStore) Unsuspend(_ context.Context, a store.ModerationAction) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[a.TargetUserID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Suspended = false
+	u.suspendedAt = time.Time{}
+	u.suspensionReason = ""
+	s.d.recordAction(a)
+	return nil
+}
+
+func (s *ModerationStore) RemovePublishedProject(_ context.Context, a store.ModerationAction) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	p, ok := s.d.published[a.TargetUserID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	for i, proj := range p.Projects {
+		if proj.ProjectID == a.ProjectID {
+			p.Projects = append(p.Projects[:i:i], p.Projects[i+1:]...)
+			s.d.moderated[a.ProjectID] = true
+			s.d.recordAction(a)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+// unpublish ลบ snapshot บน dashboard และปิด show_on_dashboard — ผู้เรียกต้องถือ mu
+func (d *data) unpublish(userID int) {
+	delete(d.published, userID)
+	if u, ok := d.users[userID]; ok {
+		u.ShowOnDashboard = false
+	}
+}
+
+// recordAction แทน INSERT INTO moderation_actions — ผู้เรียกต้องถือ mu
+func (d *data) recordAction(a store.ModerationAction) {
+	d.lastActionID++
+	a.ID = d.lastActionID
+	a.AdminEmail = ""
+	a.CreatedAt = d.now()
+	d.actions = append(d.actions, a)
+}
+
+func (s *ModerationStore) ListActions(_ context.Context, f store.ActionFilter, limit, offset int) ([]store.ModerationAction, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	list := []store.ModerationAction{}
+	for i := len(s.d.actions) - 1; i >= 0; i-- {
+		a := s.d.actions[i]
+		if f.TargetUserID != 0 && a.TargetUserID != f.TargetUserID {
+			continue
+		}
+		// แทน JOIN users ของ target — target ที่ถูกลบแล้วไม่อยู่ใน scope ของมหาวิทยาลัยไหน
+		if f.University != "" {
+			target, ok := s.d.users[a.TargetUserID]
+			if !ok || !strings.EqualFold(target.University, f.University) {
+				continue
+			}
+		}
+		// แทน LEFT JOIN users — admin ที่ถูกลบแล้วได้อีเมลว่าง
+		if admin, ok := s.d.users[a.AdminID]; ok {
+			a.AdminEmail = admin.Email
+		}
+		list = append(list, a)
+	}
+
+	start, end := pageBounds(len(list), limit, offset)
+	return list[start:end], nil
+}
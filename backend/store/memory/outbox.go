@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/store"
+)
+
+type OutboxStore struct {
+	d *data
+}
+
+func (s *OutboxStore) Enqueue(_ context.Context, m store.OutboxMessage) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.lastOutboxID++
+	m.ID = s.d.lastOutboxID
+	m.Attempts = 0
+	s.d.outbox[m.ID] = &outboxRow{OutboxMessage: m, status: "pending", nextAttempt: s.d.now()}
+	return nil
+}
+
+func (s *OutboxStore) Claim(_ context.Context, lease time.Duration, limit int) ([]store.OutboxMessage, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := s.d.now()
+	due := []*outboxRow{}
+	for _, r := range s.d.outbox {
+		if r.status == "pending" && !r.nextAttempt.After(now) && !r.lockedUntil.After(now) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttempt.Equal(due[j].nextAttempt) {
+			return due[i].nextAttempt.Before(due[j].nextAttempt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	list := make([]store.OutboxMessage, 0, len(due))
+	for _, r := range due {
+		r.lockedUntil = now.Add(lease)
+		list = append(list, r.OutboxMessage)
+	}
+	return list, nil
+}
+
+func (s *OutboxStore) MarkSent(_ context.Context, id int64) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if r, ok := s.d.outbox[id]; ok {
+		r.status = "sent"
+		r.Attempts++
+		r.lockedUntil = time.Time{}
+		r.lastError = ""
+		r.HTML, r.Text = "", ""
+	}
+	return nil
+}
+
+func (s *OutboxStore) MarkFailed(_ context.Context, id int64, attempts int, lastErr string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if r, ok := s.d.outbox[id]; ok {
+		r.status = "failed"
+		r.Attempts = attempts
+		r.lockedUntil = time.Time{}
+		r.lastError = lastErr
+		r.HTML, r.Text = "", ""
+	}
+	return nil
+}
+
+func (s *OutboxStore) MarkRetry(_ context.Context, id int64, attempts int, lastErr string, retryIn time.Duration) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if r, ok := s.d.outbox[id]; ok {
+		r.Attempts = attempts
+		r.lockedUntil = time.Time{}
+		r.lastError = lastErr
+		r.nextAttempt = s.d.now().Add(retryIn)
+	}
+	return nil
+}
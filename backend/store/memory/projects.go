@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"backend/store"
+)
+
+type ProjectStore struct {
+	d *data
+}
+
+func copyProject(p *store.Project) store.Project {
+	out := *p
+	out.Images = copyList(p.Images)
+	return out
+}
+
+func (s *ProjectStore) List(_ context.Context, userID int) ([]store.Project, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	list := []store.Project{}
+	for _, p := range s.d.projects {
+		if p.UserID == userID {
+			list = append(list, copyProject(p))
+		}
+	}
+	sortProjects(list)
+	return list, nil
+}
+
+// sortProjects เรียงใหม่สุดก่อน (created_at DESC) — สร้างในวินาทีเดียวกันใช้ id ที่มากกว่าก่อน
+func sortProjects(list []store.Project) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+}
+
+func (s *ProjectStore) Get(_ context.Context, userID, projectID int) (*store.Project, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	p, ok := s.d.projects[projectID]
+	if !ok || p.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	out := copyProject(p)
+	return &out, nil
+}
+
+func (s *ProjectStore) Create(_ context.Context, p *store.Project) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.lastProjectID++
+	p.ID = s.d.lastProjectID
+	p.CreatedAt = s.d.now()
+	p.Images = copyList(p.Images)
+
+	row := copyProject(p)
+	s.d.projects[row.ID] = &row
+	return nil
+}
+
+func (s *ProjectStore) Update(_ context.Context, p *store.Project) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, ok := s.d.projects[p.ID]
+	if !ok || row.UserID != p.UserID {
+		return store.ErrNotFound
+	}
+	row.Title = p.Title
+	row.Desc = p.Desc
+	row.Images = copyList(p.Images)
+	return nil
+}
+
+func (s *ProjectStore) Delete(_ context.Context, userID, projectID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	p, ok := s.d.projects[projectID]
+	if !ok || p.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.d.projects, projectID)
+	delete(s.d.moderated, projectID)
+	return nil
+}
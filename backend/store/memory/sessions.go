@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"backend/store"
+)
+
+type SessionStore struct {
+	d *data
+}
+
+func (s *SessionStore) Create(_ context.Context, sess store.Session) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	ts := s.d.now()
+	s.d.sessions[sess.ID] = &sessionRow{Session: sess, createdAt: ts, lastUsedAt: ts}
+	return nil
+}
+
+func (s *SessionStore) IsActive(_ context.Context, sessionID string, userID int) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	sess, ok := s.d.sessions[sessionID]
+	if !ok || sess.UserID != userID {
+		return false, nil
+	}
+	return !sess.revoked && sess.ExpiresAt.After(s.d.now()), nil
+}
+
+// findByHash หา session จาก refresh token ปัจจุบัน — ผู้เรียกต้องถือ mu
+func (s *SessionStore) findByHash(tokenHash string) *sessionRow {
+	for _, sess := range s.d.sessions {
+		if sess.RefreshTokenHash == tokenHash {
+			return sess
+		}
+	}
+	return nil
+}
+
+func (s *SessionStore) FindByRefreshToken(_ context.Context, tokenHash string) (*store.RefreshSession, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	sess := s.findByHash(tokenHash)
+	if sess == nil {
+		return nil, store.ErrNotFound
+	}
+	u, ok := s.d.users[sess.UserID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &store.RefreshSession{
+		SessionID: sess.ID,
+		UserID:    sess.UserID,
+		Role:      u.Role,
+		Active:    !sess.revoked && sess.ExpiresAt.After(s.d.now()) && !u.Suspended,
+	}, nil
+}
+
+func (s *SessionStore) RevokeReused(_ context.Context, tokenHash string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for _, sess := range s.d.sessions {
+		if sess.previousHash == tokenHash && !sess.revoked {
+			sess.revoked = true
+			return sess.UserID, nil
+		}
+	}
+	return 0, store.ErrNotFound
+}
+
+func (s *SessionStore) Rotate(_ context.Context, oldHash string, next store.Session) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	sess, ok := s.d.sessions[next.ID]
+	if !ok || sess.RefreshTokenHash != oldHash {
+		return store.ErrNotFound
+	}
+	sess.previousHash = sess.RefreshTokenHash
+	sess.RefreshTokenHash = next.RefreshTokenHash
+	sess.ExpiresAt = next.ExpiresAt
+	sess.IPAddress = next.IPAddress
+	sess.UserAgent = next.UserAgent
+	sess.lastUsedAt = s.d.now()
+	return nil
+}
+
+func (s *SessionStore) Revoke(_ context.Context, sessionID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if sess, ok := s.d.sessions[sessionID]; ok {
+		sess.revoked = true
+	}
+	return nil
+}
+
+func (s *SessionStore) ListActive(_ context.Context, userID int) ([]store.SessionInfo, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := s.d.now()
+	list := []store.SessionInfo{}
+	for _, sess := range s.d.sessions {
+		if sess.UserID != userID || sess.revoked || !sess.ExpiresAt.After(now) {
+			continue
+		}
+		list = append(list, store.SessionInfo{
+			ID:         sess.ID,
+			UserAgent:  sess.UserAgent,
+			IPAddress:  sess.IPAddress,
+			CreatedAt:  sess.createdAt,
+			LastUsedAt: sess.lastUsedAt,
+			ExpiresAt:  sess.ExpiresAt,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsedAt.After(list[j].LastUsedAt) })
+	return list, nil
+}
+
+func (s *SessionStore) RevokeOthers(_ context.Context, userID int, keepSessionID string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	n := 0
+	for id, sess := range s.d.sessions {
+		if sess.UserID == userID && id != keepSessionID && !sess.revoked {
+			sess.revoked = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *SessionStore) RevokeForUser(_ context.Context, userID int, sessionID string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	sess, ok := s.d.sessions[sessionID]
+	if !ok || sess.UserID != userID || sess.revoked {
+		return store.ErrNotFound
+	}
+	sess.revoked = true
+	return nil
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"backend/store"
+)
+
+type TwoFactorStore struct {
+	d *data
+}
+
+func (s *TwoFactorStore) Get(_ context.Context, userID int) (*store.TOTP, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	t, ok := s.d.totp[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	out := *t
+	return &out, nil
+}
+
+func (s *TwoFactorStore) Enroll(_ context.Context, userID int, secretEncrypted string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	// แทน FK ของ user_totp
+	if _, ok := s.d.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.d.totp[userID] = &store.TOTP{SecretEncrypted: secretEncrypted}
+	return nil
+}
+
+func (s *TwoFactorStore) Enable(_ context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	t, ok := s.d.totp[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	t.Enabled = true
+	t.LastUsedStep = step
+	s.d.replaceRecoveryCodes(userID, recoveryCodeHashes)
+	return nil
+}
+
+func (s *TwoFactorStore) Disable(_ context.Context, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	delete(s.d.totp, userID)
+	s.d.deleteRecoveryCodes(userID)
+	return nil
+}
+
+func (s *TwoFactorStore) ReplaceRecoveryCodes(_ context.Context, userID int, codeHashes []string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+// replaceRecoveryCodes ลบ recovery codes เดิมแล้วเพิ่มชุดใหม่ — ผู้เรียกต้องถือ mu
+func (d *data) replaceRecoveryCodes(userID int, codeHashes []string) {
+	d.deleteRecoveryCodes(userID)
+	for _, h := range codeHashes {
+		d.recovery = append(d.recovery, &recoveryCodeRow{userID: userID, hash: h})
+	}
+}
+
+// deleteRecoveryCodes — ผู้เรียกต้องถือ mu
+func (d *data) deleteRecoveryCodes(userID int) {
+	kept := d.recovery[:0]
+	for _, rc := range d.recovery {
+		if rc.userID != userID {
+			kept = append(kept, rc)
+		}
+	}
+	d.recovery = kept
+}
+
+func (s *TwoFactorStore) RemainingRecoveryCodes(_ context.Context, userID int) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	n := 0
+	for _, rc := range s.d.recovery {
+		if rc.userID == userID && !rc.used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *TwoFactorStore) UseRecoveryCode(_ context.Context, userID int, codeHash string) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for _, rc := range s.d.recovery {
+		if rc.userID == userID && rc.hash == codeHash && !rc.used {
+			rc.used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *TwoFactorStore) AdvanceStep(_ context.Context, userID int, step int64) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	t, ok := s.d.totp[userID]
+	if !ok || t.LastUsedStep >= step {
+		return false, nil
+	}
+	t.LastUsedStep = step
+	return true, nil
+}
+
+func (s *TwoFactorStore) CreateChallenge(_ context.Context, challengeHash string, userID int, expiresAt time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.challenges[challengeHash] = &challengeRow{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *TwoFactorStore) ReserveChallenge(_ context.Context, challengeHash string, maxAttempts int) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	ch, ok := s.d.challenges[challengeHash]
+	if !ok || ch.used || !ch.expiresAt.After(s.d.now()) || ch.attempts >= maxAttempts {
+		return 0, store.ErrNotFound
+	}
+	ch.attempts++
+	return ch.userID, nil
+}
+
+func (s *TwoFactorStore) UseChallenge(_ context.Context, challengeHash string) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	ch, ok := s.d.challenges[challengeHash]
+	if !ok || ch.used {
+		return false, nil
+	}
+	ch.used = true
+	return true, nil
+}
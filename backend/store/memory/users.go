@@ -0,0 +1,358 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/store"
+)
+
+type UserStore struct {
+	d *data
+}
+
+// snapshot คัดลอก user ออกไป (caller แก้ค่าแล้วไม่กระทบข้อมูลใน store) — ผู้เรียกต้องถือ mu
+func (s *UserStore) snapshot(u *userRow, withSkills bool) *store.User {
+	out := u.User
+	out.LockedFor = 0
+	if wait := u.lockedUntil.Sub(s.d.now()); wait > 0 {
+		out.LockedFor = (wait + time.Second - 1).Truncate(time.Second) // ปัดขึ้นเป็นวินาทีเหมือน CEIL ใน postgres
+	}
+	out.Skills = nil
+	if withSkills {
+		out.Skills = copyList(u.Skills)
+	}
+	return &out
+}
+
+func (s *UserStore) Create(_ context.Context, u store.NewUser) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if s.d.userByEmail(u.Email) != nil {
+		return 0, store.ErrEmailTaken
+	}
+
+	s.d.lastUserID++
+	row := &userRow{User: store.User{
+		ID:           s.d.lastUserID,
+		Email:        u.Email,
+		PasswordHash: u.PasswordHash,
+		UserName:     u.UserName,
+		Phone:        u.Phone,
+		University:   u.University,
+		Faculty:      u.Faculty,
+		Major:        u.Major,
+		GPA:          u.GPA,
+		JobInterest:  u.JobInterest,
+		Role:         u.Role,
+		Locale:       u.Locale,
+		Skills:       s.d.normalizeSkills(u.Skills),
+	}, createdAt: s.d.now()}
+	s.d.users[row.ID] = row
+	return row.ID, nil
+}
+
+func (s *UserStore) Get(_ context.Context, userID int) (*store.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.snapshot(u, true), nil
+}
+
+func (s *UserStore) GetByEmail(_ context.Context, email string) (*store.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u := s.d.userByEmail(email)
+	if u == nil {
+		return nil, store.ErrNotFound
+	}
+	return s.snapshot(u, false), nil
+}
+
+func (s *UserStore) Skills(_ context.Context, userID int) ([]string, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	// เหมือน postgres — user ที่ไม่มีอยู่ได้ list ว่าง
+	if u, ok := s.d.users[userID]; ok {
+		return copyList(u.Skills), nil
+	}
+	return []string{}, nil
+}
+
+func (s *UserStore) UpdateProfile(_ context.Context, userID int, p store.ProfileUpdate) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.UserName = p.UserName
+	u.Phone = p.Phone
+	u.University = p.University
+	u.Faculty = p.Faculty
+	u.Major = p.Major
+	u.GPA = p.GPA
+	u.JobInterest = p.JobInterest
+	u.ProfileImageURL = p.ProfileImageURL
+	if p.Locale != "" {
+		u.Locale = p.Locale
+	}
+	u.Skills = s.d.normalizeSkills(p.Skills)
+	return nil
+}
+
+func (s *UserStore) Delete(_ context.Context, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if _, ok := s.d.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.d.users, userID)
+	delete(s.d.published, userID)
+
+	// แทน ON DELETE CASCADE ของ postgres
+	for id, p := range s.d.projects {
+		if p.UserID == userID {
+			delete(s.d.projects, id)
+			delete(s.d.moderated, id)
+		}
+	}
+	for id, c := range s.d.codes {
+		if c.UserID == userID {
+			delete(s.d.codes, id)
+		}
+	}
+	for hash, t := range s.d.resetTokens {
+		if t.userID == userID {
+			delete(s.d.resetTokens, hash)
+		}
+	}
+	for id, sess := range s.d.sessions {
+		if sess.UserID == userID {
+			delete(s.d.sessions, id)
+		}
+	}
+	delete(s.d.totp, userID)
+	s.d.deleteRecoveryCodes(userID)
+	for hash, ch := range s.d.challenges {
+		if ch.userID == userID {
+			delete(s.d.challenges, hash)
+		}
+	}
+	// แทน ON DELETE SET NULL ของ moderation_actions
+	for i := range s.d.actions {
+		if s.d.actions[i].AdminID == userID {
+			s.d.actions[i].AdminID = 0
+		}
+		if s.d.actions[i].TargetUserID == userID {
+			s.d.actions[i].TargetUserID = 0
+		}
+	}
+	return nil
+}
+
+func (s *UserStore) List(_ context.Context) ([]store.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	list := make([]store.User, 0, len(s.d.users))
+	for _, u := range s.d.users {
+		list = append(list, *s.snapshot(u, false))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (s *UserStore) SetRole(_ context.Context, userID int, role string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Role = role
+	return nil
+}
+
+func (s *UserStore) SetPassword(_ context.Context, userID int, passwordHash string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	u.failedLogins = 0
+	u.lockedUntil = time.Time{}
+
+	for _, sess := range s.d.sessions {
+		if sess.UserID == userID {
+			sess.revoked = true
+		}
+	}
+	for hash, t := range s.d.resetTokens {
+		if t.userID == userID {
+			delete(s.d.resetTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (s *UserStore) RecordLoginFailure(_ context.Context, userID int) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	u.failedLogins++
+	return u.failedLogins, nil
+}
+
+func (s *UserStore) Lock(_ context.Context, userID int, d time.Duration) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if u, ok := s.d.users[userID]; ok {
+		u.lockedUntil = s.d.now().Add(d)
+	}
+	return nil
+}
+
+func (s *UserStore) ClearLoginFailures(_ context.Context, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if u, ok := s.d.users[userID]; ok {
+		u.failedLogins = 0
+		u.lockedUntil = time.Time{}
+	}
+	return nil
+}
+
+func (s *UserStore) VerifyEmail(_ context.Context, userID, codeID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if c, ok := s.d.codes[codeID]; ok {
+		c.used = true
+	}
+	if u, ok := s.d.users[userID]; ok {
+		u.EmailVerified = true
+	}
+	return nil
+}
+
+func (s *UserStore) ResetPassword(_ context.Context, resetTokenHash, passwordHash string) (*store.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	t, ok := s.d.resetTokens[resetTokenHash]
+	if !ok || t.used || !t.expiresAt.After(s.d.now()) {
+		return nil, store.ErrNotFound
+	}
+	u, ok := s.d.users[t.userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	t.used = true
+
+	u.PasswordHash = passwordHash
+	u.failedLogins = 0
+	u.lockedUntil = time.Time{}
+
+	for _, sess := range s.d.sessions {
+		if sess.UserID == u.ID {
+			sess.revoked = true
+		}
+	}
+	for id, c := range s.d.codes {
+		if c.UserID == u.ID && c.Type == store.CodeForgotPassword {
+			delete(s.d.codes, id)
+		}
+	}
+
+	return &store.User{ID: u.ID, Email: u.Email, Locale: u.Locale}, nil
+}
+
+func (s *UserStore) ChangePassword(_ context.Context, userID int, keepSessionID, passwordHash string) (*store.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	u, ok := s.d.users[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	u.failedLogins = 0
+	u.lockedUntil = time.Time{}
+
+	for id, sess := range s.d.sessions {
+		if sess.UserID == userID && id != keepSessionID {
+			sess.revoked = true
+		}
+	}
+	for hash, t := range s.d.resetTokens {
+		if t.userID == userID {
+			delete(s.d.resetTokens, hash)
+		}
+	}
+	for id, c := range s.d.codes {
+		if c.UserID == userID && c.Type == store.CodeForgotPassword {
+			delete(s.d.codes, id)
+		}
+	}
+
+	return &store.User{ID: u.ID, Email: u.Email, Locale: u.Locale}, nil
+}
+
+func (s *UserStore) ChangeEmail(_ context.Context, userID, codeID int) (string, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, ok := s.d.codes[codeID]
+	if !ok || c.UserID != userID || c.used || c.newEmail == "" {
+		return "", store.ErrNotFound
+	}
+	u, ok := s.d.users[userID]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	// แทน UNIQUE ของ users.email — ตรวจก่อนใช้รหัสเหมือน transaction ที่ rollback
+	if other := s.d.userByEmail(c.newEmail); other != nil && other.ID != userID {
+		return "", store.ErrEmailTaken
+	}
+
+	c.used = true
+	u.Email = c.newEmail
+	u.EmailVerified = true
+	if p, ok := s.d.published[userID]; ok {
+		p.Email = c.newEmail
+		p.UpdatedAt = s.d.now()
+	}
+
+	for id, other := range s.d.codes {
+		if other.UserID == userID && id != codeID {
+			delete(s.d.codes, id)
+		}
+	}
+	for hash, t := range s.d.resetTokens {
+		if t.userID == userID {
+			delete(s.d.resetTokens, hash)
+		}
+	}
+	return c.newEmail, nil
+}
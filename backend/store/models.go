@@ -0,0 +1,222 @@
+package store
+
+import "time"
+
+// User คือบัญชีผู้ใช้ — ช่องที่เป็น NULL ใน DB จะเป็นค่าว่าง
+type User struct {
+	ID              int
+	Email           string
+	PasswordHash    string
+	UserName        string
+	Phone           string
+	University      string
+	Faculty         string
+	Major           string
+	GPA             float64
+	JobInterest     string
+	ProfileImageURL string
+	Role            string
+	Locale          string
+	EmailVerified   bool
+	Suspended       bool
+	ShowOnDashboard bool
+	LockedFor       time.Duration // > 0 = ยังถูกล็อกจากการใส่รหัสผ่านผิด
+	Skills          []string      // โหลดเฉพาะ UserStore.Get
+}
+
+// NewUser คือข้อมูลตอนสมัครสมาชิก (PasswordHash เป็น bcrypt แล้ว)
+type NewUser struct {
+	Email        string
+	PasswordHash string
+	UserName     string
+	Phone        string
+	University   string
+	Faculty      string
+	Major        string
+	GPA          float64
+	JobInterest  string
+	Role         string
+	Locale       string
+	Skills       []string
+}
+
+// ProfileUpdate คือข้อมูลที่ผู้ใช้แก้ได้จากหน้าโปรไฟล์
+type ProfileUpdate struct {
+	UserName        string
+	Phone           string
+	University      string
+	Faculty         string
+	Major           string
+	GPA             float64
+	JobInterest     string
+	ProfileImageURL string
+	Locale          string // ว่าง = ใช้ค่าเดิม
+	Skills          []string
+}
+
+// Project คือโปรเจคใน portfolio — Images เก็บเป็น JSON array ใน projects.image_url
+type Project struct {
+	ID        int
+	UserID    int
+	Title     string
+	Desc      string
+	Images    []string
+	CreatedAt time.Time
+}
+
+// ProfileSummary คือการ์ด 1 ใบบน dashboard
+type ProfileSummary struct {
+	UserID          int
+	UserName        string
+	ProfileImageURL string
+	JobInterest     string
+	University      string
+	Faculty         string
+	Major           string
+	GPA             float64
+}
+
+// PublishedProfile คือ snapshot ที่ publish ไว้ (หน้า profile สาธารณะ)
+type PublishedProfile struct {
+	ProfileSummary
+	Email     string
+	Phone     string
+	Skills    []string
+	Projects  []PublishedProject
+	UpdatedAt time.Time
+}
+
+// PublishedProject คือ snapshot ของโปรเจคตอน publish
+type PublishedProject struct {
+	ProjectID   int
+	Title       string
+	Desc        string
+	Images      []string
+	PublishedAt time.Time
+}
+
+// VerificationCode คือรหัส OTP ที่จองสิทธิ์ตรวจแล้ว (Hash = utils.HashOTP ของรหัสจริง)
+type VerificationCode struct {
+	ID       int
+	UserID   int
+	Type     string
+	Hash     string
+	Attempts int
+}
+
+// Session คือการ login 1 ครั้ง — เก็บเฉพาะ hash ของ refresh token
+type Session struct {
+	ID               string
+	UserID           int
+	RefreshTokenHash string
+	UserAgent        string
+	IPAddress        string
+	ExpiresAt        time.Time
+}
+
+// RefreshSession คือ session ที่หาเจอจาก refresh token พร้อม role ล่าสุดของเจ้าของ
+type RefreshSession struct {
+	SessionID string
+	UserID    int
+	Role      string
+	Active    bool // ยังไม่หมดอายุ ไม่ถูก revoke และบัญชีไม่ถูกระงับ
+}
+
+// SessionInfo คือ session ที่แสดงในหน้า "อุปกรณ์ที่ login อยู่"
+type SessionInfo struct {
+	ID         string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// TOTP คือการตั้งค่า 2FA ของ user — SecretEncrypted เข้ารหัสด้วย utils.EncryptTOTPSecret
+type TOTP struct {
+	SecretEncrypted string
+	Enabled         bool  // false = enroll แล้วแต่ยังไม่ confirm
+	LastUsedStep    int64 // กันใช้รหัส TOTP ซ้ำ
+}
+
+// UserFilter คือเงื่อนไขค้นหาผู้ใช้ของ admin — ช่องว่าง = ไม่กรอง
+type UserFilter struct {
+	University string // university admin เห็นเฉพาะมหาวิทยาลัยตัวเอง (ไม่สนตัวพิมพ์)
+	Query      string // ชื่อหรืออีเมลบางส่วน
+	Role       string
+	Status     string // UserStatusActive / Suspended / Unverified / Published
+}
+
+// UserSummary คือผู้ใช้ 1 แถวในรายการของ admin
+type UserSummary struct {
+	ID              int
+	UserName        string
+	Email           string
+	University      string
+	Role            string
+	EmailVerified   bool
+	ShowOnDashboard bool
+	SuspendedAt     time.Time // zero = ไม่ถูกระงับ
+	CreatedAt       time.Time
+}
+
+// UserDetail คือหน้ารายละเอียดผู้ใช้ของ admin
+type UserDetail struct {
+	UserSummary
+	SuspensionReason      string
+	ProjectCount          int
+	PublishedProjectCount int
+}
+
+// ModerationAction คือการกระทำของ admin 1 ครั้ง — AdminID / TargetUserID เป็น 0 เมื่อบัญชีถูกลบไปแล้ว
+type ModerationAction struct {
+	ID           int
+	AdminID      int
+	AdminEmail   string // เติมเฉพาะตอน ListActions
+	TargetUserID int
+	Action       string // ModUnpublishProfile / ...
+	Reason       string
+	ProjectID    int // เฉพาะ ModDeletePublishedProject
+	CreatedAt    time.Time
+}
+
+// ActionFilter คือเงื่อนไขของ ModerationStore.ListActions — ค่าว่าง / 0 = ไม่กรอง
+type ActionFilter struct {
+	TargetUserID int
+	University   string // มหาวิทยาลัยของ target user (ไม่สนตัวพิมพ์) — scope ของ university admin
+}
+
+// AuditEvent คือ audit_events 1 แถว — UserID / ActorID เป็น 0 เมื่อไม่ทราบ
+type AuditEvent struct {
+	ID        int64
+	UserID    int
+	ActorID   int
+	Action    string
+	IPAddress string
+	UserAgent string
+	Metadata  string // JSON object
+	CreatedAt time.Time
+}
+
+// AuditFilter คือเงื่อนไขค้นหา audit_events — ช่องว่าง / 0 / zero time = ไม่กรอง
+type AuditFilter struct {
+	UserID       int
+	ActorID      int
+	Action       string // ตรงทั้งคำ
+	ActionPrefix string // ขึ้นต้นด้วย เช่น "auth."
+	IPAddress    string
+	Since        time.Time // >= Since
+	Until        time.Time // < Until
+	University   string    // เฉพาะ event ของผู้ใช้ในมหาวิทยาลัยนี้ (ไม่สนตัวพิมพ์)
+}
+
+// OutboxMessage คืออีเมล 1 ฉบับใน mail_outbox
+type OutboxMessage struct {
+	ID       int64
+	Attempts int
+	To       string
+	Subject  string
+	HTML     string
+	Text     string
+	Template string
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type AuditStore struct {
+	db *sql.DB
+}
+
+// Record — audit_events เป็น append-only (มี trigger กัน UPDATE / DELETE)
+func (s *AuditStore) Record(ctx context.Context, e store.AuditEvent) error {
+	metadata := e.Metadata
+	if metadata == "" {
+		metadata = "{}"
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_events (user_id, actor_id, action, ip_address, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, sqlstore.NullInt(e.UserID), sqlstore.NullInt(e.ActorID), e.Action, e.IPAddress, e.UserAgent, metadata)
+	return err
+}
+
+func (s *AuditStore) List(ctx context.Context, f store.AuditFilter, limit, offset int) ([]store.AuditEvent, int, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.University != "" {
+		where = append(where, "user_id IN (SELECT user_id FROM users WHERE LOWER(university) = LOWER("+addArg(f.University)+"))")
+	}
+	if f.UserID != 0 {
+		where = append(where, "user_id = "+addArg(f.UserID))
+	}
+	if f.ActorID != 0 {
+		where = append(where, "actor_id = "+addArg(f.ActorID))
+	}
+	if f.Action != "" {
+		where = append(where, "action = "+addArg(f.Action))
+	}
+	if f.ActionPrefix != "" {
+		where = append(where, "action LIKE "+addArg(f.ActionPrefix+"%"))
+	}
+	if f.IPAddress != "" {
+		where = append(where, "ip_address = "+addArg(f.IPAddress))
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "created_at >= "+addArg(f.Since))
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "created_at < "+addArg(f.Until))
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_events WHERE "+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT event_id, COALESCE(user_id, 0), COALESCE(actor_id, 0), action,
+			COALESCE(ip_address, ''), COALESCE(user_agent, ''), metadata, created_at
+		FROM audit_events WHERE `+whereSQL+`
+		ORDER BY created_at DESC, event_id DESC
+		LIMIT `+addArg(limit)+` OFFSET `+addArg(offset), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []store.AuditEvent{}
+	for rows.Next() {
+		var e store.AuditEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ActorID, &e.Action, &e.IPAddress, &e.UserAgent, &e.Metadata, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, e)
+	}
+	return list, total, rows.Err()
+}
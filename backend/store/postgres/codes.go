@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type CodeStore struct {
+	db *sql.DB
+}
+
+func (s *CodeStore) Issue(ctx context.Context, userID int, codeType, codeHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=$1 AND type=$2", userID, codeType); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO verification_codes (user_id, code, type, expired_at) VALUES ($1,$2,$3,$4)",
+		userID, codeHash, codeType, expiresAt,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CodeStore) Reserve(ctx context.Context, email, codeType string, maxAttempts int) (*store.VerificationCode, error) {
+	code := store.VerificationCode{Type: codeType}
+
+	// จองสิทธิ์ 1 ครั้งแบบ atomic เพื่อให้ request ที่ยิงพร้อมกันเดารหัสเกินจำนวนครั้งไม่ได้
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE verification_codes SET attempts=attempts+1
+		WHERE code_id=(
+			SELECT vc.code_id
+			FROM verification_codes vc
+			JOIN users u ON vc.user_id=u.user_id
+			WHERE LOWER(u.email)=LOWER($1) AND vc.type=$2 AND vc.is_used=FALSE AND vc.expired_at > NOW()
+			ORDER BY vc.created_at DESC LIMIT 1
+		) AND attempts < $3
+		RETURNING code_id, user_id, code, attempts
+	`, email, codeType, maxAttempts).Scan(&code.ID, &code.UserID, &code.Hash, &code.Attempts)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	return &code, nil
+}
+
+func (s *CodeStore) MarkUsed(ctx context.Context, codeID int) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE verification_codes SET is_used=TRUE WHERE code_id=$1 AND is_used=FALSE", codeID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func (s *CodeStore) CreateResetToken(ctx context.Context, codeID, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// OTP ใช้ได้ครั้งเดียว — ถ้ามี request อื่นใช้ไปก่อนแล้วจะไม่มีแถวถูกอัปเดต
+	if err := sqlstore.RequireRow(tx.ExecContext(ctx, "UPDATE verification_codes SET is_used=TRUE WHERE code_id=$1 AND is_used=FALSE", codeID)); err != nil {
+		return err
+	}
+
+	// token ที่ยังไม่ได้ใช้ของ user นี้ถือว่าหมดสิทธิ์ ใช้ได้เฉพาะอันล่าสุด
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expired_at) VALUES ($1,$2,$3)",
+		userID, tokenHash, expiresAt,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CodeStore) IssueEmailChange(ctx context.Context, userID int, newEmail, codeHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=$1 AND type=$2", userID, store.CodeEmailChange); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO verification_codes (user_id, code, type, new_email, expired_at) VALUES ($1,$2,$3,$4,$5)",
+		userID, codeHash, store.CodeEmailChange, newEmail, expiresAt,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CodeStore) PurgeExpired(ctx context.Context) (int, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE is_used = TRUE OR expired_at <= NOW()")
+	if err != nil {
+		return 0, 0, err
+	}
+	codes, _ := result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE used_at IS NOT NULL OR expired_at <= NOW()")
+	if err != nil {
+		return 0, 0, err
+	}
+	tokens, _ := result.RowsAffected()
+
+	return int(codes), int(tokens), tx.Commit()
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type DashboardStore struct {
+	db *sql.DB
+}
+
+func (s *DashboardStore) List(ctx context.Context, excludeUserID, limit int) ([]store.ProfileSummary, error) {
+	// user_id เริ่มที่ 1 เสมอ — excludeUserID = 0 จึงไม่ตัดใครออก
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, COALESCE(user_name, ''), COALESCE(profile_image_url, ''), COALESCE(job_interest, ''),
+			COALESCE(university, ''), COALESCE(faculty, ''), COALESCE(major, ''), gpa
+		FROM published_profiles
+		WHERE user_id != $1
+		ORDER BY updated_at DESC
+		LIMIT $2
+	`, excludeUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.ProfileSummary{}
+	for rows.Next() {
+		var p store.ProfileSummary
+		var gpa sql.NullFloat64
+		if err := rows.Scan(&p.UserID, &p.UserName, &p.ProfileImageURL, &p.JobInterest, &p.University, &p.Faculty, &p.Major, &gpa); err != nil {
+			return nil, err
+		}
+		p.GPA = gpa.Float64
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *DashboardStore) Get(ctx context.Context, userID int) (*store.PublishedProfile, error) {
+	p := store.PublishedProfile{ProfileSummary: store.ProfileSummary{UserID: userID}}
+	var gpa sql.NullFloat64
+	var skills sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(user_name, ''), COALESCE(email, ''), COALESCE(phone, ''), COALESCE(university, ''),
+			COALESCE(faculty, ''), COALESCE(major, ''), gpa, COALESCE(job_interest, ''),
+			COALESCE(profile_image_url, ''), skills, COALESCE(updated_at, NOW())
+		FROM published_profiles
+		WHERE user_id = $1
+	`, userID).Scan(&p.UserName, &p.Email, &p.Phone, &p.University, &p.Faculty, &p.Major, &gpa, &p.JobInterest, &p.ProfileImageURL, &skills, &p.UpdatedAt)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	p.GPA = gpa.Float64
+	p.Skills = sqlstore.DecodeList(skills)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_id, COALESCE(project_name, ''), COALESCE(description, ''), image_url, COALESCE(published_at, NOW())
+		FROM published_projects WHERE user_id = $1 ORDER BY published_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Projects = []store.PublishedProject{}
+	for rows.Next() {
+		var proj store.PublishedProject
+		var imageURL sql.NullString
+		if err := rows.Scan(&proj.ProjectID, &proj.Title, &proj.Desc, &imageURL, &proj.PublishedAt); err != nil {
+			return nil, err
+		}
+		proj.Images = sqlstore.DecodeList(imageURL)
+		p.Projects = append(p.Projects, proj)
+	}
+	return &p, rows.Err()
+}
+
+func (s *DashboardStore) Publish(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	skills, err := querySkills(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	// 1. snapshot ของ profile ปัจจุบัน (publish ซ้ำ = อัปเดต snapshot เดิม)
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO published_profiles
+		(user_id, user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url, skills, updated_at)
+		SELECT user_id, user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url, $2, NOW()
+		FROM users WHERE user_id = $1
+		ON CONFLICT (user_id) DO UPDATE SET
+			user_name = EXCLUDED.user_name,
+			email = EXCLUDED.email,
+			phone = EXCLUDED.phone,
+			university = EXCLUDED.university,
+			faculty = EXCLUDED.faculty,
+			major = EXCLUDED.major,
+			gpa = EXCLUDED.gpa,
+			job_interest = EXCLUDED.job_interest,
+			profile_image_url = EXCLUDED.profile_image_url,
+			skills = EXCLUDED.skills,
+			updated_at = NOW()
+	`, userID, sqlstore.EncodeList(skills))
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+
+	// 2. แทนที่ snapshot ของ projects ทั้งชุด
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO published_projects (user_id, project_id, project_name, description, image_url)
+		SELECT user_id, project_id, project_name, description, image_url
+		FROM projects WHERE user_id = $1 AND moderated_at IS NULL
+	`, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET show_on_dashboard = true WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *DashboardStore) Unpublish(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_profiles WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET show_on_dashboard = false WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+)
+
+type OutboxStore struct {
+	db *sql.DB
+}
+
+func (s *OutboxStore) Enqueue(ctx context.Context, m store.OutboxMessage) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO mail_outbox (to_email, subject, html_body, text_body, template)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	`, m.To, m.Subject, m.HTML, m.Text, m.Template)
+	return err
+}
+
+// Claim — locked_until กันไม่ให้หลาย instance ส่งฉบับเดียวกันซ้ำ, SKIP LOCKED กัน worker รอกันเอง
+func (s *OutboxStore) Claim(ctx context.Context, lease time.Duration, limit int) ([]store.OutboxMessage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE mail_outbox SET locked_until = NOW() + $1 * INTERVAL '1 second'
+		WHERE outbox_id IN (
+			SELECT outbox_id FROM mail_outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+				AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_attempt_at, outbox_id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING outbox_id, attempts, to_email, subject, html_body, text_body, template
+	`, int(lease.Seconds()), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.OutboxMessage
+	for rows.Next() {
+		var m store.OutboxMessage
+		var html, text, template sql.NullString
+		if err := rows.Scan(&m.ID, &m.Attempts, &m.To, &m.Subject, &html, &text, &template); err != nil {
+			return nil, err
+		}
+		m.HTML, m.Text, m.Template = html.String, text.String, template.String
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+func (s *OutboxStore) MarkSent(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mail_outbox SET status = 'sent', sent_at = NOW(), attempts = attempts + 1, locked_until = NULL, last_error = NULL,
+			html_body = NULL, text_body = NULL
+		WHERE outbox_id = $1
+	`, id)
+	return err
+}
+
+func (s *OutboxStore) MarkFailed(ctx context.Context, id int64, attempts int, lastErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mail_outbox SET status = 'failed', failed_at = NOW(), attempts = $1, last_error = $2, locked_until = NULL,
+			html_body = NULL, text_body = NULL
+		WHERE outbox_id = $3
+	`, attempts, lastErr, id)
+	return err
+}
+
+func (s *OutboxStore) MarkRetry(ctx context.Context, id int64, attempts int, lastErr string, retryIn time.Duration) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mail_outbox SET attempts = $1, last_error = $2, locked_until = NULL,
+			next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE outbox_id = $4
+	`, attempts, lastErr, int(retryIn.Seconds()), id)
+	return err
+}
@@ -0,0 +1,72 @@
+// Package postgres คือ implementation ของ store บน PostgreSQL (schema ใน database/migrations)
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"backend/store"
+	"backend/store/sqlstore"
+
+	"github.com/lib/pq"
+)
+
+// New สร้าง store ทุกตัวบน connection pool เดียวกัน
+func New(db *sql.DB) *store.Store {
+	return &store.Store{
+		Users:      &UserStore{db: db},
+		Projects:   &ProjectStore{db: db},
+		Dashboard:  &DashboardStore{db: db},
+		Codes:      &CodeStore{db: db},
+		Sessions:   &SessionStore{db: db},
+		TwoFactor:  &TwoFactorStore{db: db},
+		Moderation: &ModerationStore{db: db},
+		Audit:      &AuditStore{db: db},
+		Outbox:     &OutboxStore{db: db},
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// replaceSkills แทนที่ skills ของ user ทั้งชุด — skill ที่ยังไม่มีในตาราง skills จะถูกเพิ่มให้ (ไม่สนตัวพิมพ์)
+func replaceSkills(ctx context.Context, tx *sql.Tx, userID int, skills []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_skills WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	for _, s := range skills {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		var skillID int
+		err := tx.QueryRowContext(ctx, "SELECT skill_id FROM skills WHERE LOWER(skill_name)=LOWER($1)", s).Scan(&skillID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.QueryRowContext(ctx, "INSERT INTO skills (skill_name) VALUES ($1) RETURNING skill_id", s).Scan(&skillID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO user_skills (user_id, skill_id) VALUES ($1,$2) ON CONFLICT DO NOTHING", userID, skillID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// querySkills ใช้ได้ทั้ง *sql.DB และ *sql.Tx
+func querySkills(ctx context.Context, q sqlstore.Querier, userID int) ([]string, error) {
+	return sqlstore.Strings(ctx, q, `
+		SELECT s.skill_name
+		FROM user_skills us
+		JOIN skills s ON us.skill_id = s.skill_id
+		WHERE us.user_id = $1
+	`, userID)
+}
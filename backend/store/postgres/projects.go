@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type ProjectStore struct {
+	db *sql.DB
+}
+
+func (s *ProjectStore) List(ctx context.Context, userID int) ([]store.Project, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_id, COALESCE(project_name, ''), COALESCE(description, ''), image_url, COALESCE(created_at, NOW())
+		FROM projects
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.Project{}
+	for rows.Next() {
+		p := store.Project{UserID: userID}
+		var imageURL sql.NullString
+		if err := rows.Scan(&p.ID, &p.Title, &p.Desc, &imageURL, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Images = sqlstore.DecodeList(imageURL)
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *ProjectStore) Get(ctx context.Context, userID, projectID int) (*store.Project, error) {
+	p := store.Project{ID: projectID, UserID: userID}
+	var imageURL sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(project_name, ''), COALESCE(description, ''), image_url, COALESCE(created_at, NOW())
+		FROM projects
+		WHERE project_id = $1 AND user_id = $2
+	`, projectID, userID).Scan(&p.Title, &p.Desc, &imageURL, &p.CreatedAt)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	p.Images = sqlstore.DecodeList(imageURL)
+	return &p, nil
+}
+
+func (s *ProjectStore) Create(ctx context.Context, p *store.Project) error {
+	return s.db.QueryRowContext(ctx, `
+		INSERT INTO projects (user_id, project_name, description, image_url)
+		VALUES ($1, $2, $3, $4)
+		RETURNING project_id, created_at
+	`, p.UserID, p.Title, p.Desc, sqlstore.EncodeList(p.Images)).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (s *ProjectStore) Update(ctx context.Context, p *store.Project) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx, `
+		UPDATE projects
+		SET project_name = $1, description = $2, image_url = $3
+		WHERE project_id = $4 AND user_id = $5
+	`, p.Title, p.Desc, sqlstore.EncodeList(p.Images), p.ID, p.UserID))
+}
+
+func (s *ProjectStore) Delete(ctx context.Context, userID, projectID int) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx,
+		"DELETE FROM projects WHERE project_id = $1 AND user_id = $2",
+		projectID, userID,
+	))
+}
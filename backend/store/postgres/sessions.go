@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+func (s *SessionStore) Create(ctx context.Context, sess store.Session) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (session_id, user_id, refresh_token_hash, user_agent, ip_address, expired_at)
+		VALUES ($1,$2,$3,$4,$5,$6)
+	`, sess.ID, sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddress, sess.ExpiresAt)
+	return err
+}
+
+func (s *SessionStore) IsActive(ctx context.Context, sessionID string, userID int) (bool, error) {
+	var active bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT revoked_at IS NULL AND expired_at > NOW()
+		FROM sessions WHERE session_id = $1 AND user_id = $2
+	`, sessionID, userID).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return active, err
+}
+
+func (s *SessionStore) FindByRefreshToken(ctx context.Context, tokenHash string) (*store.RefreshSession, error) {
+	var rs store.RefreshSession
+	// อ่าน role ล่าสุดจาก users เพื่อให้การเปลี่ยน role มีผลตั้งแต่ token ถัดไป
+	err := s.db.QueryRowContext(ctx, `
+		SELECT s.session_id, s.user_id, u.role,
+			s.revoked_at IS NULL AND s.expired_at > NOW() AND u.suspended_at IS NULL
+		FROM sessions s JOIN users u ON u.user_id = s.user_id
+		WHERE s.refresh_token_hash=$1
+	`, tokenHash).Scan(&rs.SessionID, &rs.UserID, &rs.Role, &rs.Active)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	return &rs, nil
+}
+
+func (s *SessionStore) RevokeReused(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+	err := s.db.QueryRowContext(ctx,
+		"UPDATE sessions SET revoked_at=NOW() WHERE previous_token_hash=$1 AND revoked_at IS NULL RETURNING user_id",
+		tokenHash,
+	).Scan(&userID)
+	return userID, sqlstore.NotFound(err)
+}
+
+func (s *SessionStore) Rotate(ctx context.Context, oldHash string, next store.Session) error {
+	// เงื่อนไข refresh_token_hash กันไม่ให้ 2 request หมุนพร้อมกันได้ทั้งคู่
+	return sqlstore.RequireRow(s.db.ExecContext(ctx, `
+		UPDATE sessions SET
+			previous_token_hash=refresh_token_hash,
+			refresh_token_hash=$1,
+			last_used_at=NOW(),
+			expired_at=$2,
+			ip_address=$3,
+			user_agent=$4
+		WHERE session_id=$5 AND refresh_token_hash=$6
+	`, next.RefreshTokenHash, next.ExpiresAt, next.IPAddress, next.UserAgent, next.ID, oldHash))
+}
+
+func (s *SessionStore) Revoke(ctx context.Context, sessionID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE session_id=$1 AND revoked_at IS NULL", sessionID)
+	return err
+}
+
+func (s *SessionStore) ListActive(ctx context.Context, userID int) ([]store.SessionInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expired_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expired_at > NOW()
+		ORDER BY last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.SessionInfo{}
+	for rows.Next() {
+		var si store.SessionInfo
+		if err := rows.Scan(&si.ID, &si.UserAgent, &si.IPAddress, &si.CreatedAt, &si.LastUsedAt, &si.ExpiresAt); err != nil {
+			return nil, err
+		}
+		list = append(list, si)
+	}
+	return list, rows.Err()
+}
+
+func (s *SessionStore) RevokeOthers(ctx context.Context, userID int, keepSessionID string) (int, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND session_id<>$2 AND revoked_at IS NULL",
+		userID, keepSessionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+func (s *SessionStore) RevokeForUser(ctx context.Context, userID int, sessionID string) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=NOW() WHERE session_id=$1 AND user_id=$2 AND revoked_at IS NULL",
+		sessionID, userID,
+	))
+}
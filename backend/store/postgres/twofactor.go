@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type TwoFactorStore struct {
+	db *sql.DB
+}
+
+func (s *TwoFactorStore) Get(ctx context.Context, userID int) (*store.TOTP, error) {
+	var t store.TOTP
+	err := s.db.QueryRowContext(ctx,
+		"SELECT secret_encrypted, enabled_at IS NOT NULL, last_used_step FROM user_totp WHERE user_id=$1",
+		userID,
+	).Scan(&t.SecretEncrypted, &t.Enabled, &t.LastUsedStep)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	return &t, nil
+}
+
+func (s *TwoFactorStore) Enroll(ctx context.Context, userID int, secretEncrypted string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret_encrypted, enabled_at, last_used_step)
+		VALUES ($1, $2, NULL, 0)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret_encrypted = EXCLUDED.secret_encrypted,
+			enabled_at = NULL,
+			last_used_step = 0,
+			created_at = NOW()
+	`, userID, secretEncrypted)
+	return err
+}
+
+func (s *TwoFactorStore) Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := sqlstore.RequireRow(tx.ExecContext(ctx, "UPDATE user_totp SET enabled_at=NOW(), last_used_step=$1 WHERE user_id=$2", step, userID)); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *TwoFactorStore) Disable(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *TwoFactorStore) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaceRecoveryCodes ลบ recovery codes เดิมแล้วเพิ่มชุดใหม่ (เก็บเฉพาะ hash)
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1,$2)", userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TwoFactorStore) RemainingRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL", userID).Scan(&n)
+	return n, err
+}
+
+func (s *TwoFactorStore) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE recovery_codes SET used_at=NOW() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL",
+		userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n == 1, nil
+}
+
+func (s *TwoFactorStore) AdvanceStep(ctx context.Context, userID int, step int64) (bool, error) {
+	// เงื่อนไข last_used_step < step กัน request ที่ใช้รหัสเดียวกันพร้อมกัน
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_used_step=$1 WHERE user_id=$2 AND last_used_step < $1",
+		step, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n == 1, nil
+}
+
+func (s *TwoFactorStore) CreateChallenge(ctx context.Context, challengeHash string, userID int, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO login_challenges (challenge_hash, user_id, expired_at) VALUES ($1,$2,$3)",
+		challengeHash, userID, expiresAt,
+	)
+	return err
+}
+
+func (s *TwoFactorStore) ReserveChallenge(ctx context.Context, challengeHash string, maxAttempts int) (int, error) {
+	var userID int
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE login_challenges SET attempts=attempts+1
+		WHERE challenge_hash=$1 AND used_at IS NULL AND expired_at > NOW() AND attempts < $2
+		RETURNING user_id
+	`, challengeHash, maxAttempts).Scan(&userID)
+	return userID, sqlstore.NotFound(err)
+}
+
+func (s *TwoFactorStore) UseChallenge(ctx context.Context, challengeHash string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE login_challenges SET used_at=NOW() WHERE challenge_hash=$1 AND used_at IS NULL", challengeHash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,350 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type UserStore struct {
+	db *sql.DB
+}
+
+const userColumns = `
+	user_id, email, password_hash, COALESCE(user_name, ''), COALESCE(phone, ''),
+	COALESCE(university, ''), COALESCE(faculty, ''), COALESCE(major, ''), gpa,
+	COALESCE(job_interest, ''), COALESCE(profile_image_url, ''), role, locale,
+	verified_at IS NOT NULL, suspended_at IS NOT NULL, COALESCE(show_on_dashboard, FALSE),
+	COALESCE(CEIL(EXTRACT(EPOCH FROM (locked_until - NOW()))), 0)::int`
+
+// scanUser อ่าน userColumns จาก *sql.Row หรือ *sql.Rows
+func scanUser(row sqlstore.Scanner) (*store.User, error) {
+	var u store.User
+	var gpa sql.NullFloat64
+	var lockedSeconds int
+	err := row.Scan(
+		&u.ID, &u.Email, &u.PasswordHash, &u.UserName, &u.Phone,
+		&u.University, &u.Faculty, &u.Major, &gpa,
+		&u.JobInterest, &u.ProfileImageURL, &u.Role, &u.Locale,
+		&u.EmailVerified, &u.Suspended, &u.ShowOnDashboard,
+		&lockedSeconds,
+	)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	u.GPA = gpa.Float64
+	if lockedSeconds > 0 {
+		u.LockedFor = time.Duration(lockedSeconds) * time.Second
+	}
+	return &u, nil
+}
+
+func (s *UserStore) Create(ctx context.Context, u store.NewUser) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users
+		(email, password_hash, user_name, phone, university, faculty, major, gpa, job_interest, role, locale)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		RETURNING user_id
+	`, u.Email, u.PasswordHash, u.UserName, u.Phone, u.University, u.Faculty, u.Major, u.GPA, u.JobInterest, u.Role, u.Locale).Scan(&userID)
+	if isUniqueViolation(err) {
+		return 0, store.ErrEmailTaken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := replaceSkills(ctx, tx, userID, u.Skills); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+func (s *UserStore) Get(ctx context.Context, userID int) (*store.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE user_id=$1", userID))
+	if err != nil {
+		return nil, err
+	}
+	if u.Skills, err = querySkills(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE LOWER(email)=LOWER($1)", email))
+}
+
+func (s *UserStore) Skills(ctx context.Context, userID int) ([]string, error) {
+	return querySkills(ctx, s.db, userID)
+}
+
+func (s *UserStore) UpdateProfile(ctx context.Context, userID int, p store.ProfileUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users SET
+			user_name=$1, phone=$2, university=$3,
+			faculty=$4, major=$5, gpa=$6,
+			job_interest=$7, profile_image_url=$8,
+			locale=COALESCE(NULLIF($9, ''), locale)
+		WHERE user_id=$10
+	`, p.UserName, p.Phone, p.University, p.Faculty, p.Major, p.GPA, p.JobInterest, p.ProfileImageURL, p.Locale, userID)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+
+	if err := replaceSkills(ctx, tx, userID, p.Skills); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) Delete(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// snapshot บน dashboard ไม่มี foreign key ไปที่ users จึงต้องลบเอง
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_profiles WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	// projects, user_skills, verification_codes, sessions ถูกลบตาม ON DELETE CASCADE
+	result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE user_id=$1", userID)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) List(ctx context.Context) ([]store.User, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *u)
+	}
+	return list, rows.Err()
+}
+
+func (s *UserStore) SetRole(ctx context.Context, userID int, role string) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx, "UPDATE users SET role=$1 WHERE user_id=$2", role, userID))
+}
+
+func (s *UserStore) SetPassword(ctx context.Context, userID int, passwordHash string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE users SET password_hash=$1, failed_login_count=0, locked_until=NULL WHERE user_id=$2",
+		passwordHash, userID,
+	)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=$1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) RecordLoginFailure(ctx context.Context, userID int) (int, error) {
+	var failures int
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users SET failed_login_count=failed_login_count+1, last_failed_login_at=NOW()
+		WHERE user_id=$1
+		RETURNING failed_login_count
+	`, userID).Scan(&failures)
+	return failures, sqlstore.NotFound(err)
+}
+
+func (s *UserStore) Lock(ctx context.Context, userID int, d time.Duration) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET locked_until=NOW() + $1 * INTERVAL '1 second' WHERE user_id=$2",
+		int(d.Seconds()), userID,
+	)
+	return err
+}
+
+func (s *UserStore) ClearLoginFailures(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET failed_login_count=0, locked_until=NULL WHERE user_id=$1 AND (failed_login_count > 0 OR locked_until IS NOT NULL)",
+		userID,
+	)
+	return err
+}
+
+func (s *UserStore) VerifyEmail(ctx context.Context, userID, codeID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE verification_codes SET is_used=TRUE WHERE code_id=$1", codeID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET verified_at=NOW() WHERE user_id=$1 AND verified_at IS NULL", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) ResetPassword(ctx context.Context, resetTokenHash, passwordHash string) (*store.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// ใช้ token ได้ครั้งเดียวและต้องยังไม่หมดอายุ
+	var u store.User
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens SET used_at=NOW()
+		WHERE token_hash=$1 AND used_at IS NULL AND expired_at > NOW()
+		RETURNING user_id
+	`, resetTokenHash).Scan(&u.ID)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE users SET password_hash=$1, failed_login_count=0, locked_until=NULL WHERE user_id=$2 RETURNING email, locale",
+		passwordHash, u.ID,
+	).Scan(&u.Email, &u.Locale)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+
+	// revoke ทุก session เพื่อให้ access/refresh token ที่ออกไปก่อนหน้านี้ใช้ไม่ได้ทั้งหมด
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL", u.ID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=$1 AND type=$2", u.ID, store.CodeForgotPassword); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *UserStore) ChangePassword(ctx context.Context, userID int, keepSessionID, passwordHash string) (*store.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u := store.User{ID: userID}
+	err = tx.QueryRowContext(ctx,
+		"UPDATE users SET password_hash=$1, failed_login_count=0, locked_until=NULL WHERE user_id=$2 RETURNING email, locale",
+		passwordHash, userID,
+	).Scan(&u.Email, &u.Locale)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+
+	// เครื่องที่เปลี่ยนรหัสยัง login อยู่ — เครื่องอื่นต้อง login ใหม่ด้วยรหัสผ่านใหม่
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND session_id<>$2 AND revoked_at IS NULL",
+		userID, keepSessionID,
+	); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=$1", userID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=$1 AND type=$2", userID, store.CodeForgotPassword); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *UserStore) ChangeEmail(ctx context.Context, userID, codeID int) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var newEmail sql.NullString
+	err = tx.QueryRowContext(ctx,
+		"UPDATE verification_codes SET is_used=TRUE WHERE code_id=$1 AND user_id=$2 AND is_used=FALSE RETURNING new_email",
+		codeID, userID,
+	).Scan(&newEmail)
+	if err != nil {
+		return "", sqlstore.NotFound(err)
+	}
+	if newEmail.String == "" {
+		return "", store.ErrNotFound
+	}
+
+	// users.email เป็น UNIQUE — มีคนสมัครด้วยอีเมลนี้ระหว่างรอยืนยัน
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email=$1, verified_at=NOW() WHERE user_id=$2", newEmail.String, userID)
+	if isUniqueViolation(err) {
+		return "", store.ErrEmailTaken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// published_projects ไม่มีคอลัมน์อีเมล จึงแก้แค่ published_profiles
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE published_profiles SET email=$1, updated_at=NOW() WHERE user_id=$2",
+		newEmail.String, userID,
+	); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=$1 AND code_id<>$2", userID, codeID); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=$1", userID); err != nil {
+		return "", err
+	}
+
+	return newEmail.String, tx.Commit()
+}
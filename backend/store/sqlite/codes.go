@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type CodeStore struct {
+	db *sql.DB
+}
+
+func (s *CodeStore) Issue(ctx context.Context, userID int, codeType, codeHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=? AND type=?", userID, codeType); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO verification_codes (user_id, code, type, expired_at, created_at) VALUES (?,?,?,?,?)",
+		userID, codeHash, codeType, expiresAt.UTC(), now(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CodeStore) Reserve(ctx context.Context, email, codeType string, maxAttempts int) (*store.VerificationCode, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// BEGIN IMMEDIATE ถือ write lock ตั้งแต่ต้น — request ที่ยิงพร้อมกันจึงจองสิทธิ์ทีละตัว เดารหัสเกินจำนวนครั้งไม่ได้
+	code := store.VerificationCode{Type: codeType}
+	err = tx.QueryRowContext(ctx, `
+		SELECT vc.code_id, vc.user_id, vc.code, vc.attempts
+		FROM verification_codes vc
+		JOIN users u ON vc.user_id=u.user_id
+		WHERE u.email=? AND vc.type=? AND vc.is_used=0 AND vc.expired_at > ?
+		ORDER BY vc.created_at DESC, vc.code_id DESC LIMIT 1
+	`, email, codeType, now()).Scan(&code.ID, &code.UserID, &code.Hash, &code.Attempts)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	if code.Attempts >= maxAttempts {
+		return nil, store.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE verification_codes SET attempts=attempts+1 WHERE code_id=?", code.ID); err != nil {
+		return nil, err
+	}
+	code.Attempts++
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (s *CodeStore) MarkUsed(ctx context.Context, codeID int) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE verification_codes SET is_used=1 WHERE code_id=? AND is_used=0", codeID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func (s *CodeStore) CreateResetToken(ctx context.Context, codeID, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// OTP ใช้ได้ครั้งเดียว — ถ้ามี request อื่นใช้ไปก่อนแล้วจะไม่มีแถวถูกอัปเดต
+	if err := sqlstore.RequireRow(tx.ExecContext(ctx, "UPDATE verification_codes SET is_used=1 WHERE code_id=? AND is_used=0", codeID)); err != nil {
+		return err
+	}
+
+	// token ที่ยังไม่ได้ใช้ของ user นี้ถือว่าหมดสิทธิ์ ใช้ได้เฉพาะอันล่าสุด
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expired_at, created_at) VALUES (?,?,?,?)",
+		userID, tokenHash, expiresAt.UTC(), now(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CodeStore) IssueEmailChange(ctx context.Context, userID int, newEmail, codeHash string, expiresAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=? AND type=?", userID, store.CodeEmailChange); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO verification_codes (user_id, code, type, new_email, expired_at, created_at) VALUES (?,?,?,?,?,?)",
+		userID, codeHash, store.CodeEmailChange, newEmail, expiresAt.UTC(), now(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *CodeStore) PurgeExpired(ctx context.Context) (int, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ts := now()
+	result, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE is_used = 1 OR expired_at <= ?", ts)
+	if err != nil {
+		return 0, 0, err
+	}
+	codes, _ := result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE used_at IS NOT NULL OR expired_at <= ?", ts)
+	if err != nil {
+		return 0, 0, err
+	}
+	tokens, _ := result.RowsAffected()
+
+	return int(codes), int(tokens), tx.Commit()
+}
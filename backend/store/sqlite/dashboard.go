@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type DashboardStore struct {
+	db *sql.DB
+}
+
+func (s *DashboardStore) List(ctx context.Context, excludeUserID, limit int) ([]store.ProfileSummary, error) {
+	// user_id เริ่มที่ 1 เสมอ — excludeUserID = 0 จึงไม่ตัดใครออก
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, COALESCE(user_name, ''), COALESCE(profile_image_url, ''), COALESCE(job_interest, ''),
+			COALESCE(university, ''), COALESCE(faculty, ''), COALESCE(major, ''), gpa
+		FROM published_profiles
+		WHERE user_id != ?
+		ORDER BY updated_at DESC, user_id DESC
+		LIMIT ?
+	`, excludeUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.ProfileSummary{}
+	for rows.Next() {
+		var p store.ProfileSummary
+		var gpa sql.NullFloat64
+		if err := rows.Scan(&p.UserID, &p.UserName, &p.ProfileImageURL, &p.JobInterest, &p.University, &p.Faculty, &p.Major, &gpa); err != nil {
+			return nil, err
+		}
+		p.GPA = gpa.Float64
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *DashboardStore) Get(ctx context.Context, userID int) (*store.PublishedProfile, error) {
+	p := store.PublishedProfile{ProfileSummary: store.ProfileSummary{UserID: userID}}
+	var gpa sql.NullFloat64
+	var skills sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(user_name, ''), COALESCE(email, ''), COALESCE(phone, ''), COALESCE(university, ''),
+			COALESCE(faculty, ''), COALESCE(major, ''), gpa, COALESCE(job_interest, ''),
+			COALESCE(profile_image_url, ''), skills, updated_at
+		FROM published_profiles
+		WHERE user_id = ?
+	`, userID).Scan(&p.UserName, &p.Email, &p.Phone, &p.University, &p.Faculty, &p.Major, &gpa, &p.JobInterest, &p.ProfileImageURL, &skills, &p.UpdatedAt)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	p.GPA = gpa.Float64
+	p.Skills = sqlstore.DecodeList(skills)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_id, COALESCE(project_name, ''), COALESCE(description, ''), image_url, published_at
+		FROM published_projects WHERE user_id = ? ORDER BY published_at DESC, project_id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Projects = []store.PublishedProject{}
+	for rows.Next() {
+		var proj store.PublishedProject
+		var imageURL sql.NullString
+		if err := rows.Scan(&proj.ProjectID, &proj.Title, &proj.Desc, &imageURL, &proj.PublishedAt); err != nil {
+			return nil, err
+		}
+		proj.Images = sqlstore.DecodeList(imageURL)
+		p.Projects = append(p.Projects, proj)
+	}
+	return &p, rows.Err()
+}
+
+func (s *DashboardStore) Publish(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE user_id = ?)", userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+
+	// skills รวมเป็น JSON ใน Go แทน json_agg
+	skills, err := querySkills(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	ts := now()
+
+	// 1. snapshot ของ profile ปัจจุบัน — แทน ON CONFLICT: อัปเดต snapshot เดิมก่อน ถ้ายังไม่มีค่อย INSERT
+	result, err := tx.ExecContext(ctx, `
+		UPDATE published_profiles SET
+			(user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url) = (
+				SELECT user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url
+				FROM users WHERE user_id = ?
+			),
+			skills = ?,
+			updated_at = ?
+		WHERE user_id = ?
+	`, userID, sqlstore.EncodeList(skills), ts, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO published_profiles
+			(user_id, user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url, skills, published_at, updated_at)
+			SELECT user_id, user_name, email, phone, university, faculty, major, gpa, job_interest, profile_image_url, ?, ?, ?
+			FROM users WHERE user_id = ?
+		`, sqlstore.EncodeList(skills), ts, ts, userID); err != nil {
+			return err
+		}
+	}
+
+	// 2. แทนที่ snapshot ของ projects ทั้งชุด
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO published_projects (user_id, project_id, project_name, description, image_url, published_at)
+		SELECT user_id, project_id, project_name, description, image_url, ?
+		FROM projects WHERE user_id = ? AND moderated_at IS NULL
+	`, ts, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET show_on_dashboard = 1 WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *DashboardStore) Unpublish(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_profiles WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET show_on_dashboard = 0 WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
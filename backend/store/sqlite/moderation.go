@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type ModerationStore struct {
+	db *sql.DB
+}
+
+func (s *ModerationStore) ListUsers(ctx context.Context, f store.UserFilter, limit, offset int) ([]store.UserSummary, int, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+
+	if f.University != "" {
+		where = append(where, "LOWER(university) = LOWER(?)")
+		args = append(args, f.University)
+	}
+	if f.Query != "" {
+		// LIKE ของ sqlite ไม่สนตัวพิมพ์ (ASCII) อยู่แล้ว — LOWER ไว้ให้ตรงกับ postgres
+		p := "%" + strings.ToLower(f.Query) + "%"
+		where = append(where, "(LOWER(user_name) LIKE ? OR LOWER(email) LIKE ?)")
+		args = append(args, p, p)
+	}
+	if f.Role != "" {
+		where = append(where, "role = ?")
+		args = append(args, f.Role)
+	}
+	switch f.Status {
+	case store.UserStatusActive:
+		where = append(where, "suspended_at IS NULL")
+	case store.UserStatusSuspended:
+		where = append(where, "suspended_at IS NOT NULL")
+	case store.UserStatusUnverified:
+		where = append(where, "verified_at IS NULL")
+	case store.UserStatusPublished:
+		where = append(where, "show_on_dashboard = 1")
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE "+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+userSummaryColumns+`
+		FROM users WHERE `+whereSQL+`
+		ORDER BY user_id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []store.UserSummary{}
+	for rows.Next() {
+		u, err := sqlstore.ScanUserSummary(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, *u)
+	}
+	return list, total, rows.Err()
+}
+
+const userSummaryColumns = `
+	user_id, COALESCE(user_name, ''), email, COALESCE(university, ''), role,
+	verified_at IS NOT NULL, show_on_dashboard, suspended_at, created_at`
+
+func (s *ModerationStore) GetUser(ctx context.Context, userID int) (*store.UserDetail, error) {
+	var d store.UserDetail
+	u, err := sqlstore.ScanUserSummary(s.db.QueryRowContext(ctx, `
+		SELECT `+userSummaryColumns+`, COALESCE(suspension_reason, ''),
+			(SELECT COUNT(*) FROM projects WHERE user_id = ?1),
+			(SELECT COUNT(*) FROM published_projects WHERE user_id = ?1)
+		FROM users WHERE user_id = ?1
+	`, userID), &d.SuspensionReason, &d.ProjectCount, &d.PublishedProjectCount)
+	if err != nil {
+		return nil, err
+	}
+	d.UserSummary = *u
+	return &d, nil
+}
+
+func (s *ModerationStore) Unpublish(ctx context.Context, a store.ModerationAction) error {
+	return s.withAction(ctx, a, func(tx *sql.Tx) error {
+		return unpublishTx(ctx, tx, a.TargetUserID)
+	})
+}
+
+func (s *ModerationStore) Suspend(ctx context.Context, a store.ModerationAction) error {
+	return s.withAction(ctx, a, func(tx *sql.Tx) error {
+		ts := now()
+		if err := sqlstore.RequireRow(tx.ExecContext(ctx,
+			"UPDATE users SET suspended_at = ?, suspension_reason = ? WHERE user_id = ?",
+			ts, a.Reason, a.TargetUserID,
+		)); err != nil {
+			return err
+		}
+		if err := unpublishTx(ctx, tx, a.TargetUserID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", ts, a.TargetUserID)
+		return err
+	})
+}
+
+func (s *ModerationStore) Unsuspend(ctx context.Context, a store.ModerationAction) error {
+	return s.withAction(ctx, a, func(tx *sql.Tx) error {
+		return sqlstore.RequireRow(tx.ExecContext(ctx,
+			"UPDATE users SET suspended_at = NULL, suspension_reason = NULL WHERE user_id = ?",
+			a.TargetUserID,
+		))
+	})
+}
+
+func (s *ModerationStore) RemovePublishedProject(ctx context.Context, a store.ModerationAction) error {
+	return s.withAction(ctx, a, func(tx *sql.Tx) error {
+		if err := sqlstore.RequireRow(tx.ExecContext(ctx,
+			"DELETE FROM published_projects WHERE user_id = ? AND project_id = ?",
+			a.TargetUserID, a.ProjectID,
+		)); err != nil {
+			return err
+		}
+		// ตั้ง flag ไว้ที่ตัวโปรเจค — publish ใหม่แล้วโปรเจคนี้จะไม่กลับขึ้น Dashboard
+		_, err := tx.ExecContext(ctx,
+			"UPDATE projects SET moderated_at = ? WHERE user_id = ? AND project_id = ?",
+			now(), a.TargetUserID, a.ProjectID,
+		)
+		return err
+	})
+}
+
+// withAction รัน fn แล้วบันทึก a ลง moderation_actions ใน transaction เดียวกัน
+func (s *ModerationStore) withAction(ctx context.Context, a store.ModerationAction, fn func(tx *sql.Tx) error) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO moderation_actions (admin_id, target_user_id, action, reason, project_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, sqlstore.NullInt(a.AdminID), sqlstore.NullInt(a.TargetUserID), a.Action, a.Reason, sqlstore.NullInt(a.ProjectID), now())
+		return err
+	})
+}
+
+// unpublishTx ลบ snapshot บน dashboard และปิด show_on_dashboard
+func unpublishTx(ctx context.Context, tx *sql.Tx, userID int) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_profiles WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "UPDATE users SET show_on_dashboard = 0 WHERE user_id = ?", userID)
+	return err
+}
+
+func (s *ModerationStore) ListActions(ctx context.Context, f store.ActionFilter, limit, offset int) ([]store.ModerationAction, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.action_id, COALESCE(m.admin_id, 0), COALESCE(a.email, ''), COALESCE(m.target_user_id, 0),
+			m.action, m.reason, COALESCE(m.project_id, 0), m.created_at
+		FROM moderation_actions m
+		LEFT JOIN users a ON a.user_id = m.admin_id
+		LEFT JOIN users t ON t.user_id = m.target_user_id
+		WHERE (?1 = 0 OR m.target_user_id = ?1)
+			AND (?2 = '' OR LOWER(t.university) = LOWER(?2))
+		ORDER BY m.created_at DESC, m.action_id DESC
+		LIMIT ?3 OFFSET ?4
+	`, f.TargetUserID, f.University, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.ModerationAction{}
+	for rows.Next() {
+		var a store.ModerationAction
+		if err := rows.Scan(&a.ID, &a.AdminID, &a.AdminEmail, &a.TargetUserID, &a.Action, &a.Reason, &a.ProjectID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+)
+
+type OutboxStore struct {
+	db *sql.DB
+}
+
+func (s *OutboxStore) Enqueue(ctx context.Context, m store.OutboxMessage) error {
+	ts := now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO mail_outbox (to_email, subject, html_body, text_body, template, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?)
+	`, m.To, m.Subject, m.HTML, m.Text, m.Template, ts, ts)
+	return err
+}
+
+// Claim — locked_until กันไม่ให้หลาย worker ส่งฉบับเดียวกันซ้ำ (BEGIN IMMEDIATE แทน FOR UPDATE SKIP LOCKED)
+func (s *OutboxStore) Claim(ctx context.Context, lease time.Duration, limit int) ([]store.OutboxMessage, error) {
+	var list []store.OutboxMessage
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ts := now()
+		rows, err := tx.QueryContext(ctx, `
+			SELECT outbox_id, attempts, to_email, subject, html_body, text_body, template
+			FROM mail_outbox
+			WHERE status = 'pending' AND next_attempt_at <= ?
+				AND (locked_until IS NULL OR locked_until < ?)
+			ORDER BY next_attempt_at, outbox_id
+			LIMIT ?
+		`, ts, ts, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var m store.OutboxMessage
+			var html, text, template sql.NullString
+			if err := rows.Scan(&m.ID, &m.Attempts, &m.To, &m.Subject, &html, &text, &template); err != nil {
+				return err
+			}
+			m.HTML, m.Text, m.Template = html.String, text.String, template.String
+			list = append(list, m)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, m := range list {
+			if _, err := tx.ExecContext(ctx, "UPDATE mail_outbox SET locked_until = ? WHERE outbox_id = ?", ts.Add(lease), m.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *OutboxStore) MarkSent(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mail_outbox SET status = 'sent', sent_at = ?, attempts = attempts + 1, locked_until = NULL, last_error = NULL,
+			html_body = NULL, text_body = NULL
+		WHERE outbox_id = ?
+	`, now(), id)
+	return err
+}
+
+func (s *OutboxStore) MarkFailed(ctx context.Context, id int64, attempts int, lastErr string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mail_outbox SET status = 'failed', failed_at = ?, attempts = ?, last_error = ?, locked_until = NULL,
+			html_body = NULL, text_body = NULL
+		WHERE outbox_id = ?
+	`, now(), attempts, lastErr, id)
+	return err
+}
+
+func (s *OutboxStore) MarkRetry(ctx context.Context, id int64, attempts int, lastErr string, retryIn time.Duration) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE mail_outbox SET attempts = ?, last_error = ?, locked_until = NULL, next_attempt_at = ?
+		WHERE outbox_id = ?
+	`, attempts, lastErr, now().Add(retryIn), id)
+	return err
+}
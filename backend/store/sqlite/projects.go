@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type ProjectStore struct {
+	db *sql.DB
+}
+
+func (s *ProjectStore) List(ctx context.Context, userID int) ([]store.Project, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_id, COALESCE(project_name, ''), COALESCE(description, ''), image_url, created_at
+		FROM projects
+		WHERE user_id = ?
+		ORDER BY created_at DESC, project_id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.Project{}
+	for rows.Next() {
+		p := store.Project{UserID: userID}
+		var imageURL sql.NullString
+		if err := rows.Scan(&p.ID, &p.Title, &p.Desc, &imageURL, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Images = sqlstore.DecodeList(imageURL)
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (s *ProjectStore) Get(ctx context.Context, userID, projectID int) (*store.Project, error) {
+	p := store.Project{ID: projectID, UserID: userID}
+	var imageURL sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(project_name, ''), COALESCE(description, ''), image_url, created_at
+		FROM projects
+		WHERE project_id = ? AND user_id = ?
+	`, projectID, userID).Scan(&p.Title, &p.Desc, &imageURL, &p.CreatedAt)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	p.Images = sqlstore.DecodeList(imageURL)
+	return &p, nil
+}
+
+func (s *ProjectStore) Create(ctx context.Context, p *store.Project) error {
+	createdAt := now()
+	id, err := insertID(s.db.ExecContext(ctx, `
+		INSERT INTO projects (user_id, project_name, description, image_url, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, p.UserID, p.Title, p.Desc, sqlstore.EncodeList(p.Images), createdAt))
+	if err != nil {
+		return err
+	}
+	p.ID = id
+	p.CreatedAt = createdAt
+	return nil
+}
+
+func (s *ProjectStore) Update(ctx context.Context, p *store.Project) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx, `
+		UPDATE projects
+		SET project_name = ?, description = ?, image_url = ?
+		WHERE project_id = ? AND user_id = ?
+	`, p.Title, p.Desc, sqlstore.EncodeList(p.Images), p.ID, p.UserID))
+}
+
+func (s *ProjectStore) Delete(ctx context.Context, userID, projectID int) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx,
+		"DELETE FROM projects WHERE project_id = ? AND user_id = ?",
+		projectID, userID,
+	))
+}
@@ -0,0 +1,119 @@
+-- schema ของ STORAGE=sqlite — เฉพาะตารางที่ store ใช้ (2FA / admin / audit / mail_outbox อยู่ใน 0002)
+-- เทียบกับ database/migrations ของ postgres: SERIAL → INTEGER PRIMARY KEY AUTOINCREMENT, BOOLEAN → INTEGER 0/1,
+-- เวลาเก็บเป็น TEXT แบบ UTC ที่ Go เขียนให้ (เทียบกันด้วย < > ได้ตรงๆ)
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_name TEXT,
+    email TEXT UNIQUE NOT NULL COLLATE NOCASE,
+    password_hash TEXT NOT NULL,
+    phone TEXT,
+    university TEXT,
+    faculty TEXT,
+    major TEXT,
+    gpa REAL,
+    job_interest TEXT,
+    profile_image_url TEXT,
+    show_on_dashboard INTEGER NOT NULL DEFAULT 0,
+    verified_at TIMESTAMP, -- NULL = ยังไม่ได้ยืนยันอีเมล
+    role TEXT NOT NULL DEFAULT 'student'
+        CHECK (role IN ('student', 'recruiter', 'university_admin', 'platform_admin')),
+    suspended_at TIMESTAMP,
+    suspension_reason TEXT,
+    locale TEXT NOT NULL DEFAULT 'th',
+    failed_login_count INTEGER NOT NULL DEFAULT 0,
+    last_failed_login_at TIMESTAMP,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_users_show_on_dashboard ON users(show_on_dashboard);
+
+CREATE TABLE IF NOT EXISTS verification_codes (
+    code_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    code TEXT NOT NULL, -- HMAC-SHA256 (hex) ของรหัส
+    type TEXT NOT NULL DEFAULT 'forgot_password',
+    is_used INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    new_email TEXT,
+    expired_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_verification_codes_user_type ON verification_codes(user_id, type);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    token_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expired_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    refresh_token_hash TEXT UNIQUE NOT NULL,
+    previous_token_hash TEXT,
+    user_agent TEXT,
+    ip_address TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expired_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions(previous_token_hash);
+
+CREATE TABLE IF NOT EXISTS projects (
+    project_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    project_name TEXT,
+    description TEXT,
+    image_url TEXT, -- JSON array of images
+    moderated_at TIMESTAMP, -- ไม่ใช่ NULL = admin ลบออกจาก Dashboard แล้ว (publish ใหม่ก็ไม่ขึ้น)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_projects_composite ON projects(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS skills (
+    skill_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    skill_name TEXT UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    skill_id INTEGER REFERENCES skills(skill_id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, skill_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_skills_skill_id ON user_skills(skill_id);
+
+-- snapshot ที่แสดงใน Dashboard — ไม่ใช้ foreign key เหมือน postgres
+CREATE TABLE IF NOT EXISTS published_profiles (
+    user_id INTEGER PRIMARY KEY,
+    user_name TEXT,
+    email TEXT,
+    phone TEXT,
+    university TEXT,
+    faculty TEXT,
+    major TEXT,
+    gpa REAL,
+    job_interest TEXT,
+    profile_image_url TEXT,
+    skills TEXT, -- JSON array of skills
+    published_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_published_profiles_updated_at ON published_profiles(updated_at DESC);
+
+CREATE TABLE IF NOT EXISTS published_projects (
+    published_project_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    project_name TEXT,
+    description TEXT,
+    image_url TEXT,
+    published_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, project_id)
+);
+CREATE INDEX IF NOT EXISTS idx_published_projects_user_id ON published_projects(user_id);
@@ -0,0 +1,81 @@
+-- 2FA, moderation ของ admin, audit event และ mail_outbox — เทียบกับตารางเดียวกันใน database/migrations
+-- (mail_outbox รวมคอลัมน์ template ของ 0003 ไว้แล้ว)
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    action_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    admin_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    target_user_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    action TEXT NOT NULL, -- unpublish_profile | suspend_user | unsuspend_user | delete_published_project
+    reason TEXT NOT NULL,
+    project_id INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_target ON moderation_actions(target_user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id INTEGER PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled_at TIMESTAMP, -- NULL = enroll แล้วแต่ยังไม่ confirm
+    last_used_step INTEGER NOT NULL DEFAULT 0, -- กันใช้รหัส TOTP ซ้ำ
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    code_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+    challenge_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expired_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- user_id ไม่มี FK — ประวัติยังอยู่หลังลบบัญชี
+CREATE TABLE IF NOT EXISTS audit_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER,
+    actor_id INTEGER,
+    action TEXT NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    metadata TEXT NOT NULL DEFAULT '{}', -- JSON object
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_user ON audit_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, created_at DESC);
+
+-- append-only: ห้าม UPDATE / DELETE
+CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TABLE IF NOT EXISTS mail_outbox (
+    outbox_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    to_email TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    html_body TEXT,
+    text_body TEXT,
+    template TEXT,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending | sent | failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL, -- retry ครั้งถัดไป (exponential backoff)
+    locked_until TIMESTAMP, -- worker ที่จองไว้กำลังส่งอยู่
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    failed_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_pending ON mail_outbox(next_attempt_at) WHERE status = 'pending';
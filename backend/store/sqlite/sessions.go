@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type SessionStore struct {
+	db *sql.DB
+}
+
+func (s *SessionStore) Create(ctx context.Context, sess store.Session) error {
+	ts := now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (session_id, user_id, refresh_token_hash, user_agent, ip_address, expired_at, created_at, last_used_at)
+		VALUES (?,?,?,?,?,?,?,?)
+	`, sess.ID, sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddress, sess.ExpiresAt.UTC(), ts, ts)
+	return err
+}
+
+func (s *SessionStore) IsActive(ctx context.Context, sessionID string, userID int) (bool, error) {
+	var active bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT revoked_at IS NULL AND expired_at > ?
+		FROM sessions WHERE session_id = ? AND user_id = ?
+	`, now(), sessionID, userID).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return active, err
+}
+
+func (s *SessionStore) FindByRefreshToken(ctx context.Context, tokenHash string) (*store.RefreshSession, error) {
+	var rs store.RefreshSession
+	// อ่าน role ล่าสุดจาก users เพื่อให้การเปลี่ยน role มีผลตั้งแต่ token ถัดไป
+	err := s.db.QueryRowContext(ctx, `
+		SELECT s.session_id, s.user_id, u.role,
+			s.revoked_at IS NULL AND s.expired_at > ? AND u.suspended_at IS NULL
+		FROM sessions s JOIN users u ON u.user_id = s.user_id
+		WHERE s.refresh_token_hash=?
+	`, now(), tokenHash).Scan(&rs.SessionID, &rs.UserID, &rs.Role, &rs.Active)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	return &rs, nil
+}
+
+func (s *SessionStore) RevokeReused(ctx context.Context, tokenHash string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var sessionID string
+	var userID int
+	err = tx.QueryRowContext(ctx,
+		"SELECT session_id, user_id FROM sessions WHERE previous_token_hash=? AND revoked_at IS NULL",
+		tokenHash,
+	).Scan(&sessionID, &userID)
+	if err != nil {
+		return 0, sqlstore.NotFound(err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at=? WHERE session_id=?", now(), sessionID); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+func (s *SessionStore) Rotate(ctx context.Context, oldHash string, next store.Session) error {
+	// เงื่อนไข refresh_token_hash กันไม่ให้ 2 request หมุนพร้อมกันได้ทั้งคู่
+	return sqlstore.RequireRow(s.db.ExecContext(ctx, `
+		UPDATE sessions SET
+			previous_token_hash=refresh_token_hash,
+			refresh_token_hash=?,
+			last_used_at=?,
+			expired_at=?,
+			ip_address=?,
+			user_agent=?
+		WHERE session_id=? AND refresh_token_hash=?
+	`, next.RefreshTokenHash, now(), next.ExpiresAt.UTC(), next.IPAddress, next.UserAgent, next.ID, oldHash))
+}
+
+func (s *SessionStore) Revoke(ctx context.Context, sessionID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET revoked_at=? WHERE session_id=? AND revoked_at IS NULL", now(), sessionID)
+	return err
+}
+
+func (s *SessionStore) ListActive(ctx context.Context, userID int) ([]store.SessionInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expired_at
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expired_at > ?
+		ORDER BY last_used_at DESC
+	`, userID, now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.SessionInfo{}
+	for rows.Next() {
+		var si store.SessionInfo
+		if err := rows.Scan(&si.ID, &si.UserAgent, &si.IPAddress, &si.CreatedAt, &si.LastUsedAt, &si.ExpiresAt); err != nil {
+			return nil, err
+		}
+		list = append(list, si)
+	}
+	return list, rows.Err()
+}
+
+func (s *SessionStore) RevokeOthers(ctx context.Context, userID int, keepSessionID string) (int, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=? WHERE user_id=? AND session_id<>? AND revoked_at IS NULL",
+		now(), userID, keepSessionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+func (s *SessionStore) RevokeForUser(ctx context.Context, userID int, sessionID string) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=? WHERE session_id=? AND user_id=? AND revoked_at IS NULL",
+		now(), sessionID, userID,
+	))
+}
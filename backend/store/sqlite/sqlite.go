@@ -0,0 +1,199 @@
+// Package sqlite คือ implementation ของ store บน SQLite (STORAGE=sqlite) สำหรับ deploy แบบไฟล์เดียวไม่ต้องมี Postgres
+//
+// SQL ในแพ็กเกจนี้ไม่ใช้ของเฉพาะ Postgres: ไม่มี RETURNING (ใช้ LastInsertId / SELECT ใน transaction เดียวกัน),
+// ไม่มี ON CONFLICT (UPDATE ก่อน ถ้าไม่โดนแถวไหนค่อย INSERT), ไม่มี json_agg (รวม list ใน Go แล้ว sqlstore.EncodeList)
+// และไม่มี NOW() — เวลาทุกค่ามาจาก Go แบบ UTC
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// ไฟล์ schema ชื่อ <version>_<name>.sql — version ที่ apply แล้วเก็บใน PRAGMA user_version
+//
+//go:embed schema/*.sql
+var schemaFiles embed.FS
+
+// DSN สร้าง connection string ของ modernc.org/sqlite — เปิด foreign key (ON DELETE CASCADE), WAL
+// และ BEGIN IMMEDIATE เพื่อให้ transaction ที่เขียนพร้อมกันรอกันตาม busy_timeout แทนที่จะ error ทันที
+func DSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_txlock", "immediate")
+	q.Set("_time_format", "sqlite")
+	return "file:" + path + "?" + q.Encode()
+}
+
+// Open เปิด database ที่ path แล้ว Migrate — path ":memory:" ได้ database ว่างที่หายไปเมื่อปิด (ใช้ใน test)
+// server ไม่ได้ใช้ Open เพราะต้องเปิดผ่าน tracing.OpenDB ให้ query อยู่ใน trace
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", DSN(path))
+	if err != nil {
+		return nil, err
+	}
+	// :memory: คือ database แยกของแต่ละ connection — ทั้ง pool ต้องใช้ connection เดียวกัน
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+	if _, err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate apply ไฟล์ใน schema/ ที่ยังไม่เคย apply — server เรียกทุกครั้งที่เริ่มเมื่อ STORAGE=sqlite
+func Migrate(ctx context.Context, db *sql.DB) (applied []string, err error) {
+	entries, err := fs.ReadDir(schemaFiles, "schema")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var current int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&current); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return applied, fmt.Errorf("sqlite schema: ชื่อไฟล์ไม่ถูกต้อง %q", entry.Name())
+		}
+		if version <= current {
+			continue
+		}
+
+		raw, err := schemaFiles.ReadFile("schema/" + entry.Name())
+		if err != nil {
+			return applied, err
+		}
+		if err := withTx(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(raw)); err != nil {
+				return err
+			}
+			// PRAGMA รับ parameter ไม่ได้ — version เป็นตัวเลขจากชื่อไฟล์จึงต่อ string ได้
+			_, err := tx.ExecContext(ctx, "PRAGMA user_version = "+strconv.Itoa(version))
+			return err
+		}); err != nil {
+			return applied, fmt.Errorf("sqlite schema %s: %w", name, err)
+		}
+		applied = append(applied, name)
+	}
+	return applied, nil
+}
+
+// New สร้าง store ทุกตัวบน connection pool เดียวกัน (db ต้องเปิดด้วย DSN และผ่าน Migrate แล้ว)
+func New(db *sql.DB) *store.Store {
+	return &store.Store{
+		Users:      &UserStore{db: db},
+		Projects:   &ProjectStore{db: db},
+		Dashboard:  &DashboardStore{db: db},
+		Codes:      &CodeStore{db: db},
+		Sessions:   &SessionStore{db: db},
+		TwoFactor:  &TwoFactorStore{db: db},
+		Moderation: &ModerationStore{db: db},
+		Audit:      &AuditStore{db: db},
+		Outbox:     &OutboxStore{db: db},
+	}
+}
+
+// now คือเวลาปัจจุบันที่ใช้แทน NOW() ของ postgres
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// insertID รัน INSERT แล้วคืน id ของแถวใหม่ (แทน RETURNING)
+func insertID(result sql.Result, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// replaceSkills แทนที่ skills ของ user ทั้งชุด — skill_name เป็น COLLATE NOCASE จึงไม่สนตัวพิมพ์เหมือน postgres
+func replaceSkills(ctx context.Context, tx *sql.Tx, userID int, skills []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_skills WHERE user_id=?", userID); err != nil {
+		return err
+	}
+
+	for _, s := range skills {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		var skillID int
+		err := tx.QueryRowContext(ctx, "SELECT skill_id FROM skills WHERE skill_name=?", s).Scan(&skillID)
+		if errors.Is(err, sql.ErrNoRows) {
+			skillID, err = insertID(tx.ExecContext(ctx, "INSERT INTO skills (skill_name) VALUES (?)", s))
+		}
+		if err != nil {
+			return err
+		}
+
+		// skill ซ้ำในรายการเดียวกัน (เช่น "Go", "go") — แทน ON CONFLICT DO NOTHING
+		var exists bool
+		if err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM user_skills WHERE user_id=? AND skill_id=?)", userID, skillID,
+		).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO user_skills (user_id, skill_id) VALUES (?,?)", userID, skillID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// querySkills ใช้ได้ทั้ง *sql.DB และ *sql.Tx
+func querySkills(ctx context.Context, q sqlstore.Querier, userID int) ([]string, error) {
+	return sqlstore.Strings(ctx, q, `
+		SELECT s.skill_name
+		FROM user_skills us
+		JOIN skills s ON us.skill_id = s.skill_id
+		WHERE us.user_id = ?
+	`, userID)
+}
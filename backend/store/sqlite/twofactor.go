@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type TwoFactorStore struct {
+	db *sql.DB
+}
+
+func (s *TwoFactorStore) Get(ctx context.Context, userID int) (*store.TOTP, error) {
+	var t store.TOTP
+	err := s.db.QueryRowContext(ctx,
+		"SELECT secret_encrypted, enabled_at IS NOT NULL, last_used_step FROM user_totp WHERE user_id=?",
+		userID,
+	).Scan(&t.SecretEncrypted, &t.Enabled, &t.LastUsedStep)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	return &t, nil
+}
+
+func (s *TwoFactorStore) Enroll(ctx context.Context, userID int, secretEncrypted string) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		// แทน ON CONFLICT — enroll ซ้ำก่อน confirm แทนที่ secret เดิม
+		result, err := tx.ExecContext(ctx,
+			"UPDATE user_totp SET secret_encrypted=?, enabled_at=NULL, last_used_step=0, created_at=? WHERE user_id=?",
+			secretEncrypted, now(), userID,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO user_totp (user_id, secret_encrypted, enabled_at, last_used_step, created_at) VALUES (?,?,NULL,0,?)",
+			userID, secretEncrypted, now(),
+		)
+		return err
+	})
+}
+
+func (s *TwoFactorStore) Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := sqlstore.RequireRow(tx.ExecContext(ctx, "UPDATE user_totp SET enabled_at=?, last_used_step=? WHERE user_id=?", now(), step, userID)); err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	})
+}
+
+func (s *TwoFactorStore) Disable(ctx context.Context, userID int) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id=?", userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=?", userID)
+		return err
+	})
+}
+
+func (s *TwoFactorStore) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	})
+}
+
+// replaceRecoveryCodes ลบ recovery codes เดิมแล้วเพิ่มชุดใหม่ (เก็บเฉพาะ hash)
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=?", userID); err != nil {
+		return err
+	}
+	ts := now()
+	for _, h := range codeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES (?,?,?)", userID, h, ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TwoFactorStore) RemainingRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM recovery_codes WHERE user_id=? AND used_at IS NULL", userID).Scan(&n)
+	return n, err
+}
+
+func (s *TwoFactorStore) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE recovery_codes SET used_at=? WHERE user_id=? AND code_hash=? AND used_at IS NULL",
+		now(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n == 1, nil
+}
+
+func (s *TwoFactorStore) AdvanceStep(ctx context.Context, userID int, step int64) (bool, error) {
+	// เงื่อนไข last_used_step < step กัน request ที่ใช้รหัสเดียวกันพร้อมกัน
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_used_step=? WHERE user_id=? AND last_used_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n == 1, nil
+}
+
+func (s *TwoFactorStore) CreateChallenge(ctx context.Context, challengeHash string, userID int, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO login_challenges (challenge_hash, user_id, expired_at, created_at) VALUES (?,?,?,?)",
+		challengeHash, userID, expiresAt.UTC(), now(),
+	)
+	return err
+}
+
+func (s *TwoFactorStore) ReserveChallenge(ctx context.Context, challengeHash string, maxAttempts int) (int, error) {
+	var userID int
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		// BEGIN IMMEDIATE — request ที่ยิงพร้อมกันจองสิทธิ์ทีละตัว (แทน UPDATE ... RETURNING)
+		err := tx.QueryRowContext(ctx, `
+			SELECT user_id FROM login_challenges
+			WHERE challenge_hash=? AND used_at IS NULL AND expired_at > ? AND attempts < ?
+		`, challengeHash, now(), maxAttempts).Scan(&userID)
+		if err != nil {
+			return sqlstore.NotFound(err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE login_challenges SET attempts=attempts+1 WHERE challenge_hash=?", challengeHash)
+		return err
+	})
+	return userID, err
+}
+
+func (s *TwoFactorStore) UseChallenge(ctx context.Context, challengeHash string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE login_challenges SET used_at=? WHERE challenge_hash=? AND used_at IS NULL", now(), challengeHash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,370 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/store"
+	"backend/store/sqlstore"
+)
+
+type UserStore struct {
+	db *sql.DB
+}
+
+const userColumns = `
+	user_id, email, password_hash, COALESCE(user_name, ''), COALESCE(phone, ''),
+	COALESCE(university, ''), COALESCE(faculty, ''), COALESCE(major, ''), gpa,
+	COALESCE(job_interest, ''), COALESCE(profile_image_url, ''), role, locale,
+	verified_at IS NOT NULL, suspended_at IS NOT NULL, show_on_dashboard,
+	locked_until`
+
+// scanUser อ่าน userColumns จาก *sql.Row หรือ *sql.Rows
+func scanUser(row sqlstore.Scanner) (*store.User, error) {
+	var u store.User
+	var gpa sql.NullFloat64
+	var lockedUntil sql.NullTime
+	err := row.Scan(
+		&u.ID, &u.Email, &u.PasswordHash, &u.UserName, &u.Phone,
+		&u.University, &u.Faculty, &u.Major, &gpa,
+		&u.JobInterest, &u.ProfileImageURL, &u.Role, &u.Locale,
+		&u.EmailVerified, &u.Suspended, &u.ShowOnDashboard,
+		&lockedUntil,
+	)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	u.GPA = gpa.Float64
+	if wait := lockedUntil.Time.Sub(now()); lockedUntil.Valid && wait > 0 {
+		u.LockedFor = (wait + time.Second - 1).Truncate(time.Second) // ปัดขึ้นเป็นวินาทีเหมือน CEIL ใน postgres
+	}
+	return &u, nil
+}
+
+func (s *UserStore) Create(ctx context.Context, u store.NewUser) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	userID, err := insertID(tx.ExecContext(ctx, `
+		INSERT INTO users
+		(email, password_hash, user_name, phone, university, faculty, major, gpa, job_interest, role, locale, created_at)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?)
+	`, u.Email, u.PasswordHash, u.UserName, u.Phone, u.University, u.Faculty, u.Major, u.GPA, u.JobInterest, u.Role, u.Locale, now()))
+	if isUniqueViolation(err) {
+		return 0, store.ErrEmailTaken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := replaceSkills(ctx, tx, userID, u.Skills); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+func (s *UserStore) Get(ctx context.Context, userID int) (*store.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE user_id=?", userID))
+	if err != nil {
+		return nil, err
+	}
+	if u.Skills, err = querySkills(ctx, s.db, userID); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	// email เป็น COLLATE NOCASE — ไม่ต้อง LOWER() และยังใช้ unique index ได้
+	return scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email=?", email))
+}
+
+func (s *UserStore) Skills(ctx context.Context, userID int) ([]string, error) {
+	return querySkills(ctx, s.db, userID)
+}
+
+func (s *UserStore) UpdateProfile(ctx context.Context, userID int, p store.ProfileUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users SET
+			user_name=?, phone=?, university=?,
+			faculty=?, major=?, gpa=?,
+			job_interest=?, profile_image_url=?,
+			locale=COALESCE(NULLIF(?, ''), locale)
+		WHERE user_id=?
+	`, p.UserName, p.Phone, p.University, p.Faculty, p.Major, p.GPA, p.JobInterest, p.ProfileImageURL, p.Locale, userID)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+
+	if err := replaceSkills(ctx, tx, userID, p.Skills); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) Delete(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// snapshot บน dashboard ไม่มี foreign key ไปที่ users จึงต้องลบเอง
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_profiles WHERE user_id=?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM published_projects WHERE user_id=?", userID); err != nil {
+		return err
+	}
+
+	// projects, user_skills, verification_codes, sessions ถูกลบตาม ON DELETE CASCADE (DSN เปิด foreign_keys ไว้)
+	result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE user_id=?", userID)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) List(ctx context.Context) ([]store.User, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []store.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *u)
+	}
+	return list, rows.Err()
+}
+
+func (s *UserStore) SetRole(ctx context.Context, userID int, role string) error {
+	return sqlstore.RequireRow(s.db.ExecContext(ctx, "UPDATE users SET role=? WHERE user_id=?", role, userID))
+}
+
+func (s *UserStore) SetPassword(ctx context.Context, userID int, passwordHash string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE users SET password_hash=?, failed_login_count=0, locked_until=NULL WHERE user_id=?",
+		passwordHash, userID,
+	)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at=? WHERE user_id=? AND revoked_at IS NULL", now(), userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=?", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) RecordLoginFailure(ctx context.Context, userID int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE users SET failed_login_count=failed_login_count+1, last_failed_login_at=? WHERE user_id=?",
+		now(), userID,
+	)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return 0, err
+	}
+
+	var failures int
+	if err := tx.QueryRowContext(ctx, "SELECT failed_login_count FROM users WHERE user_id=?", userID).Scan(&failures); err != nil {
+		return 0, sqlstore.NotFound(err)
+	}
+	return failures, tx.Commit()
+}
+
+func (s *UserStore) Lock(ctx context.Context, userID int, d time.Duration) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET locked_until=? WHERE user_id=?",
+		now().Add(d.Truncate(time.Second)), userID,
+	)
+	return err
+}
+
+func (s *UserStore) ClearLoginFailures(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET failed_login_count=0, locked_until=NULL WHERE user_id=? AND (failed_login_count > 0 OR locked_until IS NOT NULL)",
+		userID,
+	)
+	return err
+}
+
+func (s *UserStore) VerifyEmail(ctx context.Context, userID, codeID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE verification_codes SET is_used=1 WHERE code_id=?", codeID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET verified_at=? WHERE user_id=? AND verified_at IS NULL", now(), userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *UserStore) ResetPassword(ctx context.Context, resetTokenHash, passwordHash string) (*store.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// ใช้ token ได้ครั้งเดียวและต้องยังไม่หมดอายุ — BEGIN IMMEDIATE ทำให้ SELECT แล้ว UPDATE ไม่มี request อื่นแทรก
+	ts := now()
+	var u store.User
+	err = tx.QueryRowContext(ctx,
+		"SELECT user_id FROM password_reset_tokens WHERE token_hash=? AND used_at IS NULL AND expired_at > ?",
+		resetTokenHash, ts,
+	).Scan(&u.ID)
+	if err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE password_reset_tokens SET used_at=? WHERE token_hash=?", ts, resetTokenHash); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE users SET password_hash=?, failed_login_count=0, locked_until=NULL WHERE user_id=?",
+		passwordHash, u.ID,
+	)
+	if err := sqlstore.RequireRow(result, err); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT email, locale FROM users WHERE user_id=?", u.ID).Scan(&u.Email, &u.Locale); err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+
+	// revoke ทุก session เพื่อให้ access/refresh token ที่ออกไปก่อนหน้านี้ใช้ไม่ได้ทั้งหมด
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at=? WHERE user_id=? AND revoked_at IS NULL", ts, u.ID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=? AND type=?", u.ID, store.CodeForgotPassword); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *UserStore) ChangePassword(ctx context.Context, userID int, keepSessionID, passwordHash string) (*store.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u := store.User{ID: userID}
+	if err := sqlstore.RequireRow(tx.ExecContext(ctx, "UPDATE users SET password_hash=?, failed_login_count=0, locked_until=NULL WHERE user_id=?", passwordHash, userID)); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT email, locale FROM users WHERE user_id=?", userID).Scan(&u.Email, &u.Locale); err != nil {
+		return nil, sqlstore.NotFound(err)
+	}
+
+	// เครื่องที่เปลี่ยนรหัสยัง login อยู่ — เครื่องอื่นต้อง login ใหม่ด้วยรหัสผ่านใหม่
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at=? WHERE user_id=? AND session_id<>? AND revoked_at IS NULL",
+		now(), userID, keepSessionID,
+	); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=?", userID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=? AND type=?", userID, store.CodeForgotPassword); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *UserStore) ChangeEmail(ctx context.Context, userID, codeID int) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var newEmail sql.NullString
+	err = tx.QueryRowContext(ctx,
+		"SELECT new_email FROM verification_codes WHERE code_id=? AND user_id=? AND is_used=0",
+		codeID, userID,
+	).Scan(&newEmail)
+	if err != nil {
+		return "", sqlstore.NotFound(err)
+	}
+	if newEmail.String == "" {
+		return "", store.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE verification_codes SET is_used=1 WHERE code_id=?", codeID); err != nil {
+		return "", err
+	}
+
+	// users.email เป็น UNIQUE — มีคนสมัครด้วยอีเมลนี้ระหว่างรอยืนยัน
+	ts := now()
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email=?, verified_at=? WHERE user_id=?", newEmail.String, ts, userID)
+	if isUniqueViolation(err) {
+		return "", store.ErrEmailTaken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// published_projects ไม่มีคอลัมน์อีเมล จึงแก้แค่ published_profiles
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE published_profiles SET email=?, updated_at=? WHERE user_id=?",
+		newEmail.String, ts, userID,
+	); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verification_codes WHERE user_id=? AND code_id<>?", userID, codeID); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id=?", userID); err != nil {
+		return "", err
+	}
+
+	return newEmail.String, tx.Commit()
+}
@@ -0,0 +1,103 @@
+// Package sqlstore รวม helper ที่ store แบบ SQL (postgres, sqlite) ใช้ร่วมกันและไม่ขึ้นกับ dialect
+//
+// อะไรที่ต่างกันตาม dialect (placeholder, RETURNING, ON CONFLICT, error code ของ unique constraint)
+// ยังอยู่ในแพ็กเกจของแต่ละ backend
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"backend/store"
+)
+
+// Querier ใช้ได้ทั้ง *sql.DB และ *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Scanner ใช้ได้ทั้ง *sql.Row และ *sql.Rows
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// NotFound แปลง sql.ErrNoRows เป็น store.ErrNotFound
+func NotFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// RequireRow คืน store.ErrNotFound ถ้า exec ไม่โดนแถวไหนเลย
+func RequireRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// DecodeList อ่าน JSON array ของ string (image_url, skills) — ว่างหรืออ่านไม่ได้คืน slice ว่าง
+func DecodeList(s sql.NullString) []string {
+	list := []string{}
+	if s.Valid && s.String != "" {
+		_ = json.Unmarshal([]byte(s.String), &list)
+	}
+	if list == nil {
+		list = []string{}
+	}
+	return list
+}
+
+// EncodeList เขียน list เป็น JSON array — nil ได้ "[]"
+func EncodeList(list []string) string {
+	if list == nil {
+		return "[]"
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// NullInt เก็บ 0 เป็น NULL (ไม่ทราบ user / บัญชีถูกลบไปแล้ว)
+func NullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+// Strings รัน query ที่คืนคอลัมน์ string คอลัมน์เดียว — ไม่มีแถวได้ slice ว่าง (ไม่ใช่ nil)
+func Strings(ctx context.Context, q Querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []string{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// ScanUserSummary อ่านคอลัมน์ของ store.UserSummary ตามลำดับใน SELECT — extra ต่อท้ายสำหรับคอลัมน์เพิ่มเติม
+func ScanUserSummary(row Scanner, extra ...interface{}) (*store.UserSummary, error) {
+	var u store.UserSummary
+	var suspendedAt, createdAt sql.NullTime
+	dest := append([]interface{}{
+		&u.ID, &u.UserName, &u.Email, &u.University, &u.Role,
+		&u.EmailVerified, &u.ShowOnDashboard, &suspendedAt, &createdAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, NotFound(err)
+	}
+	u.SuspendedAt, u.CreatedAt = suspendedAt.Time, createdAt.Time
+	return &u, nil
+}
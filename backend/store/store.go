@@ -0,0 +1,219 @@
+// Package store คือชั้นข้อมูลของ backend — handler / controller เรียกผ่าน interface ในไฟล์นี้
+// แทนการเขียน SQL เอง implementation อยู่ใน store/postgres, store/sqlite และ store/memory
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound — ไม่พบแถวที่ต้องการ (หรือไม่ใช่ของ user คนนั้น)
+	ErrNotFound = errors.New("store: not found")
+	// ErrEmailTaken — มีบัญชีที่ใช้อีเมลนี้แล้ว
+	ErrEmailTaken = errors.New("store: email already registered")
+)
+
+// ประเภทของรหัสใน verification_codes.type
+const (
+	CodeForgotPassword = "forgot_password"
+	CodeEmailVerify    = "email_verify"
+	CodeEmailChange    = "email_change"
+)
+
+// moderation_actions.action
+const (
+	ModUnpublishProfile       = "unpublish_profile"
+	ModSuspendUser            = "suspend_user"
+	ModUnsuspendUser          = "unsuspend_user"
+	ModDeletePublishedProject = "delete_published_project"
+)
+
+// สถานะที่ใช้กรองใน ModerationStore.ListUsers
+const (
+	UserStatusActive     = "active"
+	UserStatusSuspended  = "suspended"
+	UserStatusUnverified = "unverified"
+	UserStatusPublished  = "published"
+)
+
+// Store รวม store ทุกตัวที่ handler ใช้ — สร้างด้วย postgres.New, sqlite.New หรือ memory.New (ทุก backend มีครบทุกตัว)
+type Store struct {
+	Users      UserStore
+	Projects   ProjectStore
+	Dashboard  DashboardStore
+	Codes      CodeStore
+	Sessions   SessionStore
+	TwoFactor  TwoFactorStore
+	Moderation ModerationStore
+	Audit      AuditStore
+	Outbox     OutboxStore
+}
+
+// UserStore จัดการบัญชีผู้ใช้, skills และสถานะการ login
+type UserStore interface {
+	// Create สร้างบัญชีพร้อม skills คืน user_id — อีเมลซ้ำคืน ErrEmailTaken
+	Create(ctx context.Context, u NewUser) (int, error)
+	// Get คืนข้อมูลผู้ใช้พร้อม skills
+	Get(ctx context.Context, userID int) (*User, error)
+	// GetByEmail หาด้วยอีเมล (ไม่สนตัวพิมพ์) ไม่โหลด skills
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	Skills(ctx context.Context, userID int) ([]string, error)
+	// UpdateProfile แก้ข้อมูลส่วนตัวและแทนที่ skills ทั้งชุด
+	UpdateProfile(ctx context.Context, userID int, p ProfileUpdate) error
+	// Delete ลบบัญชีและ snapshot บน dashboard (ข้อมูลอื่นถูกลบตาม foreign key)
+	Delete(ctx context.Context, userID int) error
+	// List คืนผู้ใช้ทั้งหมดเรียงตาม user_id ไม่โหลด skills (admin CLI)
+	List(ctx context.Context) ([]User, error)
+	// SetRole เปลี่ยนบทบาท (role ต้องผ่าน utils.IsValidRole แล้ว)
+	SetRole(ctx context.Context, userID int, role string) error
+	// SetPassword ตั้งรหัสผ่านใหม่โดยไม่ต้องมี reset token (admin CLI) ล้างการล็อก
+	// revoke ทุก session และยกเลิก reset token ที่ค้าง
+	SetPassword(ctx context.Context, userID int, passwordHash string) error
+
+	// RecordLoginFailure นับรหัสผ่านผิดติดกันเพิ่ม 1 แล้วคืนจำนวนล่าสุด
+	RecordLoginFailure(ctx context.Context, userID int) (int, error)
+	// Lock ล็อกบัญชีไว้ d นับจากตอนนี้
+	Lock(ctx context.Context, userID int, d time.Duration) error
+	// ClearLoginFailures ล้างตัวนับรหัสผิดและการล็อกหลัง login สำเร็จ
+	ClearLoginFailures(ctx context.Context, userID int) error
+
+	// VerifyEmail ใช้รหัส codeID แล้วตั้งบัญชีเป็นยืนยันอีเมลแล้ว (ใน transaction เดียว)
+	VerifyEmail(ctx context.Context, userID, codeID int) error
+	// ResetPassword ใช้ reset token (ครั้งเดียว ยังไม่หมดอายุ) ตั้งรหัสผ่านใหม่ ล้างการล็อก
+	// revoke ทุก session และลบรหัส forgot_password ที่ค้าง — token ใช้ไม่ได้คืน ErrNotFound
+	ResetPassword(ctx context.Context, resetTokenHash, passwordHash string) (*User, error)
+	// ChangePassword ตั้งรหัสผ่านใหม่จากหน้าโปรไฟล์ ล้างการล็อก revoke ทุก session ยกเว้น keepSessionID
+	// และยกเลิก reset token / รหัส forgot_password ที่ค้าง — คืน email และ locale ไว้ส่งอีเมลแจ้ง
+	ChangePassword(ctx context.Context, userID int, keepSessionID, passwordHash string) (*User, error)
+	// ChangeEmail ใช้รหัส email_change codeID แล้วเปลี่ยนอีเมลเป็น new_email ของรหัสนั้น (ถือว่ายืนยันแล้ว)
+	// พร้อมแก้อีเมลใน snapshot บน dashboard และยกเลิกรหัส / reset token อื่นที่ส่งไปอีเมลเดิม
+	// รหัสถูกใช้ไปแล้วคืน ErrNotFound, มีคนใช้อีเมลใหม่ไปก่อนคืน ErrEmailTaken
+	ChangeEmail(ctx context.Context, userID, codeID int) (string, error)
+}
+
+// ProjectStore จัดการโปรเจคของ student — ทุก method จำกัดเฉพาะโปรเจคของ userID
+type ProjectStore interface {
+	List(ctx context.Context, userID int) ([]Project, error)
+	Get(ctx context.Context, userID, projectID int) (*Project, error)
+	// Create บันทึกโปรเจคใหม่แล้วเติม p.ID
+	Create(ctx context.Context, p *Project) error
+	Update(ctx context.Context, p *Project) error
+	Delete(ctx context.Context, userID, projectID int) error
+}
+
+// DashboardStore จัดการ snapshot ที่ publish ขึ้น dashboard (published_profiles / published_projects)
+type DashboardStore interface {
+	// List คืน profile ที่ publish ล่าสุดก่อน ไม่เกิน limit — excludeUserID > 0 จะไม่รวมคนนั้น
+	List(ctx context.Context, excludeUserID, limit int) ([]ProfileSummary, error)
+	// Get คืน profile ที่ publish พร้อมโปรเจค — ยังไม่ publish คืน ErrNotFound
+	Get(ctx context.Context, userID int) (*PublishedProfile, error)
+	// Publish คัดลอก profile, skills และโปรเจคปัจจุบันเป็น snapshot แล้วตั้ง show_on_dashboard
+	// (ข้ามโปรเจคที่ admin เคยลบออกจาก Dashboard ด้วย RemovePublishedProject)
+	Publish(ctx context.Context, userID int) error
+	// Unpublish ลบ snapshot และปิด show_on_dashboard
+	Unpublish(ctx context.Context, userID int) error
+}
+
+// CodeStore จัดการรหัส OTP (verification_codes) และ reset token
+type CodeStore interface {
+	// Issue แทนที่รหัสเดิมของ user ในประเภทเดียวกันด้วยรหัสใหม่ (codeHash = utils.HashOTP)
+	Issue(ctx context.Context, userID int, codeType, codeHash string, expiresAt time.Time) error
+	// Reserve จองสิทธิ์ตรวจรหัสล่าสุดที่ยังใช้ได้ของ email + type 1 ครั้ง (attempts+1 แบบ atomic)
+	// ไม่มีรหัส หรือครบ maxAttempts แล้วคืน ErrNotFound
+	Reserve(ctx context.Context, email, codeType string, maxAttempts int) (*VerificationCode, error)
+	// MarkUsed ใช้รหัส — คืน false ถ้าถูกใช้ไปก่อนแล้ว
+	MarkUsed(ctx context.Context, codeID int) (bool, error)
+	// CreateResetToken ใช้รหัส forgot_password แล้วออก reset token ใหม่แทน token เดิมของ user
+	// รหัสถูกใช้ไปก่อนแล้วคืน ErrNotFound
+	CreateResetToken(ctx context.Context, codeID, userID int, tokenHash string, expiresAt time.Time) error
+	// IssueEmailChange เหมือน Issue(CodeEmailChange) แต่เก็บอีเมลใหม่ที่รอยืนยันไว้กับรหัสด้วย
+	IssueEmailChange(ctx context.Context, userID int, newEmail, codeHash string, expiresAt time.Time) error
+	// PurgeExpired ลบรหัสและ reset token ที่หมดอายุหรือใช้ไปแล้ว คืนจำนวนที่ลบของแต่ละตาราง
+	PurgeExpired(ctx context.Context) (codes, resetTokens int, err error)
+}
+
+// SessionStore จัดการ session ของการ login (1 แถวต่อเครื่อง) และ refresh token
+type SessionStore interface {
+	Create(ctx context.Context, s Session) error
+	// IsActive — session ยังไม่หมดอายุ ไม่ถูก revoke และเป็นของ userID
+	IsActive(ctx context.Context, sessionID string, userID int) (bool, error)
+	// FindByRefreshToken หา session จาก hash ของ refresh token ปัจจุบัน
+	FindByRefreshToken(ctx context.Context, tokenHash string) (*RefreshSession, error)
+	// RevokeReused revoke session ที่ refresh token ก่อนหน้าเป็น tokenHash (token เก่าถูกนำกลับมาใช้) คืน user_id
+	RevokeReused(ctx context.Context, tokenHash string) (int, error)
+	// Rotate เปลี่ยน refresh token จาก oldHash เป็น next.RefreshTokenHash
+	// ถ้า request อื่นหมุนไปก่อนแล้วคืน ErrNotFound
+	Rotate(ctx context.Context, oldHash string, next Session) error
+	Revoke(ctx context.Context, sessionID string) error
+	// ListActive คืน session ที่ยังใช้ได้ของ user ใช้ล่าสุดก่อน
+	ListActive(ctx context.Context, userID int) ([]SessionInfo, error)
+	// RevokeOthers revoke ทุก session ของ user ยกเว้น keepSessionID คืนจำนวนที่ revoke
+	RevokeOthers(ctx context.Context, userID int, keepSessionID string) (int, error)
+	// RevokeForUser revoke session เดียวที่เป็นของ userID — ไม่พบหรือถูก revoke ไปแล้วคืน ErrNotFound
+	RevokeForUser(ctx context.Context, userID int, sessionID string) error
+}
+
+// TwoFactorStore จัดการ TOTP (user_totp), recovery codes และ challenge ของขั้นที่สองตอน login
+type TwoFactorStore interface {
+	// Get คืน secret และสถานะ — ยังไม่เคย enroll คืน ErrNotFound
+	Get(ctx context.Context, userID int) (*TOTP, error)
+	// Enroll แทนที่ secret เดิมด้วย secret ใหม่ที่ยังไม่เปิดใช้
+	Enroll(ctx context.Context, userID int, secretEncrypted string) error
+	// Enable เปิดใช้ 2FA บันทึก step ของรหัสแรก และแทนที่ recovery codes ทั้งชุด (ใน transaction เดียว)
+	Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	// Disable ลบ secret และ recovery codes ทั้งหมด
+	Disable(ctx context.Context, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	RemainingRecoveryCodes(ctx context.Context, userID int) (int, error)
+	// UseRecoveryCode ใช้ recovery code — คืน false ถ้าไม่มีหรือถูกใช้ไปแล้ว
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	// AdvanceStep บันทึก step ของรหัส TOTP ที่เพิ่งใช้ — คืน false ถ้ามี request อื่นใช้ step นี้หรือใหม่กว่าไปก่อน
+	AdvanceStep(ctx context.Context, userID int, step int64) (bool, error)
+
+	CreateChallenge(ctx context.Context, challengeHash string, userID int, expiresAt time.Time) error
+	// ReserveChallenge นับ attempts+1 แบบ atomic แล้วคืน user_id
+	// challenge ถูกใช้แล้ว หมดอายุ หรือครบ maxAttempts คืน ErrNotFound
+	ReserveChallenge(ctx context.Context, challengeHash string, maxAttempts int) (int, error)
+	// UseChallenge ใช้ challenge — คืน false ถ้าถูกใช้ไปก่อนแล้ว
+	UseChallenge(ctx context.Context, challengeHash string) (bool, error)
+}
+
+// ModerationStore คือข้อมูลของ admin API — ทุกการกระทำบันทึกลง moderation_actions ใน transaction เดียวกัน
+type ModerationStore interface {
+	// ListUsers ค้นหาผู้ใช้ใหม่สุดก่อน คืนรายการหน้านี้และจำนวนทั้งหมดที่ตรงเงื่อนไข
+	ListUsers(ctx context.Context, f UserFilter, limit, offset int) ([]UserSummary, int, error)
+	// GetUser คืนสถานะบัญชีและจำนวนโปรเจค — ไม่พบคืน ErrNotFound
+	GetUser(ctx context.Context, userID int) (*UserDetail, error)
+	// Unpublish ลบ snapshot ของ a.TargetUserID ออกจาก dashboard
+	Unpublish(ctx context.Context, a ModerationAction) error
+	// Suspend ระงับบัญชี ลบออกจาก dashboard และ revoke ทุก session
+	Suspend(ctx context.Context, a ModerationAction) error
+	// Unsuspend ยกเลิกการระงับ (profile ยังไม่ขึ้น dashboard จนกว่าเจ้าของจะ publish ใหม่)
+	Unsuspend(ctx context.Context, a ModerationAction) error
+	// RemovePublishedProject ลบโปรเจค a.ProjectID ออกจาก snapshot และตั้ง flag ให้ Publish ครั้งต่อไปข้ามโปรเจคนี้
+	// — ไม่อยู่ใน snapshot คืน ErrNotFound
+	RemovePublishedProject(ctx context.Context, a ModerationAction) error
+	// ListActions คืนประวัติการกระทำใหม่สุดก่อน
+	ListActions(ctx context.Context, f ActionFilter, limit, offset int) ([]ModerationAction, error)
+}
+
+// AuditStore จัดการ audit_events (append-only)
+type AuditStore interface {
+	Record(ctx context.Context, e AuditEvent) error
+	// List คืน event ใหม่สุดก่อน พร้อมจำนวนทั้งหมดที่ตรงเงื่อนไข
+	List(ctx context.Context, f AuditFilter, limit, offset int) ([]AuditEvent, int, error)
+}
+
+// OutboxStore คือคิวอีเมล (mail_outbox) ที่ mail.Worker ดึงไปส่ง
+type OutboxStore interface {
+	Enqueue(ctx context.Context, m OutboxMessage) error
+	// Claim จองอีเมลที่ถึงกำหนดส่งไม่เกิน limit ฉบับไว้ lease — กันไม่ให้หลาย worker ส่งฉบับเดียวกันซ้ำ
+	Claim(ctx context.Context, lease time.Duration, limit int) ([]OutboxMessage, error)
+	// MarkSent / MarkFailed ปิดงานและล้างเนื้อหา (มี OTP / รหัสจริงที่ไม่ควรค้างไว้)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, attempts int, lastErr string) error
+	// MarkRetry ปล่อย lease แล้วเลื่อนการส่งครั้งถัดไปออกไป retryIn
+	MarkRetry(ctx context.Context, id int64, attempts int, lastErr string, retryIn time.Duration) error
+}
@@ -0,0 +1,88 @@
+// Package tracing ตั้งค่า OpenTelemetry ตาม TRACING_EXPORTER (none | stdout | otlp)
+// span ของ request มาจาก otelgin และ span ของ query มาจาก OpenDB (otelsql)
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+
+	"backend/config"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup ตั้ง TracerProvider / propagator ของทั้ง process แล้วคืนฟังก์ชัน shutdown (flush span ที่ค้าง)
+// exporter none = ไม่ตั้งอะไร otel ใช้ no-op tracer เดิม
+func Setup(ctx context.Context, cfg config.Tracing) (func(context.Context) error, error) {
+	if cfg.Exporter == "" || cfg.Exporter == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("tracing: ไม่รู้จัก exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: สร้าง exporter %s ไม่ได้: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// OpenDB เหมือน sql.Open แต่ทุก query / exec / transaction เป็น span (ลูกของ span ใน ctx ที่ส่งเข้าไป)
+// query ที่ไม่มี span แม่ (เช่น mail worker, rate limit store) จะไม่ถูกบันทึก เพื่อไม่ให้ trace รก
+func OpenDB(driverName, dsn string) (*sql.DB, error) {
+	system := semconv.DBSystemNamePostgreSQL
+	if driverName == "sqlite" {
+		system = semconv.DBSystemNameSQLite
+	}
+	return otelsql.Open(driverName, dsn,
+		otelsql.WithAttributes(system),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitRows:             true,
+			OmitConnPrepare:      true,
+			OmitConnResetSession: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Action ใน audit_events — ตั้งชื่อแบบ <หมวด>.<เหตุการณ์>
+const (
+	AuditRegister               = "auth.register"
+	AuditLogin                  = "auth.login"
+	AuditLoginFailed            = "auth.login_failed"
+	AuditLoginLocked            = "auth.account_locked"
+	AuditLoginTwoFactorFailed   = "auth.2fa_failed"
+	AuditLogout                 = "auth.logout"
+	AuditRefreshTokenReuse      = "auth.refresh_token_reuse"
+	AuditEmailVerified          = "auth.email_verified"
+	AuditPasswordResetRequested = "auth.password_reset_requested"
+	AuditPasswordReset          = "auth.password_reset"
+	AuditPasswordChanged        = "account.password_changed"
+	AuditEmailChangeRequested   = "account.email_change_requested"
+	AuditEmailChanged           = "account.email_changed"
+	AuditTwoFactorEnabled       = "account.2fa_enabled"
+	AuditTwoFactorDisabled      = "account.2fa_disabled"
+	AuditRecoveryCodesRenewed   = "account.recovery_codes_regenerated"
+	AuditProfileUpdated         = "account.profile_updated"
+	AuditDashboardPublished     = "account.dashboard_published"
+	AuditDashboardUnpublished   = "account.dashboard_unpublished"
+	AuditSessionRevoked         = "account.session_revoked"
+	AuditSessionsRevoked        = "account.sessions_revoked"
+	AuditAccountDeleted         = "account.deleted"
+)
+
+// AuditEvent คือเหตุการณ์ 1 แถวใน audit_events
+// UserID = บัญชีที่ถูกกระทำ, ActorID = คนที่ทำ (0 = ไม่ทราบ เช่น login ผิดจากคนนอก)
+type AuditEvent struct {
+	UserID   int
+	ActorID  int
+	Action   string
+	Metadata map[string]interface{}
+}
+
+// RecordAuditEvent บันทึก event พร้อม IP / User-Agent ของ request — บันทึกไม่สำเร็จไม่ทำให้ request ล้ม (แค่ log ไว้)
+func RecordAuditEvent(audit store.AuditStore, c *gin.Context, ev AuditEvent) {
+	metadata := ev.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(metadata)
+	if err == nil {
+		err = audit.Record(c.Request.Context(), store.AuditEvent{
+			UserID:    ev.UserID,
+			ActorID:   ev.ActorID,
+			Action:    ev.Action,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Metadata:  string(raw),
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "บันทึก audit event ไม่สำเร็จ", "action", ev.Action, "error", err)
+	}
+}
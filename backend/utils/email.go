@@ -1,38 +1,66 @@
 package utils
 
 import (
-	"fmt"
-	"net/smtp"
+	"context"
+	"time"
+
+	"backend/mail"
+	"backend/store"
+)
+
+// ชื่อ template ใน mail/templates (<name>.<locale>.tmpl)
+const (
+	EmailPasswordResetOTP = "password_reset_otp"
+	EmailVerify           = "email_verify"
+	EmailChange           = "email_change"
+	EmailChangeTaken      = "email_change_taken"
+	EmailPasswordChanged  = "password_changed"
+	EmailAccountDeleted   = "account_deleted"
+	EmailAccountLocked    = "account_locked"
 )
 
-func SendOTPEmail(toEmail string, otp string) error {
-	// ⚠️ แก้ไขข้อมูลตรงนี้
-	from := "nutpintopro@gmail.com"   // ใส่ Gmail ของนาย
-	password := "uxio upcy qprf yrwu" // รหัส App Password 16 หลักที่นายเพิ่งได้มา
-
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587" // ✅ แก้จาก 5876 เป็น 587 (พอร์ตมาตรฐาน SMTP)
-
-	subject := "Subject: PortHub Verification Code\n"
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	body := fmt.Sprintf(`
-        <div style="font-family: sans-serif; max-width: 400px; margin: auto; padding: 20px; border: 1px solid #eee; border-radius: 10px;">
-            <h2 style="color: #1d7cf2; text-align: center;">PortHub</h2>
-            <p>Your verification code is:</p>
-            <div style="background: #f1f7ff; padding: 20px; text-align: center; font-size: 32px; font-weight: bold; letter-spacing: 5px; color: #000; border-radius: 8px;">
-                %s
-            </div>
-            <p style="font-size: 12px; color: #888; margin-top: 20px;">This code will expire in 5 minutes.</p>
-        </div>
-    `, otp)
-
-	message := []byte(subject + mime + body)
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-
-	// สั่งส่งเมล
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{toEmail}, message)
+// SendOTPEmail ใส่อีเมลรหัส OTP (template = EmailPasswordResetOTP / EmailVerify / EmailChange) ลงคิว outbox
+// ในภาษาที่ผู้ใช้เลือก — mail.Worker เป็นคนส่งจริง
+func SendOTPEmail(ctx context.Context, outbox store.OutboxStore, template, toEmail, locale, otp string) error {
+	return sendTemplateEmail(ctx, outbox, template, toEmail, locale, map[string]interface{}{
+		"OTP":     otp,
+		"Minutes": int(OTPTTL().Minutes()),
+	})
+}
+
+// SendEmailChangeTakenEmail แจ้งเจ้าของอีเมลว่ามีคนขอเปลี่ยนอีเมลของบัญชีอื่นมาเป็นอีเมลนี้
+// ใช้แทนการตอบ 409 — ผู้ขอจะไม่รู้ว่าอีเมลนี้มีบัญชีอยู่แล้วหรือไม่
+func SendEmailChangeTakenEmail(ctx context.Context, outbox store.OutboxStore, toEmail, locale string) error {
+	return sendTemplateEmail(ctx, outbox, EmailChangeTaken, toEmail, locale, map[string]interface{}{
+		"Email": toEmail,
+	})
+}
+
+// SendAccountLockedEmail แจ้งเจ้าของบัญชีว่ามีการใส่รหัสผ่านผิดหลายครั้งจนบัญชีถูกล็อกชั่วคราว
+func SendAccountLockedEmail(ctx context.Context, outbox store.OutboxStore, toEmail, locale string, lockedFor time.Duration) error {
+	return sendTemplateEmail(ctx, outbox, EmailAccountLocked, toEmail, locale, map[string]interface{}{
+		"Minutes": int(lockedFor.Minutes()),
+	})
+}
+
+// SendPasswordChangedEmail แจ้งว่ารหัสผ่านถูกเปลี่ยน (ทั้งจากหน้าโปรไฟล์และจาก reset password)
+func SendPasswordChangedEmail(ctx context.Context, outbox store.OutboxStore, toEmail, locale string) error {
+	return sendTemplateEmail(ctx, outbox, EmailPasswordChanged, toEmail, locale, map[string]interface{}{
+		"Email": toEmail,
+	})
+}
+
+// SendAccountDeletedEmail แจ้งว่าบัญชีถูกลบแล้ว
+func SendAccountDeletedEmail(ctx context.Context, outbox store.OutboxStore, toEmail, locale string) error {
+	return sendTemplateEmail(ctx, outbox, EmailAccountDeleted, toEmail, locale, map[string]interface{}{
+		"Email": toEmail,
+	})
+}
+
+func sendTemplateEmail(ctx context.Context, outbox store.OutboxStore, template, toEmail, locale string, data map[string]interface{}) error {
+	msg, err := mail.Render(template, locale, toEmail, data)
 	if err != nil {
 		return err
 	}
-	return nil
+	return mail.Enqueue(ctx, outbox, msg)
 }
@@ -1,30 +1,60 @@
 package utils
 
 import (
-	"os"
+	"backend/config"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// อายุของ access token — ต่ออายุด้วย refresh token ผ่าน /token/refresh
+const AccessTokenTTL = 15 * time.Minute
+
+// Claims คือ payload ของ access token
+// SessionID อ้างถึงแถวใน sessions — ถ้า session ถูก revoke แล้ว token จะใช้ไม่ได้ทันที
+// Role คือ users.role ณ ตอนออก token (เปลี่ยน role แล้วมีผลเมื่อ refresh ครั้งถัดไป)
+type Claims struct {
+	SessionID string `json:"sid"`
+	Role      string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// getJWTKey — ค่ามาจาก config (JWT_SECRET) ซึ่ง production บังคับให้ตั้งเอง
 func getJWTKey() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// fallback สำหรับ dev — production ต้องตั้ง JWT_SECRET ใน env
-		secret = "porthub_dev_secret_change_in_production_2024"
-	}
-	return []byte(secret)
+	return []byte(config.Current().Auth.JWTSecret)
 }
 
-func GenerateToken(email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+func GenerateToken(userID string, sessionID string, role string) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
 
-	claims := jwt.RegisteredClaims{
-		Subject:   email,
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	claims := Claims{
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(getJWTKey())
 }
+
+// ParseToken ตรวจ signature/วันหมดอายุ แล้วคืน claims
+func ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return getJWTKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"backend/config"
+	"time"
+)
+
+// ค่าของการล็อกบัญชีเมื่อ login ผิดมาจาก config — ปรับได้ด้วย env LOGIN_MAX_FAILURES, LOGIN_LOCKOUT, LOGIN_LOCKOUT_MAX
+// LoginMaxFailures คือจำนวนครั้งที่ใส่รหัสผ่านผิดติดกันได้ก่อนบัญชีถูกล็อก
+func LoginMaxFailures() int {
+	return config.Current().Auth.LoginMaxFailures
+}
+
+func loginLockoutBase() time.Duration {
+	return time.Duration(config.Current().Auth.LoginLockout)
+}
+
+func loginLockoutMax() time.Duration {
+	return time.Duration(config.Current().Auth.LoginLockoutMax)
+}
+
+// LoginLockoutDuration คืนระยะเวลาล็อกตามจำนวนครั้งที่ผิดติดกัน
+// ยังไม่ถึง LoginMaxFailures = 0 (ไม่ล็อก) จากนั้นเพิ่มเป็นเท่าตัวทุกครั้งที่ผิดซ้ำ แต่ไม่เกิน LOGIN_LOCKOUT_MAX
+func LoginLockoutDuration(failures int) time.Duration {
+	over := failures - LoginMaxFailures()
+	if over < 0 {
+		return 0
+	}
+
+	d, max := loginLockoutBase(), loginLockoutMax()
+	for i := 0; i < over && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
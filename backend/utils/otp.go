@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"backend/config"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"time"
+)
+
+// ค่าของ OTP มาจาก config — ปรับได้ด้วย env OTP_LENGTH, OTP_TTL, OTP_MAX_ATTEMPTS
+// OTPLength คือจำนวนหลักของ OTP (4-8)
+func OTPLength() int {
+	return config.Current().Auth.OTPLength
+}
+
+// OTPTTL คืออายุของ OTP — ใช้ทั้งตอนออกรหัสและในข้อความอีเมล
+func OTPTTL() time.Duration {
+	return time.Duration(config.Current().Auth.OTPTTL)
+}
+
+// OTPMaxAttempts คือจำนวนครั้งที่กรอกผิดได้ก่อนรหัสจะถูกยกเลิก
+func OTPMaxAttempts() int {
+	return config.Current().Auth.OTPMaxAttempts
+}
+
+// GenerateOTP สุ่มรหัสตัวเลขตามจำนวนหลักด้วย crypto/rand
+func GenerateOTP(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
+
+// HashOTP คืน HMAC-SHA256 (hex) ของรหัส — ใช้ key ลับเพื่อให้ hash ของรหัสสั้นๆ brute force จาก DB ไม่ได้
+func HashOTP(code string) string {
+	mac := hmac.New(sha256.New, otpHashKey())
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// otpHashKey แยก key ของ OTP ออกจาก JWT_SECRET แบบเดียวกับ totpEncryptionKey — ไม่ใช้ key เดียวกันข้ามงาน
+func otpHashKey() []byte {
+	sum := sha256.Sum256([]byte("otp:" + string(getJWTKey())))
+	return sum[:]
+}
+
+// CheckOTP เทียบรหัสที่ผู้ใช้กรอกกับ hash ใน DB แบบ constant time
+func CheckOTP(code, hash string) bool {
+	return hmac.Equal([]byte(HashOTP(code)), []byte(hash))
+}
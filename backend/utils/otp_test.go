@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashOTP(t *testing.T) {
+	hash := HashOTP("1234")
+	if !CheckOTP("1234", hash) {
+		t.Fatal("CheckOTP rejected the right code")
+	}
+	if CheckOTP("1235", hash) {
+		t.Fatal("CheckOTP accepted a wrong code")
+	}
+
+	// key ต้องไม่ใช่ JWT_SECRET ตรงๆ
+	mac := hmac.New(sha256.New, getJWTKey())
+	mac.Write([]byte("1234"))
+	if hash == hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatal("OTP hash uses the JWT secret as its key")
+	}
+}
@@ -24,10 +24,11 @@ func Success(c *gin.Context, code int, message string, data interface{}) {
 // ------------------------------
 func Error(c *gin.Context, code int, message string, err interface{}) {
 	c.JSON(code, gin.H{
-		"status":  "error",
-		"message": message,
-		"data":    nil,
-		"error":   err,
+		"status":     "error",
+		"message":    message,
+		"data":       nil,
+		"error":      err,
+		"request_id": RequestID(c),
 	})
 }
 
@@ -36,10 +37,11 @@ func Error(c *gin.Context, code int, message string, err interface{}) {
 // ------------------------------
 func BadRequest(c *gin.Context, message string) {
 	c.JSON(400, gin.H{
-		"status":  "error",
-		"message": message,
-		"data":    nil,
-		"error":   "BAD_REQUEST",
+		"status":     "error",
+		"message":    message,
+		"data":       nil,
+		"error":      "BAD_REQUEST",
+		"request_id": RequestID(c),
 	})
 }
 
@@ -48,10 +50,11 @@ func BadRequest(c *gin.Context, message string) {
 // ------------------------------
 func Unauthorized(c *gin.Context, message string) {
 	c.JSON(401, gin.H{
-		"status":  "error",
-		"message": message,
-		"data":    nil,
-		"error":   "UNAUTHORIZED",
+		"status":     "error",
+		"message":    message,
+		"data":       nil,
+		"error":      "UNAUTHORIZED",
+		"request_id": RequestID(c),
 	})
 }
 
@@ -60,13 +63,31 @@ func Unauthorized(c *gin.Context, message string) {
 // ------------------------------
 func Internal(c *gin.Context, message string) {
 	c.JSON(500, gin.H{
-		"status":  "error",
-		"message": message,
-		"data":    nil,
-		"error":   "INTERNAL_ERROR",
+		"status":     "error",
+		"message":    message,
+		"data":       nil,
+		"error":      "INTERNAL_ERROR",
+		"request_id": RequestID(c),
+	})
+}
+
+// ------------------------------
+// ERROR JSON (handlers / middleware)
+// ------------------------------
+// ErrorJSON ตอบ error แบบ {"error": message, "request_id": ...} ที่ handlers และ middleware ใช้
+// request_id ให้ผู้ใช้แจ้ง support แล้วค้นใน log ได้ตรงบรรทัด
+func ErrorJSON(c *gin.Context, code int, message string) {
+	c.JSON(code, gin.H{
+		"error":      message,
+		"request_id": RequestID(c),
 	})
 }
 
+// RequestID คืน X-Request-ID ของ request นี้ (ตั้งโดย middleware.RequestID)
+func RequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
 // ------------------------------
 // HELPER: extract token
 // ------------------------------
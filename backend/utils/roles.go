@@ -0,0 +1,28 @@
+package utils
+
+// บทบาทของผู้ใช้ (users.role)
+const (
+	RoleStudent         = "student"          // เจ้าของ portfolio
+	RoleRecruiter       = "recruiter"        // ดู Dashboard ได้ แต่ไม่มี portfolio
+	RoleUniversityAdmin = "university_admin" // ดูแลผู้ใช้ของมหาวิทยาลัยตัวเอง
+	RolePlatformAdmin   = "platform_admin"   // ดูแลทั้งระบบ
+)
+
+// IsValidRole ตรวจว่า role เป็นหนึ่งในบทบาทที่ระบบรู้จัก
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleStudent, RoleRecruiter, RoleUniversityAdmin, RolePlatformAdmin:
+		return true
+	}
+	return false
+}
+
+// IsSelfRegisterRole คือบทบาทที่เลือกเองได้ตอนสมัคร (admin ต้องตั้งให้โดยผู้ดูแลระบบ)
+func IsSelfRegisterRole(role string) bool {
+	return role == RoleStudent || role == RoleRecruiter
+}
+
+// IsAdminRole คือบทบาทที่ใช้ admin API ได้ — มหาวิทยาลัยของบัญชีเหล่านี้เป็น scope ของสิทธิ์ จึงแก้เองไม่ได้
+func IsAdminRole(role string) bool {
+	return role == RoleUniversityAdmin || role == RolePlatformAdmin
+}
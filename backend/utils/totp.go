@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"backend/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP ตาม RFC 6238: HMAC-SHA1, 6 หลัก, ช่วงละ 30 วินาที (ค่าที่ authenticator app ทั่วไปรองรับ)
+const (
+	totpDigits = 6
+	totpPeriod = 30
+	totpSkew   = 1 // ยอมรับรหัสของช่วงก่อน/หลัง 1 ช่วง เผื่อนาฬิกาเครื่องผู้ใช้คลาด
+	totpIssuer = "PortHub"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret สุ่ม secret 160 bit แล้วเข้ารหัสเป็น base32 (รูปแบบที่ authenticator app ใช้)
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI สร้าง otpauth:// URI สำหรับทำ QR code ให้ authenticator app สแกน
+func TOTPProvisioningURI(secret, accountName string) string {
+	label := url.PathEscape(totpIssuer + ":" + accountName)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprintf("%d", totpDigits))
+	q.Set("period", fmt.Sprintf("%d", totpPeriod))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// ValidateTOTP ตรวจรหัส 6 หลัก คืน time step ที่ตรงกัน (ใช้กันการนำรหัสเดิมมาใช้ซ้ำ)
+// รหัสต้องอยู่ใน step ที่มากกว่า lastUsedStep เท่านั้น
+func ValidateTOTP(secret, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode คำนวณรหัสของ time step ตาม RFC 4226 (HOTP) section 5.3
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// GenerateRecoveryCode สุ่ม recovery code รูปแบบ xxxxx-xxxxx (50 bit)
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+// NormalizeRecoveryCode ตัดช่องว่าง/ขีด และแปลงเป็นตัวเล็ก ก่อนนำไป hash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+// totpEncryptionKey — ตั้ง TOTP_ENCRYPTION_KEY แยกได้ ถ้าไม่ตั้งจะ derive จาก JWT secret
+func totpEncryptionKey() []byte {
+	secret := config.Current().Auth.TOTPEncryptionKey
+	if secret == "" {
+		secret = string(getJWTKey())
+	}
+	sum := sha256.Sum256([]byte("totp:" + secret))
+	return sum[:]
+}
+
+// EncryptTOTPSecret เข้ารหัส secret ด้วย AES-256-GCM ก่อนเก็บลง DB
+func EncryptTOTPSecret(secret string) (string, error) {
+	block, err := aes.NewCipher(totpEncryptionKey())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptTOTPSecret ถอดรหัส secret ที่เก็บด้วย EncryptTOTPSecret
+func DecryptTOTPSecret(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(totpEncryptionKey())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
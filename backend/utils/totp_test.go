@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret ของ test vector ใน RFC 6238 appendix B (SHA-1)
+const rfc6238Secret = "12345678901234567890"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// รหัสใน RFC เป็น 8 หลัก — รหัส 6 หลักคือ 6 หลักท้ายของค่าเดียวกัน
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got := totpCode([]byte(rfc6238Secret), tt.unix/totpPeriod)
+		if want := tt.want[len(tt.want)-totpDigits:]; got != want {
+			t.Errorf("T=%d: code = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfc6238Secret))
+	now := time.Unix(1111111109, 0)
+	current := now.Unix() / totpPeriod
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code := totpCode([]byte(rfc6238Secret), current+offset)
+		step, ok := ValidateTOTP(secret, code, now, 0)
+		wantOK := offset >= -totpSkew && offset <= totpSkew
+		if ok != wantOK {
+			t.Errorf("step %+d: ok = %v, want %v", offset, ok, wantOK)
+		}
+		if ok && step != current+offset {
+			t.Errorf("step %+d: matched step %d, want %d", offset, step, current+offset)
+		}
+	}
+
+	// secret ตัวเล็ก (บางแอปแสดงแบบนี้) ก็ใช้ได้
+	if _, ok := ValidateTOTP(strings.ToLower(secret), totpCode([]byte(rfc6238Secret), current), now, 0); !ok {
+		t.Error("lower-case secret rejected")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now, 0); ok {
+		t.Error("5-digit code accepted")
+	}
+}
+
+func TestValidateTOTPRejectsReusedStep(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfc6238Secret))
+	now := time.Unix(1234567890, 0)
+	code := totpCode([]byte(rfc6238Secret), now.Unix()/totpPeriod)
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Fatal("same code accepted twice")
+	}
+	// รหัสของช่วงก่อนหน้าที่ยังอยู่ใน skew ก็ใช้ไม่ได้ เพราะเก่ากว่า step ที่ใช้ไปแล้ว
+	if _, ok := ValidateTOTP(secret, totpCode([]byte(rfc6238Secret), step-1), now, step); ok {
+		t.Fatal("older code accepted after a newer one was used")
+	}
+	if _, ok := ValidateTOTP(secret, totpCode([]byte(rfc6238Secret), step+1), now, step); !ok {
+		t.Fatal("next step rejected")
+	}
+}
+
+func TestTOTPSecretEncryptionRoundTrip(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := EncryptTOTPSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := EncryptTOTPSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("two encryptions of the same secret are identical (nonce reused)")
+	}
+	if strings.Contains(a, secret) {
+		t.Error("ciphertext contains the plain secret")
+	}
+
+	for _, enc := range []string{a, b} {
+		got, err := DecryptTOTPSecret(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != secret {
+			t.Fatalf("decrypt = %q, want %q", got, secret)
+		}
+	}
+
+	// แก้ ciphertext แม้แต่ byte เดียว GCM ต้องไม่ยอมถอด
+	tampered := []byte(a)
+	i := len(tampered) / 2
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	if _, err := DecryptTOTPSecret(string(tampered)); err == nil {
+		t.Error("tampered ciphertext decrypted")
+	}
+	if _, err := DecryptTOTPSecret("c2hvcnQ="); err == nil {
+		t.Error("short ciphertext decrypted")
+	}
+}
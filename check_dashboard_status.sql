@@ -1,4 +1,5 @@
 -- ตรวจสอบสถานะ show_on_dashboard ของ users ทั้งหมด
+-- (เทียบเท่า `./server user list` — ไม่ต้องเปิด psql)
 SELECT 
     user_id, 
     user_name, 
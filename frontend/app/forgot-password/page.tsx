@@ -30,18 +30,13 @@ export default function ForgotPasswordPage() {
       // บันทึกอีเมลไว้สำหรับหน้า verify
       sessionStorage.setItem('reset_email', email.trim());
       
-      toast.success('OTP code has been sent to your email');
+      // backend ตอบเหมือนกันทั้งอีเมลที่มีและไม่มีในระบบ (กันการไล่เช็คบัญชี)
+      toast.success('If this email is registered, an OTP code has been sent to it');
       router.push('/verify-email');
     } catch (error) {
       if (error instanceof Error) {
-        const msg = error.message;
-        if (msg.includes('ไม่พบอีเมล') || msg.includes('not found')) {
-          setErrorMessage('This email is not registered. Please check or sign up.');
-          toast.error('This email is not registered');
-        } else {
-          setErrorMessage(msg);
-          toast.error(msg);
-        }
+        setErrorMessage(error.message);
+        toast.error(error.message);
       } else {
         setErrorMessage('Cannot connect to server. Please try again.');
         toast.error("Cannot connect to server. Please try again.");
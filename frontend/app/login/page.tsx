@@ -3,7 +3,7 @@
 import React, { useState, useEffect } from 'react';
 import Link from 'next/link';
 import { useRouter } from 'next/navigation';
-import { motion, AnimatePresence } from 'framer-motion';
+import { motion } from 'framer-motion';
 import toast, { Toaster } from 'react-hot-toast';
 import { authAPI, tokenManager } from '@/lib/api';
 
@@ -12,7 +12,6 @@ export default function LoginPage() {
   const [password, setPassword] = useState("");
   const [showPassword, setShowPassword] = useState(false);
   const [loading, setLoading] = useState(false);
-  const [showPopup, setShowPopup] = useState(false);
   const router = useRouter();
 
   const [particlePositions, setParticlePositions] = useState<Array<{ top: number; left: number; xOffset: number }>>([]);
@@ -42,32 +41,41 @@ export default function LoginPage() {
     setLoading(true);
 
     try {
-      const data = await authAPI.login({
+      let data = await authAPI.login({
         email: email.trim(),
         password: password,
       });
 
+      // เปิด 2FA ไว้ → ขอรหัสจากแอป Authenticator (หรือ recovery code) ก่อน
+      if (data.data?.two_factor_required && data.data.challenge_token) {
+        const code = window.prompt('Enter the 6-digit code from your authenticator app (or a recovery code)');
+        if (!code) {
+          return;
+        }
+        data = await authAPI.loginTwoFactor(data.data.challenge_token, code.trim());
+      }
+
       // บันทึก token
       tokenManager.setToken(data.token);
+      if (data.data?.refresh_token) {
+        tokenManager.setRefreshToken(data.data.refresh_token);
+      }
       
       toast.success('Welcome back!');
       router.push('/dashboard');
     } catch (error) {
       if (error instanceof Error) {
-        if (error.message.includes("ไม่พบอีเมล")) {
-          setShowPopup(true);
-        } else {
-          toast.error(error.message, {
-            style: {
-              borderRadius: '12px',
-              background: '#333',
-              color: '#fff',
-              fontSize: '14px',
-              fontWeight: 'bold',
-            },
-            duration: 3000,
-          });
-        }
+        // backend ตอบข้อความเดียวกันทั้งไม่พบอีเมลและรหัสผ่านผิด (กันการไล่เช็คบัญชี)
+        toast.error(error.message, {
+          style: {
+            borderRadius: '12px',
+            background: '#333',
+            color: '#fff',
+            fontSize: '14px',
+            fontWeight: 'bold',
+          },
+          duration: 3000,
+        });
       } else {
         toast.error("Cannot connect to server. Please try again.");
       }
@@ -329,93 +337,6 @@ export default function LoginPage() {
           </div>
         </motion.div>
       </div>
-
-      {/* Enhanced Popup Modal */}
-      <AnimatePresence>
-        {showPopup && (
-          <motion.div 
-            initial={{ opacity: 0 }}
-            animate={{ opacity: 1 }}
-            exit={{ opacity: 0 }}
-            className="fixed inset-0 z-50 flex items-center justify-center p-4 bg-black/40 backdrop-blur-lg"
-            onClick={() => setShowPopup(false)}
-          >
-            <motion.div
-              initial={{ opacity: 0, scale: 0.8, y: 50 }}
-              animate={{ opacity: 1, scale: 1, y: 0 }}
-              exit={{ opacity: 0, scale: 0.8, y: 50 }}
-              transition={{ type: "spring", stiffness: 300, damping: 25 }}
-              onClick={(e) => e.stopPropagation()}
-              className="bg-white/90 backdrop-blur-2xl w-full max-w-md p-10 rounded-3xl shadow-2xl text-center relative overflow-hidden border-2 border-white/60"
-            >
-              {/* Decorative Elements */}
-              <motion.div
-                animate={{ rotate: [0, 360], scale: [1, 1.2, 1] }}
-                transition={{ duration: 20, repeat: Infinity, ease: "linear" }}
-                className="absolute top-0 right-0 w-32 h-32 bg-gradient-to-br from-[#1d7cf2]/10 to-purple-500/10 rounded-full blur-3xl"
-              />
-
-              <div className="relative z-10">
-                <motion.div 
-                  initial={{ scale: 0, rotate: -180 }}
-                  animate={{ scale: 1, rotate: 0 }}
-                  transition={{ type: "spring", stiffness: 200, damping: 15 }}
-                  className="w-20 h-20 bg-gradient-to-r from-blue-50 to-purple-50 text-[#1d7cf2] rounded-2xl flex items-center justify-center mx-auto mb-6 shadow-xl"
-                >
-                  <svg xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" strokeWidth={2} stroke="currentColor" className="w-10 h-10">
-                    <path strokeLinecap="round" strokeLinejoin="round" d="M9.879 7.519c1.171-1.025 3.071-1.025 4.242 0 1.172 1.025 1.172 2.687 0 3.712-.203.179-.43.326-.67.442-.745.361-1.45.999-1.45 1.827v.75M21 12a9 9 0 1 1-18 0 9 9 0 0 1 18 0Zm-9 5.25h.008v.008H12v-.008Z" />
-                  </svg>
-                </motion.div>
-
-                <motion.h3 
-                  initial={{ opacity: 0, y: 10 }}
-                  animate={{ opacity: 1, y: 0 }}
-                  transition={{ delay: 0.1 }}
-                  className="text-2xl font-black text-gray-900 mb-3"
-                >
-                  Account not found
-                </motion.h3>
-                
-                <motion.p 
-                  initial={{ opacity: 0, y: 10 }}
-                  animate={{ opacity: 1, y: 0 }}
-                  transition={{ delay: 0.2 }}
-                  className="text-sm text-gray-600 font-semibold mb-8 leading-relaxed"
-                >
-                  It looks like you&apos;re not a member yet.<br/>
-                  Sign up now to get started.
-                </motion.p>
-
-                <div className="flex flex-col space-y-3">
-                  <Link href="/register">
-                    <motion.button
-                      whileHover={{ scale: 1.05, y: -2 }}
-                      whileTap={{ scale: 0.95 }}
-                      className="relative w-full py-4 bg-gradient-to-r from-[#1d7cf2] to-purple-500 text-white font-black rounded-2xl shadow-xl shadow-blue-300/40 transition-all overflow-hidden group"
-                    >
-                      <motion.div
-                        className="absolute inset-0 bg-gradient-to-r from-transparent via-white/30 to-transparent"
-                        animate={{ x: ['-200%', '200%'] }}
-                        transition={{ duration: 2, repeat: Infinity, ease: "linear" }}
-                      />
-                      <span className="relative z-10">Sign up</span>
-                    </motion.button>
-                  </Link>
-                  
-                  <motion.button 
-                    onClick={() => setShowPopup(false)}
-                    whileHover={{ scale: 1.05 }}
-                    whileTap={{ scale: 0.95 }}
-                    className="w-full py-4 text-gray-500 font-black hover:text-gray-900 transition-colors rounded-2xl hover:bg-gray-100"
-                  >
-                    Cancel
-                  </motion.button>
-                </div>
-              </div>
-            </motion.div>
-          </motion.div>
-        )}
-      </AnimatePresence>
     </div>
   );
 }
\ No newline at end of file
@@ -13,15 +13,15 @@ export default function ConfirmPasswordPage() {
   const [formData, setFormData] = useState({ newPassword: "", confirmPassword: "" });
   const [loading, setLoading] = useState(false);
   const [isSuccess, setIsSuccess] = useState(false);
-  const [email, setEmail] = useState("");
+  const [resetToken, setResetToken] = useState("");
 
   useEffect(() => {
-    const savedEmail = sessionStorage.getItem('reset_email');
-    if (!savedEmail) {
+    const savedToken = sessionStorage.getItem('reset_token');
+    if (!savedToken) {
       router.push('/forgot-password');
       return;
     }
-    setEmail(savedEmail);
+    setResetToken(savedToken);
   }, [router]);
 
   const getPasswordStrength = (pwd: string) => {
@@ -55,10 +55,11 @@ export default function ConfirmPasswordPage() {
 
     setLoading(true);
     try {
-      await authAPI.resetPassword(email, formData.newPassword);
+      await authAPI.resetPassword(resetToken, formData.newPassword);
       
       setIsSuccess(true);
       sessionStorage.removeItem('reset_email');
+      sessionStorage.removeItem('reset_token');
       toast.success('Password changed successfully!');
       
       setTimeout(() => router.push('/login'), 3000);
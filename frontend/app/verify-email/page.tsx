@@ -73,7 +73,8 @@ export default function VerifyEmailPage() {
 
     setLoading(true);
     try {
-      await authAPI.verifyOTP(email, otpCode);
+      const resetToken = await authAPI.verifyOTP(email, otpCode);
+      sessionStorage.setItem('reset_token', resetToken);
       
       toast.success('Code verified successfully! 🎉', {
         duration: 2000,
@@ -19,9 +19,42 @@ export const clearCache = () => {
   cache.clear();
 };
 
+// ขอ access token ใหม่ด้วย refresh token (refresh token จะถูกหมุนทุกครั้ง)
+// ใช้ promise เดียวกันถ้ามีหลาย request เจอ 401 พร้อมกัน
+let refreshPromise: Promise<boolean> | null = null;
+
+async function refreshSession(): Promise<boolean> {
+  const refreshToken = localStorage.getItem('refresh_token');
+  if (!refreshToken) return false;
+
+  if (!refreshPromise) {
+    refreshPromise = (async () => {
+      try {
+        const response = await fetch(`${API_BASE_URL}/token/refresh`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+          cache: 'no-store',
+        });
+        if (!response.ok) return false;
+        const data = await response.json() as { data?: { token?: string; refresh_token?: string } };
+        if (!data?.data?.token || !data.data.refresh_token) return false;
+        localStorage.setItem('token', data.data.token);
+        localStorage.setItem('refresh_token', data.data.refresh_token);
+        return true;
+      } catch (_e) {
+        return false;
+      } finally {
+        refreshPromise = null;
+      }
+    })();
+  }
+  return refreshPromise;
+}
+
 // ฟังก์ชันช่วยสำหรับการเรียก API
 // bypassCache = true → ข้าม cache และดึงข้อมูลสดจาก server เสมอ
-async function fetchAPI(endpoint: string, options: RequestInit = {}, bypassCache = false) {
+async function fetchAPI(endpoint: string, options: RequestInit = {}, bypassCache = false, retried = false): Promise<unknown> {
   const url = `${API_BASE_URL}${endpoint}`;
   const isGet = (options.method || 'GET').toUpperCase() === 'GET';
 
@@ -66,10 +99,15 @@ async function fetchAPI(endpoint: string, options: RequestInit = {}, bypassCache
     }
 
     if (!response.ok) {
+      // access token หมดอายุ → ต่ออายุแล้วลองใหม่ 1 ครั้ง
+      if (response.status === 401 && token && !retried && await refreshSession()) {
+        return fetchAPI(endpoint, options, bypassCache, true);
+      }
       const errMsg = (data as { error?: string })?.error || 'เกิดข้อผิดพลาด';
       if (response.status === 401 || String(errMsg).includes('Invalid')) {
         if (typeof window !== 'undefined') {
           localStorage.removeItem('token');
+          localStorage.removeItem('refresh_token');
         }
       }
       throw new Error(errMsg);
@@ -112,6 +150,15 @@ export interface LoginData {
 export interface LoginResponse {
   message: string;
   token: string;
+  data?: {
+    token: string;
+    refresh_token: string;
+    expires_in: number;
+    email_verified: boolean;
+    // เปิด 2FA → ได้ challenge_token แทน token ต้องส่งต่อให้ loginTwoFactor
+    two_factor_required?: boolean;
+    challenge_token?: string;
+  };
 }
 
 export const authAPI = {
@@ -123,16 +170,26 @@ export const authAPI = {
     return fetchAPI('/login', { method: 'POST', body: JSON.stringify(data) }) as Promise<LoginResponse>;
   },
 
+  // ขั้นที่สองของ login เมื่อเปิด 2FA: ส่งรหัส 6 หลักจากแอป หรือ recovery code (รูปแบบ xxxxx-xxxxx)
+  loginTwoFactor: async (challengeToken: string, code: string): Promise<LoginResponse> => {
+    const body = code.includes('-')
+      ? { challenge_token: challengeToken, recovery_code: code }
+      : { challenge_token: challengeToken, code };
+    return fetchAPI('/login/2fa', { method: 'POST', body: JSON.stringify(body) }) as Promise<LoginResponse>;
+  },
+
   forgotPassword: async (email: string) => {
     return fetchAPI('/forgot-password', { method: 'POST', body: JSON.stringify({ email }) });
   },
 
-  verifyOTP: async (email: string, otp: string) => {
-    return fetchAPI('/verify-otp', { method: 'POST', body: JSON.stringify({ email, otp }) });
+  // คืน reset_token (ใช้ได้ครั้งเดียว) สำหรับส่งต่อให้ resetPassword
+  verifyOTP: async (email: string, otp: string): Promise<string> => {
+    const res = await fetchAPI('/verify-otp', { method: 'POST', body: JSON.stringify({ email, otp }) }) as { data?: { reset_token?: string } };
+    return res?.data?.reset_token || '';
   },
 
-  resetPassword: async (email: string, password: string) => {
-    return fetchAPI('/reset-password', { method: 'POST', body: JSON.stringify({ email, password }) });
+  resetPassword: async (resetToken: string, password: string) => {
+    return fetchAPI('/reset-password', { method: 'POST', body: JSON.stringify({ reset_token: resetToken, password }) });
   },
 };
 
@@ -279,8 +336,13 @@ export const tokenManager = {
     return localStorage.getItem('token');
   },
 
+  setRefreshToken: (refreshToken: string) => {
+    localStorage.setItem('refresh_token', refreshToken);
+  },
+
   removeToken: () => {
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
   },
 
   hasToken: (): boolean => {
@@ -298,7 +360,15 @@ export const requireAuth = () => {
   return true;
 };
 
-export const logout = () => {
+export const logout = async () => {
+  // revoke session ฝั่ง server ด้วย — ถ้าไม่สำเร็จก็ยังลบ token ในเครื่องตามเดิม
+  if (tokenManager.hasToken()) {
+    try {
+      await fetchAPI('/logout', { method: 'POST' });
+    } catch (_e) {
+      // ignore
+    }
+  }
   tokenManager.removeToken();
   window.location.href = '/login';
 };